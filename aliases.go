@@ -0,0 +1,191 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/actgardner/gogen-avro/v10/schema"
+)
+
+// readerAliases holds the aliases declared by a reader schema.
+type readerAliases struct {
+	// types maps from an alias of a named type in the reader
+	// schema to the reader's name for that type.
+	types map[schema.QualifiedName]schema.QualifiedName
+
+	// fields maps from the name of a record in the reader
+	// schema to the aliases of its fields.
+	fields map[schema.QualifiedName]*fieldAliases
+}
+
+// fieldAliases holds the field names and aliases of a reader record.
+type fieldAliases struct {
+	// names holds the names of all the fields.
+	names map[string]bool
+	// aliases maps from a field alias to the name of the field.
+	aliases map[string]string
+}
+
+// resolveAliases returns a version of the writer type in which the
+// names of named types and record fields are replaced by the names
+// used in the reader type when the reader declares them as aliases,
+// as described in the Avro specification:
+// https://avro.apache.org/docs/1.9.1/spec.html#Aliases
+//
+// Aliases of named types are fully qualified, with namespace-relative
+// aliases taking the namespace of the type they're declared in,
+// so a writer type only matches an alias if its full name does.
+//
+// If the reader declares no aliases that match, the writer type is
+// returned unchanged. It's an error if renaming would give two
+// distinct writer types the same name.
+func resolveAliases(writer, reader *Type) (*Type, error) {
+	ra := &readerAliases{
+		types:  make(map[schema.QualifiedName]schema.QualifiedName),
+		fields: make(map[schema.QualifiedName]*fieldAliases),
+	}
+	ra.add(reader.avroType, make(map[schema.QualifiedName]bool))
+	if len(ra.types) == 0 && len(ra.fields) == 0 {
+		return writer, nil
+	}
+	changed := false
+	schemaVal, err := ra.rename(writer.avroType, "", make(map[schema.QualifiedName]schema.QualifiedName), &changed)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return writer, nil
+	}
+	data, err := json.Marshal(schemaVal)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal writer schema with aliases resolved: %v", err)
+	}
+	t, err := ParseType(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse writer schema with aliases resolved: %v", err)
+	}
+	return t, nil
+}
+
+// add adds the aliases found in the reader type at to ra.
+func (ra *readerAliases) add(at schema.AvroType, visited map[schema.QualifiedName]bool) {
+	switch at := at.(type) {
+	case *schema.Reference:
+		if visited[at.TypeName] {
+			return
+		}
+		visited[at.TypeName] = true
+		for _, alias := range at.Def.Aliases() {
+			ra.types[alias] = at.TypeName
+		}
+		def, ok := at.Def.(*schema.RecordDefinition)
+		if !ok {
+			return
+		}
+		fa := &fieldAliases{
+			names:   make(map[string]bool),
+			aliases: make(map[string]string),
+		}
+		for _, f := range def.Fields() {
+			fa.names[f.Name()] = true
+			for _, alias := range f.Aliases() {
+				fa.aliases[alias] = f.Name()
+			}
+			ra.add(f.Type(), visited)
+		}
+		if len(fa.aliases) > 0 {
+			ra.fields[at.TypeName] = fa
+		}
+	case *schema.UnionField:
+		for _, item := range at.ItemTypes() {
+			ra.add(item, visited)
+		}
+	case *schema.ArrayField:
+		ra.add(at.ItemType(), visited)
+	case *schema.MapField:
+		ra.add(at.ItemType(), visited)
+	}
+}
+
+// rename returns the JSON-marshalable schema for the writer type
+// at with aliases resolved. It sets *changed if anything was renamed.
+// The defined map records the types defined so far, mapping from
+// the resolved name of each to its name in the writer schema.
+func (ra *readerAliases) rename(at schema.AvroType, enclosingNamespace string, defined map[schema.QualifiedName]schema.QualifiedName, changed *bool) (interface{}, error) {
+	switch at := at.(type) {
+	case *schema.Reference:
+		qname := at.TypeName
+		if rname, ok := ra.types[qname]; ok && rname != qname {
+			qname = rname
+			*changed = true
+		}
+		if wname, ok := defined[qname]; ok {
+			if wname != at.TypeName {
+				return nil, fmt.Errorf("writer types %s and %s both resolve to %s", wname, at.TypeName, qname)
+			}
+			return relativeName(enclosingNamespace, qname), nil
+		}
+		defined[qname] = at.TypeName
+		def := copyOfSchemaObj(at)
+		if adef, ok := at.Def.(*schema.RecordDefinition); ok {
+			fa := ra.fields[qname]
+			writerFields := make(map[string]bool)
+			for _, f := range adef.Fields() {
+				writerFields[f.Name()] = true
+			}
+			fieldDefs := make([]map[string]interface{}, len(adef.Fields()))
+			for i, f := range adef.Fields() {
+				fieldDef := copyOfSchemaObj(f)
+				// Writer aliases play no part in resolution
+				// and might clash with the renamed fields.
+				delete(fieldDef, "aliases")
+				if fa != nil && !fa.names[f.Name()] {
+					if name, ok := fa.aliases[f.Name()]; ok && !writerFields[name] {
+						fieldDef["name"] = name
+						*changed = true
+					}
+				}
+				ftype, err := ra.rename(f.Type(), qname.Namespace, defined, changed)
+				if err != nil {
+					return nil, err
+				}
+				fieldDef["type"] = ftype
+				fieldDefs[i] = fieldDef
+			}
+			def["fields"] = fieldDefs
+		}
+		delete(def, "namespace")
+		delete(def, "aliases")
+		def["name"] = relativeName(enclosingNamespace, qname)
+		return def, nil
+	case *schema.UnionField:
+		items := make([]interface{}, len(at.ItemTypes()))
+		for i, item := range at.ItemTypes() {
+			itemVal, err := ra.rename(item, enclosingNamespace, defined, changed)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = itemVal
+		}
+		return items, nil
+	case *schema.ArrayField:
+		items, err := ra.rename(at.ItemType(), enclosingNamespace, defined, changed)
+		if err != nil {
+			return nil, err
+		}
+		obj := copyOfSchemaObj(at)
+		obj["items"] = items
+		return obj, nil
+	case *schema.MapField:
+		values, err := ra.rename(at.ItemType(), enclosingNamespace, defined, changed)
+		if err != nil {
+			return nil, err
+		}
+		obj := copyOfSchemaObj(at)
+		obj["values"] = values
+		return obj, nil
+	default:
+		obj, _ := at.Definition(emptyScope())
+		return obj, nil
+	}
+}
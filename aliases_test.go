@@ -0,0 +1,130 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestResolveRecordAndFieldAliases(t *testing.T) {
+	c := qt.New(t)
+	type OldPerson struct {
+		FullName string `json:"full_name"`
+		Years    int    `json:"years"`
+	}
+	type Person struct {
+		Name string `avro:"name,aliases=full_name"`
+		Age  int    `avro:"age,aliases=years"`
+	}
+	wNames := new(avro.Names).RenameType(OldPerson{}, "com.example.OldPerson")
+	data, wType, err := wNames.Marshal(OldPerson{
+		FullName: "Alice",
+		Years:    42,
+	})
+	c.Assert(err, qt.IsNil)
+
+	// The alias is relative to the namespace of the new name.
+	rNames := new(avro.Names).RenameType(Person{}, "com.example.Person", "OldPerson")
+	var p Person
+	_, err = rNames.Unmarshal(data, &p, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(p, qt.DeepEquals, Person{
+		Name: "Alice",
+		Age:  42,
+	})
+}
+
+func TestResolveUnionMemberAliases(t *testing.T) {
+	c := qt.New(t)
+	type OldCircle struct {
+		Side float64
+	}
+	type OldDrawing struct {
+		Main *OldCircle
+	}
+	type Drawing struct {
+		Main Shape
+	}
+	wNames := new(avro.Names).RenameType(OldCircle{}, "old.Circle")
+	data, wType, err := wNames.Marshal(OldDrawing{
+		Main: &OldCircle{Side: 2},
+	})
+	c.Assert(err, qt.IsNil)
+
+	// The writer's old.Circle type is now known as shapes.Square.
+	// Although shapes.Circle has the same unqualified name,
+	// the alias takes precedence.
+	rNames := new(avro.Names).
+		RegisterUnion((*Shape)(nil), nil, Circle{}, Square{}).
+		RenameType(Circle{}, "shapes.Circle").
+		RenameType(Square{}, "shapes.Square", "old.Circle")
+	var d Drawing
+	_, err = rNames.Unmarshal(data, &d, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(d, qt.DeepEquals, Drawing{
+		Main: Square{Side: 2},
+	})
+}
+
+func TestResolveEnumAliases(t *testing.T) {
+	c := qt.New(t)
+	wType, err := avro.ParseType(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "E",
+			"type": {
+				"type": "enum",
+				"name": "old.Color",
+				"symbols": ["y", "z"]
+			}
+		}]
+	}`)
+	c.Assert(err, qt.IsNil)
+	// The encoded value holds symbol index 1 (z).
+	data := []byte{2}
+	type R struct {
+		E EnumC
+	}
+	rNames := new(avro.Names).RenameType(EnumC(0), "new.Color", "old.Color")
+	var r R
+	_, err = rNames.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r.E, qt.Equals, EnumCZ)
+}
+
+func TestResolveAliasesWithClashingWriterTypes(t *testing.T) {
+	c := qt.New(t)
+	// The writer has distinct types under both the old and
+	// the new name, so they can't be merged by renaming.
+	wType, err := avro.ParseType(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "A",
+			"type": {
+				"type": "enum",
+				"name": "old.Color",
+				"symbols": ["y", "z"]
+			}
+		}, {
+			"name": "B",
+			"type": {
+				"type": "enum",
+				"name": "new.Color",
+				"symbols": ["x", "y", "z"]
+			}
+		}]
+	}`)
+	c.Assert(err, qt.IsNil)
+	type R struct {
+		A EnumC
+		B EnumC
+	}
+	rNames := new(avro.Names).RenameType(EnumC(0), "new.Color", "old.Color")
+	var r R
+	_, err = rNames.Unmarshal([]byte{2, 4}, &r, wType)
+	c.Assert(err, qt.ErrorMatches, `.*cannot resolve aliases: writer types old.Color and new.Color both resolve to new.Color`)
+}
@@ -32,19 +32,45 @@ type decodeProgram struct {
 	// directly into the target value (for example when
 	// the target is a struct type).
 	enter []enterFunc
+	// enterField holds the Avro field name for each Enter
+	// instruction in the program that enters a record field,
+	// indexed by pc.
+	enterField []string
+	// unmarshal holds the representation type for each
+	// Enter, AppendArray or AppendMap instruction
+	// in the program that enters a value of a type that
+	// implements AvroUnmarshaler, indexed by pc.
+	unmarshal []reflect.Type
+	// rootUnmarshal holds the representation type of
+	// the top level value if it implements AvroUnmarshaler.
+	rootUnmarshal reflect.Type
+	// rootWrapped holds whether the top level value
+	// is held in the single field of a wrapper type
+	// (see avrotypegen.AvroWrapper).
+	rootWrapped bool
 	// makeDefault holds an entry for each SetDefault instruction
 	// in the program, indexed by pc, that gets the default
 	// value for a field.
 	makeDefault []func() reflect.Value
+	// defaultField holds the index sequence of the struct
+	// field set by each SetDefault instruction, indexed by pc.
+	defaultField [][]int
 
 	readerType *Type
+	writerType *Type
 }
 
 type analyzer struct {
-	prog        *vm.Program
-	pcInfo      []pcInfo
-	enter       []enterFunc
-	makeDefault []func() reflect.Value
+	prog         *vm.Program
+	pcInfo       []pcInfo
+	enter        []enterFunc
+	enterField   []string
+	unmarshal    []reflect.Type
+	makeDefault  []func() reflect.Value
+	defaultField [][]int
+	// opts holds the type information options
+	// derived from the Names used for decoding.
+	opts typeinfo.Options
 }
 
 // enterFunc is used to "enter" a field or union value.
@@ -109,15 +135,22 @@ func compileDecoder(names *Names, t reflect.Type, writerType *Type) (*decodeProg
 	if debugging {
 		debugf("compiling:\nwriter type: %s\nreader type: %s\n", writerType, readerType)
 	}
-	prog, err := compiler.Compile(writerType.avroType, readerType.avroType, compiler.AllowLaxNames())
+	// Rename parts of the writer type to match the reader type
+	// so that the compiler can resolve aliases by name.
+	resolvedWriterType, err := resolveAliases(writerType, readerType)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve aliases: %v", err)
+	}
+	prog, err := compiler.Compile(resolvedWriterType.avroType, readerType.avroType, compiler.AllowLaxNames())
 	if err != nil {
 		return nil, fmt.Errorf("cannot create decoder: %v", err)
 	}
-	prog1, err := analyzeProgramTypes(prog, t, readerType.avroType)
+	prog1, err := analyzeProgramTypes(prog, t, readerType.avroType, names.typeinfoOptions())
 	if err != nil {
 		return nil, fmt.Errorf("analysis failed: %v", err)
 	}
 	prog1.readerType = readerType
+	prog1.writerType = writerType
 	return prog1, nil
 }
 
@@ -125,32 +158,52 @@ func compileDecoder(names *Names, t reflect.Type, writerType *Type) (*decodeProg
 // respect to the given type (the program must have been generated for that
 // type) and returns a program with a populated "enter" field allowing
 // the VM to correctly create union and field values for Enter instructions.
-func analyzeProgramTypes(prog *vm.Program, t reflect.Type, readerType schema.AvroType) (*decodeProgram, error) {
+func analyzeProgramTypes(prog *vm.Program, t reflect.Type, readerType schema.AvroType, opts typeinfo.Options) (*decodeProgram, error) {
 	a := &analyzer{
-		prog:        prog,
-		pcInfo:      make([]pcInfo, len(prog.Instructions)),
-		enter:       make([]enterFunc, len(prog.Instructions)),
-		makeDefault: make([]func() reflect.Value, len(prog.Instructions)),
+		opts:         opts,
+		prog:         prog,
+		pcInfo:       make([]pcInfo, len(prog.Instructions)),
+		enter:        make([]enterFunc, len(prog.Instructions)),
+		enterField:   make([]string, len(prog.Instructions)),
+		unmarshal:    make([]reflect.Type, len(prog.Instructions)),
+		makeDefault:  make([]func() reflect.Value, len(prog.Instructions)),
+		defaultField: make([][]int, len(prog.Instructions)),
 	}
 	if debugging {
 		debugf("analyze %d instructions; type %s\n%s {", len(prog.Instructions), t, prog)
 	}
 	defer debugf("}")
-	info, err := typeinfo.ForType(t)
+	root := pathElem{
+		ftype:    t,
+		avroType: readerType,
+	}
+	info, rootWrapped := typeinfo.ForWrapper(t)
+	if rootWrapped {
+		root.ftype = info.Type
+	} else {
+		var err error
+		info, err = typeinfo.ForType(t, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	root.info = info
+	root, rootUnmarshal, err := representationElem(root, opts)
 	if err != nil {
 		return nil, err
 	}
-	if err := a.eval([]int{0}, nil, []pathElem{{
-		ftype:    t,
-		info:     info,
-		avroType: readerType,
-	}}); err != nil {
+	if err := a.eval([]int{0}, nil, []pathElem{root}); err != nil {
 		return nil, fmt.Errorf("eval: %v", err)
 	}
 	prog1 := &decodeProgram{
-		Program:     *prog,
-		enter:       a.enter,
-		makeDefault: a.makeDefault,
+		Program:       *prog,
+		enter:         a.enter,
+		enterField:    a.enterField,
+		unmarshal:     a.unmarshal,
+		rootUnmarshal: rootUnmarshal,
+		rootWrapped:   rootWrapped,
+		makeDefault:   a.makeDefault,
+		defaultField:  a.defaultField,
 	}
 	// Sanity check that all Enter and SetDefault
 	// instructions have associated info.
@@ -242,20 +295,29 @@ func (a *analyzer) eval(stack []int, calls []int, path []pathElem) (retErr error
 			if debugging {
 				debugf("enter %d -> %v, %d entries", index, elem.info.Type, len(elem.info.Entries))
 			}
-			enterf, newElem, err := enter(elem, index)
+			enterf, newElem, err := enter(elem, index, a.opts)
 			if err != nil {
 				return fmt.Errorf("cannot enter: %v", err)
 			}
+			newElem, a.unmarshal[pc], err = representationElem(newElem, a.opts)
+			if err != nil {
+				return err
+			}
 			path = append(path, newElem)
 			a.enter[pc] = enterf
+			a.enterField[pc] = fieldName(elem.avroType, index)
 		case vm.AppendArray:
 			if elem.ftype.Kind() != reflect.Slice {
 				return fmt.Errorf("cannot append to %T", elem.ftype)
 			}
-			newElem, err := enterContainer(elem)
+			newElem, err := enterContainer(elem, a.opts)
 			if err != nil {
 				return fmt.Errorf("cannot enter array: %v", err)
 			}
+			newElem, a.unmarshal[pc], err = representationElem(newElem, a.opts)
+			if err != nil {
+				return err
+			}
 			path = append(path, newElem)
 			if debugging {
 				debugf("append array enter -> %v", elem.ftype.Elem())
@@ -264,13 +326,17 @@ func (a *analyzer) eval(stack []int, calls []int, path []pathElem) (retErr error
 			if elem.ftype.Kind() != reflect.Map {
 				return fmt.Errorf("cannot append to %T", elem.ftype)
 			}
-			if elem.ftype.Key().Kind() != reflect.String {
+			if !canDecodeMapKey(elem.ftype.Key()) {
 				return fmt.Errorf("invalid key type for map %s", elem.ftype)
 			}
-			newElem, err := enterContainer(elem)
+			newElem, err := enterContainer(elem, a.opts)
 			if err != nil {
 				return fmt.Errorf("cannot enter map: %v", err)
 			}
+			newElem, a.unmarshal[pc], err = representationElem(newElem, a.opts)
+			if err != nil {
+				return err
+			}
 			path = append(path, newElem)
 		case vm.Exit:
 			if len(path) == 0 {
@@ -290,6 +356,7 @@ func (a *analyzer) eval(stack []int, calls []int, path []pathElem) (retErr error
 				return fmt.Errorf("no default info found at index %d at %v", index, pathStr(path))
 			}
 			a.makeDefault[pc] = info.MakeDefault
+			a.defaultField[pc] = info.FieldIndex
 		case vm.Call:
 			found := false
 			for _, pc := range calls {
@@ -363,7 +430,7 @@ func (a *analyzer) eval(stack []int, calls []int, path []pathElem) (retErr error
 // and returns the new value to decode into and also reports
 // whether the new value is a reference into the original
 // value (if not, it will need to be copied into the original value).
-func enter(elem pathElem, index int) (enterFunc, pathElem, error) {
+func enter(elem pathElem, index int, opts typeinfo.Options) (enterFunc, pathElem, error) {
 	var entryType schema.AvroType
 	var info typeinfo.Info
 	switch at := elem.avroType.(type) {
@@ -413,7 +480,7 @@ func enter(elem pathElem, index int) (enterFunc, pathElem, error) {
 	}
 	if len(info.Entries) == 0 {
 		// The type itself might contribute information.
-		info1, err := typeinfo.ForType(info.Type)
+		info1, err := typeinfo.ForType(info.Type, opts)
 		if err != nil {
 			return nil, pathElem{}, fmt.Errorf("cannot get info for %s: %v", info.Type, err)
 		}
@@ -426,18 +493,37 @@ func enter(elem pathElem, index int) (enterFunc, pathElem, error) {
 		avroType: entryType,
 	}
 	var enter func(v reflect.Value) (reflect.Value, bool)
-	switch elem.ftype.Kind() {
-	case reflect.Struct:
+	switch kind := elem.ftype.Kind(); {
+	case elem.info.IsUnion && kind != reflect.Ptr && kind != reflect.Interface:
+		// It's a field made nullable by Names.WithNullableFields,
+		// so the value is decoded in place. Make empty slices and
+		// maps non-nil so that they're distinct from null.
+		enter = func(v reflect.Value) (reflect.Value, bool) {
+			switch kind {
+			case reflect.Slice:
+				v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+			case reflect.Map:
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			return v, true
+		}
+	case kind == reflect.Struct:
 		fieldIndex := info.FieldIndex
 		enter = func(v reflect.Value) (reflect.Value, bool) {
-			debugf("entering field %d in type %v", fieldIndex, v.Type())
-			return v.Field(fieldIndex), true
+			debugf("entering field %v in type %v", fieldIndex, v.Type())
+			return typeinfo.FieldByIndex(v, fieldIndex), true
+		}
+	case kind == reflect.Interface:
+		if !info.Type.AssignableTo(elem.ftype) {
+			// This can happen when the union is represented
+			// by a sealed interface type that the member
+			// type doesn't implement.
+			return nil, pathElem{}, fmt.Errorf("union member type %s does not implement %s", info.Type, elem.ftype)
 		}
-	case reflect.Interface:
 		enter = func(v reflect.Value) (reflect.Value, bool) {
 			return reflect.New(info.Type).Elem(), false
 		}
-	case reflect.Ptr:
+	case kind == reflect.Ptr:
 		if len(elem.info.Entries) != 2 {
 			return nil, pathElem{}, fmt.Errorf("pointer type without a two-member union")
 		}
@@ -452,10 +538,36 @@ func enter(elem pathElem, index int) (enterFunc, pathElem, error) {
 	return enter, newElem, nil
 }
 
+// representationElem returns the path element that should be used
+// to decode into a value of the type described by elem.
+// If the type implements AvroRepresenter, the returned element
+// describes the representation type, which is also returned.
+func representationElem(elem pathElem, opts typeinfo.Options) (pathElem, reflect.Type, error) {
+	_, rt, ok, err := representationOf(elem.ftype)
+	if !ok {
+		return elem, nil, nil
+	}
+	if err != nil {
+		return pathElem{}, nil, err
+	}
+	if !reflect.PtrTo(elem.ftype).Implements(avroUnmarshalerType) {
+		return pathElem{}, nil, fmt.Errorf("%s does not implement AvroUnmarshaler", elem.ftype)
+	}
+	info, err := typeinfo.ForType(rt, opts)
+	if err != nil {
+		return pathElem{}, nil, fmt.Errorf("cannot get info for %s: %v", rt, err)
+	}
+	return pathElem{
+		ftype:    rt,
+		info:     info,
+		avroType: elem.avroType,
+	}, rt, nil
+}
+
 // enterContainer returns the path element resulting
 // from descending into a map or array container
 // represented by elem.
-func enterContainer(elem pathElem) (pathElem, error) {
+func enterContainer(elem pathElem, opts typeinfo.Options) (pathElem, error) {
 	type container interface {
 		ItemType() schema.AvroType
 	}
@@ -466,7 +578,7 @@ func enterContainer(elem pathElem) (pathElem, error) {
 	}
 	if len(elem1.info.Entries) == 0 {
 		// The type itself might contribute information.
-		info, err := typeinfo.ForType(elem1.ftype)
+		info, err := typeinfo.ForType(elem1.ftype, opts)
 		if err != nil {
 			return pathElem{}, fmt.Errorf("cannot get info for %s: %v", info.Type, err)
 		}
@@ -475,6 +587,20 @@ func enterContainer(elem pathElem) (pathElem, error) {
 	return elem1, nil
 }
 
+// fieldName returns the name of the record field with the given
+// index if at is a record type, or the empty string otherwise.
+func fieldName(at schema.AvroType, index int) string {
+	ref, ok := at.(*schema.Reference)
+	if !ok {
+		return ""
+	}
+	def, ok := ref.Def.(*schema.RecordDefinition)
+	if !ok || index >= len(def.Fields()) {
+		return ""
+	}
+	return def.Fields()[index].Name()
+}
+
 func entryByName(entries []typeinfo.Info, fieldName string) (typeinfo.Info, bool) {
 	for _, entry := range entries {
 		if entry.FieldName == fieldName {
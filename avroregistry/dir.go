@@ -0,0 +1,344 @@
+package avroregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/heetch/avro"
+)
+
+// IndexFile holds the name of the index file inside a registry
+// directory. See OpenDir for details.
+const IndexFile = "index.json"
+
+// DirIndex holds the contents of the index file in a registry
+// directory.
+type DirIndex struct {
+	// Schemas holds an entry for each schema version in the
+	// directory. The Schema field of each entry is not stored in
+	// the index; it's read from the associated .avsc file instead.
+	Schemas []DirIndexEntry `json:"schemas"`
+
+	// Compatibility holds the global compatibility mode of the
+	// registry in the form returned by avro.CompatMode.String,
+	// or the empty string if it's not known.
+	//
+	// The compatibility fields aren't used by DirRegistry itself,
+	// but they allow a directory to hold a complete snapshot of a
+	// registry.
+	Compatibility string `json:"compatibility,omitempty"`
+
+	// SubjectCompatibility maps from subject to the compatibility
+	// mode explicitly set for that subject.
+	SubjectCompatibility map[string]string `json:"subjectCompatibility,omitempty"`
+}
+
+// DirIndexEntry holds the index entry for a single subject version.
+type DirIndexEntry struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+	ID      int64  `json:"id"`
+}
+
+// DirRegistry represents a read-only schema registry backed by
+// a directory tree. It can be used to encode and decode messages
+// in the same wire format used by Registry, without needing
+// access to a registry server.
+type DirRegistry struct {
+	dir string
+
+	// mu guards the fields below.
+	mu    sync.RWMutex
+	state *dirState
+}
+
+type dirState struct {
+	// modTimes maps from the path of each file that the state
+	// was loaded from (the index file and the schema files)
+	// to its modification time.
+	modTimes map[string]time.Time
+
+	// byID maps from schema ID to the schema.
+	byID map[int64]*avro.Type
+
+	// bySubject maps from subject to all the versions of that
+	// subject, ordered by version.
+	bySubject map[string][]*dirSchema
+}
+
+type dirSchema struct {
+	Schema
+	avroType *avro.Type
+}
+
+// OpenDir returns a registry that reads its schemas from the given
+// directory. The directory holds an index file named IndexFile containing
+// a JSON-encoded DirIndex value and, for each index entry,
+// the schema in a file named $subject/$version.avsc, where $subject is
+// path-escaped (see DirSchemaPath).
+//
+// All the schemas are read and parsed when OpenDir is called;
+// call Reload or Watch to pick up subsequent changes.
+func OpenDir(dir string) (*DirRegistry, error) {
+	r := &DirRegistry{
+		dir: dir,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DirSchemaPath returns the path, relative to the registry directory,
+// of the file holding the given version of the given subject.
+func DirSchemaPath(subject string, version int) string {
+	return filepath.Join(url.PathEscape(subject), strconv.Itoa(version)+".avsc")
+}
+
+// Reload reads the registry directory again. If there's an error,
+// the registry continues to use the previously loaded schemas.
+func (r *DirRegistry) Reload() error {
+	state, err := loadDir(r.dir)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = state
+	return nil
+}
+
+// Watch polls the registry directory at the given interval and reloads
+// the registry when the modification time of the index file or of any
+// of the schema files it refers to changes. It returns when ctx is done.
+//
+// If a reload fails, the error is passed to onError if that's non-nil
+// and the previously loaded schemas remain in use.
+func (r *DirRegistry) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r.mu.RLock()
+		state := r.state
+		r.mu.RUnlock()
+		if !state.changed() {
+			continue
+		}
+		if err := r.Reload(); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// Encoder returns an avro.EncodingRegistry implementation that can be
+// used to encode messages with schemas associated with the given
+// subject. As the registry is read-only, encoding a value whose
+// schema isn't already present under the subject will fail.
+func (r *DirRegistry) Encoder(subject string) avro.EncodingRegistry {
+	return dirEncodingRegistry{
+		r:       r,
+		subject: subject,
+	}
+}
+
+// Decoder returns an avro.DecodingRegistry implementation
+// that can be used to decode messages using the schemas in the directory.
+func (r *DirRegistry) Decoder() avro.DecodingRegistry {
+	return dirDecodingRegistry{
+		r: r,
+	}
+}
+
+// Schema returns the given version of the schema registered under the
+// given subject. The version may be "latest" to return the most recent
+// version.
+func (r *DirRegistry) Schema(subject, version string) (*Schema, error) {
+	if err := validateVersion(version); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	versions := r.state.bySubject[subject]
+	r.mu.RUnlock()
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("subject %q not found", subject)
+	}
+	if version == "latest" {
+		s := versions[len(versions)-1].Schema
+		return &s, nil
+	}
+	v, _ := strconv.Atoi(version)
+	for _, s := range versions {
+		if s.Version == v {
+			s := s.Schema
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("version %d of subject %q not found", v, subject)
+}
+
+// Subjects returns all the subjects in the registry in alphabetical order.
+func (r *DirRegistry) Subjects() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	subjects := make([]string, 0, len(r.state.bySubject))
+	for subject := range r.state.bySubject {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+	return subjects
+}
+
+// changed reports whether any of the files that s was loaded
+// from has been modified or can no longer be found.
+func (s *dirState) changed() bool {
+	for path, modTime := range s.modTimes {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadDir(dir string) (*dirState, error) {
+	indexPath := filepath.Join(dir, IndexFile)
+	info, err := os.Stat(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	var index DirIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal %s: %v", indexPath, err)
+	}
+	state := &dirState{
+		modTimes: map[string]time.Time{
+			indexPath: info.ModTime(),
+		},
+		byID:      make(map[int64]*avro.Type),
+		bySubject: make(map[string][]*dirSchema),
+	}
+	for _, entry := range index.Schemas {
+		if entry.Subject == "" || entry.Subject == "." || entry.Subject == ".." {
+			return nil, fmt.Errorf("invalid subject %q in %s", entry.Subject, indexPath)
+		}
+		if entry.Version < 1 {
+			return nil, fmt.Errorf("invalid version %d for subject %q in %s", entry.Version, entry.Subject, indexPath)
+		}
+		if entry.ID < 0 || entry.ID >= 1<<32-1 {
+			// The ID wouldn't fit in the message header.
+			return nil, fmt.Errorf("invalid schema ID %d for version %d of subject %q in %s", entry.ID, entry.Version, entry.Subject, indexPath)
+		}
+		path := filepath.Join(dir, DirSchemaPath(entry.Subject, entry.Version))
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		state.modTimes[path] = info.ModTime()
+		t, err := avro.ParseType(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema in %s: %v", path, err)
+		}
+		if t0, ok := state.byID[entry.ID]; ok {
+			if canonical(t0) != canonical(t) {
+				return nil, fmt.Errorf("schema ID %d refers to different schemas (found in %s)", entry.ID, path)
+			}
+			t = t0
+		}
+		state.byID[entry.ID] = t
+		state.bySubject[entry.Subject] = append(state.bySubject[entry.Subject], &dirSchema{
+			Schema: Schema{
+				Subject: entry.Subject,
+				ID:      entry.ID,
+				Version: entry.Version,
+				Schema:  string(data),
+			},
+			avroType: t,
+		})
+	}
+	for subject, versions := range state.bySubject {
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i].Version < versions[j].Version
+		})
+		for i := 1; i < len(versions); i++ {
+			if versions[i].Version == versions[i-1].Version {
+				return nil, fmt.Errorf("duplicate version %d for subject %q in %s", versions[i].Version, subject, indexPath)
+			}
+		}
+	}
+	return state, nil
+}
+
+type dirEncodingRegistry struct {
+	r       *DirRegistry
+	subject string
+}
+
+var _ avro.EncodingRegistry = dirEncodingRegistry{}
+
+// AppendSchemaID implements avro.EncodingRegistry.AppendSchemaID
+// by appending the id in the same wire format used by Registry.
+func (r dirEncodingRegistry) AppendSchemaID(buf []byte, id int64) []byte {
+	return appendSchemaID(buf, id)
+}
+
+// IDForSchema implements avro.EncodingRegistry.IDForSchema
+// by searching for the schema in the versions of the subject.
+func (r dirEncodingRegistry) IDForSchema(ctx context.Context, schema *avro.Type) (int64, error) {
+	r.r.mu.RLock()
+	versions := r.r.state.bySubject[r.subject]
+	r.r.mu.RUnlock()
+	want := canonical(schema)
+	// Search from the latest version backwards as that's
+	// the most likely match.
+	for i := len(versions) - 1; i >= 0; i-- {
+		if canonical(versions[i].avroType) == want {
+			return versions[i].ID, nil
+		}
+	}
+	return 0, fmt.Errorf("schema not found in subject %q", r.subject)
+}
+
+type dirDecodingRegistry struct {
+	r *DirRegistry
+}
+
+var _ avro.DecodingRegistry = dirDecodingRegistry{}
+
+// DecodeSchemaID implements avro.DecodingRegistry.DecodeSchemaID
+// by stripping off the schema-identifier header.
+func (r dirDecodingRegistry) DecodeSchemaID(msg []byte) (int64, []byte) {
+	return decodeSchemaID(msg)
+}
+
+// SchemaForID implements avro.DecodingRegistry.SchemaForID
+// by looking up the schema in the directory index.
+func (r dirDecodingRegistry) SchemaForID(ctx context.Context, id int64) (*avro.Type, error) {
+	r.r.mu.RLock()
+	t := r.r.state.byID[id]
+	r.r.mu.RUnlock()
+	if t == nil {
+		return nil, fmt.Errorf("schema ID %d not found", id)
+	}
+	return t, nil
+}
@@ -0,0 +1,273 @@
+package avroregistry_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroregistry"
+)
+
+func TestDirRegistrySingleCodec(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+
+	type R struct {
+		X int
+	}
+	type R1 struct {
+		X int
+		Y int
+	}
+	names := new(avro.Names).RenameType(R1{}, "R")
+	dir := c.TempDir()
+	writeDir(c, dir, map[int64]*avro.Type{
+		3: schemaOf(nil, R{}),
+		7: schemaOf(names, R1{}),
+	}, []avroregistry.DirIndexEntry{{
+		Subject: "s",
+		Version: 1,
+		ID:      3,
+	}, {
+		Subject: "s",
+		Version: 2,
+		ID:      7,
+	}})
+	r, err := avroregistry.OpenDir(dir)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r.Subjects(), qt.DeepEquals, []string{"s"})
+
+	enc := avro.NewSingleEncoder(r.Encoder("s"), names)
+	data1, err := enc.Marshal(ctx, R{10})
+	c.Assert(err, qt.IsNil)
+	c.Assert(data1, qt.DeepEquals, []byte{0, 0, 0, 0, 3, 20})
+
+	data2, err := enc.Marshal(ctx, R1{11, 30})
+	c.Assert(err, qt.IsNil)
+	c.Assert(data2, qt.DeepEquals, []byte{0, 0, 0, 0, 7, 22, 60})
+
+	dec := avro.NewSingleDecoder(r.Decoder(), names)
+	var x1 R
+	_, err = dec.Unmarshal(ctx, data1, &x1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(x1, qt.Equals, R{10})
+
+	var x2 R1
+	_, err = dec.Unmarshal(ctx, data2, &x2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(x2, qt.Equals, R1{11, 30})
+
+	// A schema that isn't in the directory can't be used for encoding.
+	type R2 struct {
+		Z string
+	}
+	_, err = avro.NewSingleEncoder(r.Encoder("s"), nil).Marshal(ctx, R2{})
+	c.Assert(err, qt.ErrorMatches, `schema not found in subject "s"`)
+
+	_, err = r.Decoder().SchemaForID(ctx, 99)
+	c.Assert(err, qt.ErrorMatches, `schema ID 99 not found`)
+}
+
+func TestDirRegistrySchema(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		X int
+	}
+	type R1 struct {
+		X int
+		Y string
+	}
+	names := new(avro.Names).RenameType(R1{}, "R")
+	dir := c.TempDir()
+	writeDir(c, dir, map[int64]*avro.Type{
+		1: schemaOf(nil, R{}),
+		2: schemaOf(names, R1{}),
+	}, []avroregistry.DirIndexEntry{{
+		Subject: "a/b",
+		Version: 2,
+		ID:      2,
+	}, {
+		Subject: "a/b",
+		Version: 1,
+		ID:      1,
+	}})
+	r, err := avroregistry.OpenDir(dir)
+	c.Assert(err, qt.IsNil)
+
+	s, err := r.Schema("a/b", "latest")
+	c.Assert(err, qt.IsNil)
+	c.Assert(s, qt.DeepEquals, &avroregistry.Schema{
+		Subject: "a/b",
+		ID:      2,
+		Version: 2,
+		Schema:  schemaOf(names, R1{}).String(),
+	})
+	s, err = r.Schema("a/b", "1")
+	c.Assert(err, qt.IsNil)
+	c.Assert(s.ID, qt.Equals, int64(1))
+
+	_, err = r.Schema("a/b", "3")
+	c.Assert(err, qt.ErrorMatches, `version 3 of subject "a/b" not found`)
+	_, err = r.Schema("other", "1")
+	c.Assert(err, qt.ErrorMatches, `subject "other" not found`)
+	_, err = r.Schema("a/b", "0")
+	c.Assert(err, qt.ErrorMatches, `Invalid version.*`)
+}
+
+func TestDirRegistryErrors(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		X int
+	}
+	type S struct {
+		Y int
+	}
+	dir := c.TempDir()
+	_, err := avroregistry.OpenDir(dir)
+	c.Assert(err, qt.ErrorMatches, `stat .*index.json: no such file or directory`)
+
+	writeDir(c, dir, map[int64]*avro.Type{
+		1: schemaOf(nil, R{}),
+	}, []avroregistry.DirIndexEntry{{
+		Subject: "s",
+		Version: 1,
+		ID:      1,
+	}, {
+		Subject: "s",
+		Version: 1,
+		ID:      1,
+	}})
+	_, err = avroregistry.OpenDir(dir)
+	c.Assert(err, qt.ErrorMatches, `duplicate version 1 for subject "s" in .*`)
+
+	dir = c.TempDir()
+	writeDir(c, dir, map[int64]*avro.Type{
+		1: schemaOf(nil, R{}),
+	}, []avroregistry.DirIndexEntry{{
+		Subject: "s",
+		Version: 1,
+		ID:      1,
+	}})
+	writeFile(c, filepath.Join(dir, avroregistry.DirSchemaPath("t", 1)), schemaOf(nil, S{}).String())
+	writeIndex(c, dir, []avroregistry.DirIndexEntry{{
+		Subject: "s",
+		Version: 1,
+		ID:      1,
+	}, {
+		Subject: "t",
+		Version: 1,
+		ID:      1,
+	}})
+	_, err = avroregistry.OpenDir(dir)
+	c.Assert(err, qt.ErrorMatches, `schema ID 1 refers to different schemas \(found in .*\)`)
+
+	// Schema IDs must fit in the message header.
+	for _, id := range []int64{-1, 1<<32 - 1} {
+		dir = c.TempDir()
+		writeDir(c, dir, map[int64]*avro.Type{
+			id: schemaOf(nil, R{}),
+		}, []avroregistry.DirIndexEntry{{
+			Subject: "s",
+			Version: 1,
+			ID:      id,
+		}})
+		_, err = avroregistry.OpenDir(dir)
+		c.Assert(err, qt.ErrorMatches, fmt.Sprintf(`invalid schema ID %d for version 1 of subject "s" in .*`, id))
+	}
+}
+
+func TestDirRegistryWatch(t *testing.T) {
+	c := qt.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type R struct {
+		X int
+	}
+	dir := c.TempDir()
+	writeDir(c, dir, nil, nil)
+	r, err := avroregistry.OpenDir(dir)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r.Subjects(), qt.HasLen, 0)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Note: we can't check for errors because the watcher
+		// might see a partially written index file.
+		r.Watch(ctx, time.Millisecond, nil)
+	}()
+	writeDir(c, dir, map[int64]*avro.Type{
+		1: schemaOf(nil, R{}),
+	}, []avroregistry.DirIndexEntry{{
+		Subject: "s",
+		Version: 1,
+		ID:      1,
+	}})
+	// Make sure the modification time changes even on file systems
+	// with coarse timestamps.
+	future := time.Now().Add(time.Hour)
+	err = os.Chtimes(filepath.Join(dir, avroregistry.IndexFile), future, future)
+	c.Assert(err, qt.IsNil)
+	for i := 0; len(r.Subjects()) == 0; i++ {
+		if i > 1000 {
+			c.Fatalf("registry not reloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	c.Assert(r.Subjects(), qt.DeepEquals, []string{"s"})
+
+	// Changing a schema file without changing the index
+	// also causes a reload.
+	type S struct {
+		Y string
+	}
+	newSchema := schemaOf(nil, S{}).String()
+	schemaPath := filepath.Join(dir, avroregistry.DirSchemaPath("s", 1))
+	writeFile(c, schemaPath, newSchema)
+	future = future.Add(time.Hour)
+	err = os.Chtimes(schemaPath, future, future)
+	c.Assert(err, qt.IsNil)
+	for i := 0; ; i++ {
+		if i > 1000 {
+			c.Fatalf("registry not reloaded after schema change")
+		}
+		s, err := r.Schema("s", "1")
+		c.Assert(err, qt.IsNil)
+		if s.Schema == newSchema {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+}
+
+func writeDir(c *qt.C, dir string, schemas map[int64]*avro.Type, entries []avroregistry.DirIndexEntry) {
+	for _, e := range entries {
+		writeFile(c, filepath.Join(dir, avroregistry.DirSchemaPath(e.Subject, e.Version)), schemas[e.ID].String())
+	}
+	writeIndex(c, dir, entries)
+}
+
+func writeIndex(c *qt.C, dir string, entries []avroregistry.DirIndexEntry) {
+	data, err := json.Marshal(avroregistry.DirIndex{
+		Schemas: entries,
+	})
+	c.Assert(err, qt.IsNil)
+	writeFile(c, filepath.Join(dir, avroregistry.IndexFile), string(data))
+}
+
+func writeFile(c *qt.C, path string, data string) {
+	err := os.MkdirAll(filepath.Dir(path), 0777)
+	c.Assert(err, qt.IsNil)
+	err = os.WriteFile(path, []byte(data), 0666)
+	c.Assert(err, qt.IsNil)
+}
@@ -21,6 +21,10 @@ var _ avro.EncodingRegistry = encodingRegistry{}
 // by appending the id.
 // See https://docs.confluent.io/current/schema-registry/serializer-formatter.html#wire-format.
 func (r encodingRegistry) AppendSchemaID(buf []byte, id int64) []byte {
+	return appendSchemaID(buf, id)
+}
+
+func appendSchemaID(buf []byte, id int64) []byte {
 	if id < 0 || id >= 1<<32-1 {
 		panic("schema id out of range")
 	}
@@ -63,6 +67,10 @@ var _ avro.DecodingRegistry = decodingRegistry{}
 //
 // See https://docs.confluent.io/current/schema-registry/serializer-formatter.html#wire-format.
 func (r decodingRegistry) DecodeSchemaID(msg []byte) (int64, []byte) {
+	return decodeSchemaID(msg)
+}
+
+func decodeSchemaID(msg []byte) (int64, []byte) {
 	if len(msg) < 5 || msg[0] != 0 {
 		return 0, nil
 	}
@@ -1,11 +1,13 @@
 // Package avroregistry provides avro.*Registry implementations
-// that consult an Avro registry through its REST API.
+// that consult an Avro registry through its REST API
+// or read schemas from a local directory (see OpenDir).
 package avroregistry
 
 import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -112,7 +114,37 @@ func (r *Registry) Register(ctx context.Context, subject string, schema *avro.Ty
 	return resp.ID, nil
 }
 
+// Import registers s.Schema under s.Subject, preserving the
+// schema ID and version from s. The subject (or the registry as a whole)
+// must be in ModeImport for this to succeed.
+//
+// Unlike Register, the schema text is sent unchanged.
+//
+// See https://docs.confluent.io/platform/current/schema-registry/installation/migrate.html
+func (r *Registry) Import(ctx context.Context, s Schema) error {
+	data, err := json.Marshal(struct {
+		Schema  string `json:"schema"`
+		ID      int64  `json:"id"`
+		Version int    `json:"version"`
+	}{s.Schema, s.ID, s.Version})
+	if err != nil {
+		return err
+	}
+	req := r.newRequest(ctx, "POST", fmt.Sprintf("/subjects/%s/versions", s.Subject), bytes.NewReader(data))
+	var resp struct {
+		ID int64 `json:"id"`
+	}
+	if err := r.doRequest(req, &resp); err != nil {
+		return err
+	}
+	if resp.ID != s.ID {
+		return fmt.Errorf("registry allocated ID %d for imported schema, not %d", resp.ID, s.ID)
+	}
+	return nil
+}
+
 // SetCompatibility sets the compatibility mode for the registry's subject to mode.
+// If subject is empty, the global compatibility mode is set.
 //
 // See https://docs.confluent.io/current/schema-registry/develop/api.html#put--config-(string-%20subject)
 func (r *Registry) SetCompatibility(ctx context.Context, subject string, mode avro.CompatMode) error {
@@ -122,7 +154,114 @@ func (r *Registry) SetCompatibility(ctx context.Context, subject string, mode av
 	if err != nil {
 		return err
 	}
-	return r.doRequest(r.newRequest(ctx, "PUT", "/config/"+subject, bytes.NewReader(data)), nil)
+	return r.doRequest(r.newRequest(ctx, "PUT", subjectPath("/config", subject), bytes.NewReader(data)), nil)
+}
+
+// Compatibility returns the compatibility mode for the given subject.
+// If subject is empty, the global compatibility mode is returned.
+// If no compatibility mode has been set explicitly for the subject,
+// it returns an error for which IsNotFound returns true.
+//
+// See https://docs.confluent.io/current/schema-registry/develop/api.html#get--config-(string-%20subject)
+func (r *Registry) Compatibility(ctx context.Context, subject string) (avro.CompatMode, error) {
+	urlStr := subjectPath("/config", subject)
+	if subject != "" {
+		urlStr += "?defaultToGlobal=false"
+	}
+	var resp struct {
+		CompatibilityLevel string `json:"compatibilityLevel"`
+	}
+	if err := r.doRequest(r.newRequest(ctx, "GET", urlStr, nil), &resp); err != nil {
+		return 0, err
+	}
+	mode := avro.ParseCompatMode(resp.CompatibilityLevel)
+	if mode == -1 {
+		return 0, fmt.Errorf("unknown compatibility mode %q", resp.CompatibilityLevel)
+	}
+	return mode, nil
+}
+
+// Mode represents the mode of a registry or subject, which
+// determines which operations are allowed.
+type Mode string
+
+const (
+	ModeReadWrite Mode = "READWRITE"
+	ModeReadOnly  Mode = "READONLY"
+	ModeImport    Mode = "IMPORT"
+)
+
+// SetMode sets the mode for the given subject.
+// If subject is empty, the global mode is set.
+//
+// See https://docs.confluent.io/platform/current/schema-registry/develop/api.html#put--mode-(string-%20subject)
+func (r *Registry) SetMode(ctx context.Context, subject string, mode Mode) error {
+	data, err := json.Marshal(struct {
+		Mode Mode `json:"mode"`
+	}{mode})
+	if err != nil {
+		return err
+	}
+	return r.doRequest(r.newRequest(ctx, "PUT", subjectPath("/mode", subject), bytes.NewReader(data)), nil)
+}
+
+// Mode returns the mode for the given subject. If subject is
+// empty, the global mode is returned. If no mode has been set
+// explicitly for the subject, it returns an error for which
+// IsNotFound returns true.
+//
+// See https://docs.confluent.io/platform/current/schema-registry/develop/api.html#get--mode-(string-%20subject)
+func (r *Registry) Mode(ctx context.Context, subject string) (Mode, error) {
+	urlStr := subjectPath("/mode", subject)
+	if subject != "" {
+		urlStr += "?defaultToGlobal=false"
+	}
+	var resp struct {
+		Mode Mode `json:"mode"`
+	}
+	if err := r.doRequest(r.newRequest(ctx, "GET", urlStr, nil), &resp); err != nil {
+		return "", err
+	}
+	return resp.Mode, nil
+}
+
+// DeleteMode deletes the mode for the given subject, so that
+// the global mode applies to it.
+//
+// See https://docs.confluent.io/platform/current/schema-registry/develop/api.html#delete--mode-(string-%20subject)
+func (r *Registry) DeleteMode(ctx context.Context, subject string) error {
+	return r.doRequest(r.newRequest(ctx, "DELETE", subjectPath("/mode", subject), nil), nil)
+}
+
+// Subjects returns all the subjects registered in the registry.
+//
+// See https://docs.confluent.io/current/schema-registry/develop/api.html#get--subjects
+func (r *Registry) Subjects(ctx context.Context) ([]string, error) {
+	var subjects []string
+	if err := r.doRequest(r.newRequest(ctx, "GET", "/subjects", nil), &subjects); err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+// Versions returns all the versions registered under the given subject.
+//
+// See https://docs.confluent.io/current/schema-registry/develop/api.html#get--subjects-(string-%20subject)-versions
+func (r *Registry) Versions(ctx context.Context, subject string) ([]int, error) {
+	var versions []int
+	if err := r.doRequest(r.newRequest(ctx, "GET", fmt.Sprintf("/subjects/%s/versions", subject), nil), &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// subjectPath returns the global resource path p
+// or the subject-specific variant of it if subject is non-empty.
+func subjectPath(p, subject string) string {
+	if subject == "" {
+		return p
+	}
+	return p + "/" + subject
 }
 
 // DeleteSubject deletes the  given subject from the registry.
@@ -259,6 +398,13 @@ type apiError struct {
 	StatusCode int    `json:"-"`
 }
 
+// IsNotFound reports whether err was caused by the registry
+// responding that the requested resource doesn't exist.
+func IsNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
 func (e *apiError) Error() string {
 	if e.StatusCode != e.ErrorCode {
 		return fmt.Sprintf("Avro registry error (code %d; HTTP status %d): %v", e.ErrorCode, e.StatusCode, e.Message)
@@ -0,0 +1,22 @@
+package avroregistry
+
+// SubjectStrategy determines the registry subject to register a
+// schema under, given the fully qualified Avro name of its top
+// level type, for example "com.example.User".
+//
+// See https://docs.confluent.io/platform/current/schema-registry/fundamentals/serdes-develop/index.html#subject-name-strategy
+type SubjectStrategy func(name string) string
+
+// RecordNameStrategy uses the fully qualified Avro name as the subject.
+func RecordNameStrategy(name string) string {
+	return name
+}
+
+// TopicRecordNameStrategy returns a SubjectStrategy that uses
+// the given topic and the fully qualified Avro name joined with a
+// hyphen as the subject, for example "users-com.example.User".
+func TopicRecordNameStrategy(topic string) SubjectStrategy {
+	return func(name string) string {
+		return topic + "-" + name
+	}
+}
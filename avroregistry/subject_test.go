@@ -0,0 +1,20 @@
+package avroregistry_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro/avroregistry"
+)
+
+func TestRecordNameStrategy(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(avroregistry.RecordNameStrategy("com.example.User"), qt.Equals, "com.example.User")
+}
+
+func TestTopicRecordNameStrategy(t *testing.T) {
+	c := qt.New(t)
+	subject := avroregistry.TopicRecordNameStrategy("users")
+	c.Assert(subject("com.example.User"), qt.Equals, "users-com.example.User")
+}
@@ -36,6 +36,48 @@ type RecordInfo struct {
 	Unions []UnionInfo
 }
 
+// AvroWrapper is implemented by Go types generated by the avrogo
+// command for schemas whose top level type isn't a definition, such
+// as a union of records. A wrapper type is a struct with a single
+// exported field that holds the value.
+type AvroWrapper interface {
+	AvroWrapper() WrapperInfo
+}
+
+// WrapperInfo holds information about how a Go wrapper type
+// relates to an Avro schema.
+type WrapperInfo struct {
+	// Schema holds the Avro schema of the wrapped value.
+	Schema string
+
+	// Union holds union information for the wrapped
+	// value in the same form as an entry in RecordInfo.Unions.
+	Union UnionInfo
+}
+
+// AvroEnum is implemented by Go enum types generated by the avrogo
+// command for enum schemas that specify a default symbol.
+// Hand-written enum types can implement it too, for example
+// to use an Unknown zero value as the default or to
+// specify symbols that differ from their String values.
+type AvroEnum interface {
+	AvroEnum() EnumInfo
+}
+
+// EnumInfo holds information about how a Go enum type
+// relates to an Avro schema.
+type EnumInfo struct {
+	// Symbols holds the symbols of the enum, with the
+	// Go value i corresponding to Symbols[i]. If it's nil,
+	// the symbols are derived from the String method
+	// of the type.
+	Symbols []string
+
+	// Default holds the symbol that's used when
+	// reading a symbol that's not known to the enum.
+	Default string
+}
+
 type UnionInfo struct {
 	// Type holds a value of type *T where T is
 	// the type described by the TypeInfo,
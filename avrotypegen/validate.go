@@ -0,0 +1,129 @@
+package avrotypegen
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Validator is implemented by Go types generated by the avrogo
+// command with the -validate flag.
+type Validator interface {
+	// Validate checks that the value can be represented by its
+	// Avro schema. If it can't, it returns a ValidationErrors
+	// value describing all the problems found.
+	Validate() error
+}
+
+// ValidationError describes a value that can't be
+// represented by its Avro schema.
+type ValidationError struct {
+	// Path holds the path to the invalid value from the value
+	// being validated, for example "items[2].name".
+	Path string
+
+	// Message describes the problem.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors holds all the problems found when
+// validating a value.
+type ValidationErrors []*ValidationError
+
+// Error implements the error interface.
+func (errs ValidationErrors) Error() string {
+	switch len(errs) {
+	case 0:
+		return "no validation errors"
+	case 1:
+		return errs[0].Error()
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d validation errors: %s", len(errs), strings.Join(msgs, "; "))
+}
+
+// Err returns errs as an error, or nil if
+// there are no errors.
+func (errs ValidationErrors) Err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Add adds an error for the value at the given path.
+func (errs *ValidationErrors) Add(path, msg string) {
+	*errs = append(*errs, &ValidationError{
+		Path:    path,
+		Message: msg,
+	})
+}
+
+// AddError adds err for the value at the given path.
+// If err is a ValidationErrors value, each of its errors
+// is added with its path relative to the given path.
+// It does nothing if err is nil.
+func (errs *ValidationErrors) AddError(path string, err error) {
+	if err == nil {
+		return
+	}
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		errs.Add(path, err.Error())
+		return
+	}
+	for _, e := range verrs {
+		p := path
+		switch {
+		case e.Path == "":
+		case p == "" || strings.HasPrefix(e.Path, "["):
+			p += e.Path
+		default:
+			p += "." + e.Path
+		}
+		errs.Add(p, e.Message)
+	}
+}
+
+// AddUnionType adds an error for a value at the given path
+// that isn't one of the types allowed by its union.
+func (errs *ValidationErrors) AddUnionType(path string, x interface{}) {
+	if x == nil {
+		errs.Add(path, "nil value not allowed in union")
+		return
+	}
+	errs.Add(path, fmt.Sprintf("value of type %T not allowed in union", x))
+}
+
+// Validate validates x if it implements Validator,
+// and returns nil otherwise.
+func Validate(x interface{}) error {
+	if v, ok := x.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// IndexPath returns the path of the element
+// with index i in the array at the given path.
+func IndexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// KeyPath returns the path of the element with
+// the given key in the map at the given path.
+func KeyPath(path string, key string) string {
+	return path + "[" + strconv.Quote(key) + "]"
+}
@@ -0,0 +1,173 @@
+// The avdl2avsc command converts Avro IDL files to JSON Avro schemas.
+//
+// Usage:
+//
+//	usage: avdl2avsc [flags] file.avdl...
+//	  -d string
+//	    	directory to write schema files to (default ".")
+//	  -o string
+//	    	write a single combined schema to this file ("-" for stdout)
+//
+// By default, a self-contained schema is written for each type
+// defined in the IDL files, including types from imported files,
+// to a file named after the type; for example a record named
+// com.example.User is written to User.avsc. Any other types
+// that the schema refers to are defined inline where they're
+// first used.
+//
+// When the -o flag is specified, a single schema defining all
+// the types is written instead. If there's one type that
+// refers directly or indirectly to all the others, that type
+// is the top level of the schema; otherwise the top level is
+// a union of all the types that aren't referred to by
+// other types.
+//
+// Error types are written as records, and protocol
+// messages are ignored.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	stdflag "flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/heetch/avro/internal/avdl"
+)
+
+var flag = stdflag.NewFlagSet("", stdflag.ContinueOnError)
+
+var (
+	dirFlag = flag.String("d", ".", "directory to write schema files to")
+	outFlag = flag.String("o", "", `write a single combined schema to this file ("-" for stdout)`)
+)
+
+func main() {
+	os.Exit(main1())
+}
+
+// main1 is the internal version of main that returns a status
+// code instead of calling os.Exit.
+func main1() int {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: avdl2avsc [flags] file.avdl...\n")
+		flag.PrintDefaults()
+	}
+	if flag.Parse(os.Args[1:]) != nil {
+		return 2
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
+		return 2
+	}
+	if err := avdl2avsc(flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "avdl2avsc: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// avdl2avsc converts the IDL in the given files and writes
+// the resulting schemas.
+func avdl2avsc(files []string) error {
+	proto, err := readProtocols(files)
+	if err != nil {
+		return err
+	}
+	if *outFlag != "" {
+		data, err := proto.CombinedSchema()
+		if err != nil {
+			return err
+		}
+		return writeSchema(*outFlag, data)
+	}
+	schemas, err := proto.Schemas()
+	if err != nil {
+		return err
+	}
+	outFiles := make(map[string]string)
+	for _, s := range schemas {
+		outFile := s.Name[strings.LastIndex(s.Name, ".")+1:] + ".avsc"
+		if other, ok := outFiles[outFile]; ok {
+			return fmt.Errorf("types %s and %s would both be written to %s; use -o to write a combined schema", other, s.Name, outFile)
+		}
+		outFiles[outFile] = s.Name
+	}
+	if err := os.MkdirAll(*dirFlag, 0777); err != nil {
+		return fmt.Errorf("cannot create output directory: %v", err)
+	}
+	for _, s := range schemas {
+		outFile := s.Name[strings.LastIndex(s.Name, ".")+1:] + ".avsc"
+		if err := writeSchema(filepath.Join(*dirFlag, outFile), s.Schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readProtocols reads the protocols in all the given files and
+// returns a single protocol holding all their types. A type that's
+// defined in more than one protocol (for example because it's in a
+// file that they both import) is only included once.
+func readProtocols(files []string) (*avdl.Protocol, error) {
+	merged := new(avdl.Protocol)
+	defined := make(map[string]json.RawMessage)
+	for _, f := range files {
+		proto, err := avdl.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range proto.Types {
+			name, err := typeName(t.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", f, err)
+			}
+			if schema, ok := defined[name]; ok {
+				if !bytes.Equal(schema, t.Schema) {
+					return nil, fmt.Errorf("%s: conflicting definitions of %s", f, name)
+				}
+				continue
+			}
+			defined[name] = t.Schema
+			merged.Types = append(merged.Types, t)
+		}
+	}
+	return merged, nil
+}
+
+// typeName returns the full name of the type
+// defined by the given schema.
+func typeName(schema json.RawMessage) (string, error) {
+	var t struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	}
+	if err := json.Unmarshal(schema, &t); err != nil {
+		return "", fmt.Errorf("invalid type schema: %v", err)
+	}
+	if t.Namespace == "" || strings.Contains(t.Name, ".") {
+		return t.Name, nil
+	}
+	return t.Namespace + "." + t.Name, nil
+}
+
+// writeSchema writes the given schema to outFile,
+// or to stdout if outFile is "-".
+func writeSchema(outFile string, data []byte) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return fmt.Errorf("cannot format schema: %v", err)
+	}
+	buf.WriteByte('\n')
+	if outFile == "-" {
+		os.Stdout.Write(buf.Bytes())
+		return nil
+	}
+	if err := os.WriteFile(outFile, buf.Bytes(), 0666); err != nil {
+		return fmt.Errorf("cannot create output file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/rogpeppe/go-internal/txtar"
+
+	"github.com/heetch/avro/internal/avdl"
+)
+
+// TestRoundTrip checks that converting the IDL produced by avsc2avdl
+// back to JSON results in the schema that it was generated from.
+func TestRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	files, err := filepath.Glob("../avsc2avdl/testdata/*.txt")
+	c.Assert(err, qt.IsNil)
+	c.Assert(files, qt.Not(qt.HasLen), 0)
+	for _, file := range files {
+		c.Run(filepath.Base(file), func(c *qt.C) {
+			ar, err := txtar.ParseFile(file)
+			c.Assert(err, qt.IsNil)
+			var avsc, idl []byte
+			for _, f := range ar.Files {
+				switch {
+				case strings.HasSuffix(f.Name, ".avsc"):
+					avsc = f.Data
+				case f.Name == "expect.avdl":
+					idl = f.Data
+				}
+			}
+			if avsc == nil || idl == nil {
+				c.Skip("not a single schema conversion")
+			}
+			proto, err := avdl.ParseIDL("expect.avdl", idl)
+			c.Assert(err, qt.IsNil)
+			got, err := proto.CombinedSchema()
+			c.Assert(err, qt.IsNil)
+			c.Assert(normalizeDocs(c, got), qt.DeepEquals, normalizeDocs(c, avsc))
+		})
+	}
+}
+
+// normalizeDocs decodes the given JSON schema and collapses white
+// space in all its doc strings, because the white space in doc
+// comments isn't preserved exactly by IDL.
+func normalizeDocs(c *qt.C, data []byte) interface{} {
+	var x interface{}
+	err := json.Unmarshal(data, &x)
+	c.Assert(err, qt.IsNil)
+	var walk func(x interface{})
+	walk = func(x interface{}) {
+		switch x := x.(type) {
+		case map[string]interface{}:
+			for k, v := range x {
+				if s, ok := v.(string); ok && k == "doc" {
+					x[k] = strings.Join(strings.Fields(s), " ")
+				} else {
+					walk(v)
+				}
+			}
+		case []interface{}:
+			for _, v := range x {
+				walk(v)
+			}
+		}
+	}
+	walk(x)
+	return x
+}
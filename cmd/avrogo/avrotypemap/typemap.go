@@ -93,7 +93,7 @@ func (w *walker) walk(at schema.AvroType, t reflect.Type, info typeinfo.Info) er
 			}
 			if len(info.Entries) == 0 {
 				// The type itself might contribute information.
-				info1, err := typeinfo.ForType(t)
+				info1, err := typeinfo.ForType(t, typeinfo.Options{})
 				if err != nil {
 					return fmt.Errorf("cannot get info for %s: %v", info.Type, err)
 				}
@@ -103,7 +103,7 @@ func (w *walker) walk(at schema.AvroType, t reflect.Type, info typeinfo.Info) er
 				return fmt.Errorf("field count mismatch")
 			}
 			for i, f := range def.Fields() {
-				ft := t.Field(info.Entries[i].FieldIndex)
+				ft := t.FieldByIndex(info.Entries[i].FieldIndex)
 				err := w.walk(f.Type(), ft.Type, info.Entries[i])
 				if err != nil {
 					return err
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/actgardner/gogen-avro/v10/schema"
+)
+
+// CloneMethod returns the Clone method for the definition def,
+// or the empty string if Clone methods aren't being generated.
+func (gc *generateContext) CloneMethod(def schema.Definition) string {
+	if !gc.clone {
+		return ""
+	}
+	name := defName(def)
+	w := new(strings.Builder)
+	t, ok := def.(*schema.RecordDefinition)
+	if !ok {
+		// Enum and fixed types are plain values.
+		fprintf(w, "\n\n// Clone returns a copy of e.\n")
+		fprintf(w, "func (e %s) Clone() %s {\nreturn e\n}\n", name, name)
+		return w.String()
+	}
+	var body strings.Builder
+	for _, f := range t.Fields() {
+		if _, ok := gc.typeMap.mappedFieldType(t, f); ok {
+			// Mapped types are copied by assignment.
+			continue
+		}
+		field := fieldGoName(f)
+		body.WriteString(gc.cloneCode("r1."+field, "r."+field, f.Type(), 1))
+	}
+	fprintf(w, "\n\n// Clone returns a deep copy of r.\n")
+	if body.Len() == 0 {
+		fprintf(w, "func (r %s) Clone() %s {\nreturn r\n}\n", name, name)
+		return w.String()
+	}
+	fprintf(w, "func (r %s) Clone() %s {\n", name, name)
+	fprintf(w, "r1 := r\n")
+	w.WriteString(body.String())
+	fprintf(w, "return r1\n}\n")
+	return w.String()
+}
+
+// cloneCode returns code that makes dst a deep copy of
+// the value src of Avro type t, assuming that dst already
+// holds a shallow copy of src. It returns the empty string
+// if the shallow copy is sufficient. The depth parameter
+// is used to make variable names unique.
+func (gc *generateContext) cloneCode(dst, src string, t schema.AvroType, depth int) string {
+	if x, ok := gc.cloneExpr(src, t); ok {
+		if x == src {
+			return ""
+		}
+		return dst + " = " + x + "\n"
+	}
+	c := "c" + strconv.Itoa(depth)
+	x := "x" + strconv.Itoa(depth)
+	w := new(strings.Builder)
+	switch t := t.(type) {
+	case *schema.BytesField:
+		fprintf(w, "if %s != nil {\n%s = append([]byte{}, %s...)\n}\n", src, dst, src)
+	case *schema.ArrayField:
+		goType := gc.GoTypeOf(t).GoType
+		i := "i" + strconv.Itoa(depth)
+		inner := gc.cloneCode(c+"["+i+"]", x, t.ItemType(), depth+1)
+		if inner == "" {
+			fprintf(w, "if %s != nil {\n%s = append(%s{}, %s...)\n}\n", src, dst, goType, src)
+			break
+		}
+		fprintf(w, "if %s != nil {\n", src)
+		fprintf(w, "%s := append(%s{}, %s...)\n", c, goType, src)
+		fprintf(w, "for %s, %s := range %s {\n%s}\n", i, x, src, inner)
+		fprintf(w, "%s = %s\n}\n", dst, c)
+	case *schema.MapField:
+		goType := gc.GoTypeOf(t).GoType
+		k := "k" + strconv.Itoa(depth)
+		fprintf(w, "if %s != nil {\n", src)
+		fprintf(w, "%s := make(%s, len(%s))\n", c, goType, src)
+		fprintf(w, "for %s, %s := range %s {\n", k, x, src)
+		fprintf(w, "%s[%s] = %s\n", c, k, x)
+		w.WriteString(gc.cloneCode(c+"["+k+"]", x, t.ItemType(), depth+1))
+		fprintf(w, "}\n")
+		fprintf(w, "%s = %s\n}\n", dst, c)
+	case *schema.UnionField:
+		info := gc.GoTypeOf(t)
+		if info.GoType[0] == '*' {
+			w.WriteString(gc.clonePointerCode(dst, src, nonNullMember(t), depth))
+			break
+		}
+		var cases strings.Builder
+		done := make(map[string]bool)
+		for i, mt := range t.AvroTypes() {
+			goType := info.Union[i].GoType
+			if isNullField(mt) || done[goType] {
+				continue
+			}
+			done[goType] = true
+			if _, ok := mt.(*schema.BytesField); ok && info.Sealed {
+				// The copy must be converted back to the
+				// wrapper type to implement the interface.
+				fprintf(&cases, "case %s:\nif %s != nil {\n%s = %s(append([]byte{}, %s...))\n}\n", goType, x, dst, goType, x)
+				continue
+			}
+			if inner := gc.cloneCode(dst, x, mt, depth+1); inner != "" {
+				fprintf(&cases, "case %s:\n%s", goType, inner)
+			}
+			if _, ok := mt.(*schema.Reference); ok && info.Sealed {
+				// Pointers to member types implement
+				// the interface too.
+				if inner := gc.clonePointerCode(dst, x, mt, depth+1); inner != "" {
+					fprintf(&cases, "case *%s:\n%s", goType, inner)
+				}
+			}
+		}
+		if cases.Len() > 0 {
+			fprintf(w, "switch %s := %s.(type) {\n%s}\n", x, src, cases.String())
+		}
+	}
+	return w.String()
+}
+
+// clonePointerCode is like cloneCode but for the pointer src,
+// pointing to a value of Avro type t.
+func (gc *generateContext) clonePointerCode(dst, src string, t schema.AvroType, depth int) string {
+	c := "c" + strconv.Itoa(depth)
+	w := new(strings.Builder)
+	fprintf(w, "if %s != nil {\n", src)
+	if x, ok := gc.cloneExpr("*"+src, t); ok {
+		fprintf(w, "%s := %s\n", c, x)
+	} else {
+		fprintf(w, "%s := *%s\n", c, src)
+		w.WriteString(gc.cloneCode(c, "*"+src, t, depth+1))
+	}
+	fprintf(w, "%s = &%s\n}\n", dst, c)
+	return w.String()
+}
+
+// cloneExpr returns an expression that evaluates to a deep copy
+// of the value x of Avro type t. It reports false if that
+// can't be done with a single expression.
+func (gc *generateContext) cloneExpr(x string, t schema.AvroType) (string, bool) {
+	if _, ok := gc.typeMap.mappedType(t); ok {
+		return x, true
+	}
+	switch t := t.(type) {
+	case *schema.BytesField,
+		*schema.ArrayField,
+		*schema.MapField,
+		*schema.UnionField:
+		return "", false
+	case *schema.Reference:
+		if gc.isLocalRecord(t) {
+			return paren(x) + ".Clone()", true
+		}
+	}
+	// Other types are copied by assignment. Note that
+	// this includes external types, mapped types and records
+	// from other packages, which might not have a Clone method.
+	return x, true
+}
+
+// EqualMethod returns the Equal method for the definition def,
+// or the empty string if Equal methods aren't being generated.
+func (gc *generateContext) EqualMethod(def schema.Definition) string {
+	if !gc.equal {
+		return ""
+	}
+	name := defName(def)
+	w := new(strings.Builder)
+	t, ok := def.(*schema.RecordDefinition)
+	if !ok {
+		fprintf(w, "\n\n// Equal reports whether e and e1 hold the same value.\n")
+		fprintf(w, "func (e %s) Equal(e1 %s) bool {\nreturn e == e1\n}\n", name, name)
+		return w.String()
+	}
+	fprintf(w, "\n\n// Equal reports whether r and r1 hold the same value.\n")
+	if len(t.Fields()) == 0 {
+		fprintf(w, "func (%s) Equal(%s) bool {\nreturn true\n}\n", name, name)
+		return w.String()
+	}
+	fprintf(w, "func (r %s) Equal(r1 %s) bool {\n", name, name)
+	for _, f := range t.Fields() {
+		a, b := "r."+fieldGoName(f), "r1."+fieldGoName(f)
+		if gt, ok := gc.typeMap.mappedFieldType(t, f); ok {
+			w.WriteString(gc.equalMappedCode(a, b, gt))
+			continue
+		}
+		w.WriteString(gc.equalCode(a, b, f.Type(), 1))
+	}
+	fprintf(w, "return true\n}\n")
+	return w.String()
+}
+
+// equalCode returns code that returns false from the
+// enclosing function if the values a and b of Avro type t
+// aren't equal. The depth parameter is used to make
+// variable names unique.
+func (gc *generateContext) equalCode(a, b string, t schema.AvroType, depth int) string {
+	if gt, ok := gc.typeMap.mappedType(t); ok {
+		return gc.equalMappedCode(a, b, gt)
+	}
+	x := "x" + strconv.Itoa(depth)
+	y := "y" + strconv.Itoa(depth)
+	w := new(strings.Builder)
+	switch t := t.(type) {
+	case *schema.LongField:
+		if logicalType(t) == timestampMicros {
+			fprintf(w, "if !%s.Equal(%s) {\nreturn false\n}\n", paren(a), b)
+			break
+		}
+		fprintf(w, "if %s != %s {\nreturn false\n}\n", a, b)
+	case *schema.BytesField:
+		gc.addImport("bytes")
+		fprintf(w, "if !bytes.Equal(%s, %s) {\nreturn false\n}\n", a, b)
+	case *schema.ArrayField:
+		i := "i" + strconv.Itoa(depth)
+		fprintf(w, "if len(%s) != len(%s) {\nreturn false\n}\n", a, b)
+		fprintf(w, "for %s, %s := range %s {\n", i, x, a)
+		w.WriteString(gc.equalCode(x, paren(b)+"["+i+"]", t.ItemType(), depth+1))
+		fprintf(w, "}\n")
+	case *schema.MapField:
+		k := "k" + strconv.Itoa(depth)
+		fprintf(w, "if len(%s) != len(%s) {\nreturn false\n}\n", a, b)
+		fprintf(w, "for %s, %s := range %s {\n", k, x, a)
+		fprintf(w, "%s, ok := %s[%s]\n", y, paren(b), k)
+		fprintf(w, "if !ok {\nreturn false\n}\n")
+		w.WriteString(gc.equalCode(x, y, t.ItemType(), depth+1))
+		fprintf(w, "}\n")
+	case *schema.UnionField:
+		info := gc.GoTypeOf(t)
+		if info.GoType[0] == '*' {
+			w.WriteString(gc.equalPointerCode(a, b, nonNullMember(t), depth))
+			break
+		}
+		fprintf(w, "switch %s := %s.(type) {\n", x, a)
+		fprintf(w, "case nil:\nif %s != nil {\nreturn false\n}\n", b)
+		done := make(map[string]bool)
+		for i, mt := range t.AvroTypes() {
+			goType := info.Union[i].GoType
+			if isNullField(mt) || done[goType] {
+				continue
+			}
+			done[goType] = true
+			fprintf(w, "case %s:\n", goType)
+			fprintf(w, "%s, ok := %s.(%s)\n", y, b, goType)
+			fprintf(w, "if !ok {\nreturn false\n}\n")
+			w.WriteString(gc.equalCode(x, y, mt, depth+1))
+			if _, ok := mt.(*schema.Reference); ok && info.Sealed {
+				// Pointers to member types implement
+				// the interface too.
+				fprintf(w, "case *%s:\n", goType)
+				fprintf(w, "%s, ok := %s.(*%s)\n", y, b, goType)
+				fprintf(w, "if !ok {\nreturn false\n}\n")
+				w.WriteString(gc.equalPointerCode(x, y, mt, depth+1))
+			}
+		}
+		if !info.Sealed {
+			// The value isn't one of the allowed types,
+			// so fall back to comparing it dynamically.
+			gc.addImport("reflect")
+			fprintf(w, "default:\nif !reflect.DeepEqual(%s, %s) {\nreturn false\n}\n", a, b)
+		}
+		fprintf(w, "}\n")
+	case *schema.Reference:
+		if gc.isLocalRecord(t) {
+			fprintf(w, "if !%s.Equal(%s) {\nreturn false\n}\n", paren(a), b)
+			break
+		}
+		_, isExt := gc.extTypes[t.TypeName]
+		if _, ok := t.Def.(*schema.RecordDefinition); ok || isExt {
+			// We don't know whether the type has
+			// an Equal method, so compare it dynamically.
+			gc.addImport("reflect")
+			fprintf(w, "if !reflect.DeepEqual(%s, %s) {\nreturn false\n}\n", a, b)
+			break
+		}
+		fprintf(w, "if %s != %s {\nreturn false\n}\n", a, b)
+	default:
+		fprintf(w, "if %s != %s {\nreturn false\n}\n", a, b)
+	}
+	return w.String()
+}
+
+// equalMappedCode is like equalCode but for values
+// of a Go type specified by the type mapping.
+func (gc *generateContext) equalMappedCode(a, b string, gt goType) string {
+	if gt.PkgPath == "" {
+		return fmt.Sprintf("if %s != %s {\nreturn false\n}\n", a, b)
+	}
+	// We don't know whether the type has an Equal method
+	// or is even comparable, so compare it dynamically.
+	gc.addImport("reflect")
+	return fmt.Sprintf("if !reflect.DeepEqual(%s, %s) {\nreturn false\n}\n", a, b)
+}
+
+// equalPointerCode is like equalCode but for the pointers
+// a and b, pointing to values of Avro type t.
+func (gc *generateContext) equalPointerCode(a, b string, t schema.AvroType, depth int) string {
+	w := new(strings.Builder)
+	fprintf(w, "if (%s == nil) != (%s == nil) {\nreturn false\n}\n", a, b)
+	fprintf(w, "if %s != nil {\n%s}\n", a, gc.equalCode("*"+a, "*"+b, t, depth+1))
+	return w.String()
+}
+
+// isLocalRecord reports whether t refers to a record type
+// that's generated in the current package.
+func (gc *generateContext) isLocalRecord(t *schema.Reference) bool {
+	if _, ok := gc.extTypes[t.TypeName]; ok {
+		return false
+	}
+	_, ok := t.Def.(*schema.RecordDefinition)
+	return ok && goTypeForDefinition(t.Def).PkgPath == ""
+}
+
+// fieldGoName returns the name of the Go struct
+// field generated for the record field f.
+func fieldGoName(f *schema.Field) string {
+	if isExportedGoIdentifier(f.Name()) {
+		return f.Name()
+	}
+	// The template has already rejected names
+	// that can't be made into Go identifiers.
+	name, _ := goName(f.Name())
+	return name
+}
+
+// paren returns the expression x in parentheses if
+// it's a pointer dereference, so that it can be used
+// as the operand of a selector or index expression.
+func paren(x string) string {
+	if strings.HasPrefix(x, "*") {
+		return "(" + x + ")"
+	}
+	return x
+}
@@ -13,21 +13,29 @@ import (
 	"github.com/actgardner/gogen-avro/v10/parser"
 	"github.com/actgardner/gogen-avro/v10/schema"
 
+	"github.com/heetch/avro"
 	"github.com/heetch/avro/cmd/avrogo/avrotypemap"
 	"github.com/heetch/avro/internal/typeinfo"
 )
 
 type goType = avrotypemap.GoType
 
-// typeMap returns a map from definitions
+// externalTypeMap returns a map from definitions
 // in ns to the external Go types used.
-func externalTypeMap(ns *parser.Namespace) (map[schema.QualifiedName]goType, error) {
+// It also checks that all the Go types specified
+// by the type mapping tm are compatible with the
+// schema where they're used.
+func externalTypeMap(ns *parser.Namespace, tm *typeMapping) (map[schema.QualifiedName]goType, error) {
 	extGoTypes := make(map[goType]bool)
 	for _, def := range ns.Definitions {
-		if gt := goTypeForDefinition(def); gt.PkgPath != "" {
+		if gt := tm.definitionGoType(def); gt.PkgPath != "" {
 			extGoTypes[gt] = true
 		}
 	}
+	uses := tm.uses(ns)
+	for _, u := range uses {
+		extGoTypes[u.gt] = true
+	}
 	if len(extGoTypes) == 0 {
 		// No external types found.
 		return nil, nil
@@ -44,7 +52,7 @@ func externalTypeMap(ns *parser.Namespace) (map[schema.QualifiedName]goType, err
 
 	avroToGo := make(map[schema.QualifiedName]goType)
 	for name, def := range ns.Definitions {
-		gt := goTypeForDefinition(def)
+		gt := tm.definitionGoType(def)
 		if gt.PkgPath == "" {
 			continue
 		}
@@ -82,6 +90,19 @@ func externalTypeMap(ns *parser.Namespace) (map[schema.QualifiedName]goType, err
 			}
 		}
 	}
+	for _, u := range uses {
+		info := extTypeInfo[u.gt]
+		if info.Error != "" {
+			return nil, fmt.Errorf("cannot use %s for %s: %v", mappedTypeName(u.gt), u.path, info.Error)
+		}
+		extType, err := typeinfo.ParseSchema(info.Schema, nil)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse schema for %s: %v", mappedTypeName(u.gt), err)
+		}
+		if err := checkGoCompatible(u.path, u.t, extType, make(map[schema.AvroType]bool)); err != nil {
+			return nil, fmt.Errorf("mapped type %s for %s is not compatible; its schema is %s: %v", mappedTypeName(u.gt), u.path, info.Schema, err)
+		}
+	}
 	return avroToGo, nil
 }
 
@@ -97,7 +118,27 @@ func externalTypeMap(ns *parser.Namespace) (map[schema.QualifiedName]goType, err
 // TODO This isn't nice, but it's not clear how we can avoid it because
 // the enum logic relies on calling the String method, which
 // we can't do unless we actually run it.
+//
+// Information on predeclared types is obtained directly
+// without running a program.
 func externalTypeInfoForGoTypes(gts map[goType]bool) (map[goType]avrotypemap.ExternalTypeResult, error) {
+	results := make(map[goType]avrotypemap.ExternalTypeResult)
+	for gt := range gts {
+		if gt.PkgPath != "" {
+			continue
+		}
+		delete(gts, gt)
+		var result avrotypemap.ExternalTypeResult
+		if at, err := avro.TypeOf(reflect.Zero(predeclaredTypes[gt.Name]).Interface()); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Schema = at.String()
+		}
+		results[gt] = result
+	}
+	if len(gts) == 0 {
+		return results, nil
+	}
 
 	pkgs := make(map[string]int)
 	var pkgPaths []string
@@ -158,7 +199,6 @@ func externalTypeInfoForGoTypes(gts map[goType]bool) (map[goType]avrotypemap.Ext
 	if len(resultSlice) != len(gts) {
 		return nil, fmt.Errorf("unexpected result count, got %d want %d", len(resultSlice), len(gts))
 	}
-	results := make(map[goType]avrotypemap.ExternalTypeResult)
 	for i, result := range resultSlice {
 		results[mp.Types[i]] = result
 	}
@@ -27,7 +27,7 @@ const (
 const nullType = "avrotypegen.Null"
 
 // shouldImportAvroTypeGen return true if avrotypegen is required. It checks that the definitions given are of type
-// schema.RecordDefinition by looking at their match within given parsed namespace
+// schema.RecordDefinition, schema.FixedDefinition or schema.EnumDefinition with a default by looking at their match within given parsed namespace
 func shouldImportAvroTypeGen(namespace *parser.Namespace, definitions []schema.QualifiedName) bool {
 	for _, def := range namespace.Definitions {
 		defToGenerateIdx := sort.Search(len(definitions), func(i int) bool {
@@ -40,13 +40,18 @@ func shouldImportAvroTypeGen(namespace *parser.Namespace, definitions []schema.Q
 			if _, ok := def.(*schema.FixedDefinition); ok {
 				return true
 			}
+			if def, ok := def.(*schema.EnumDefinition); ok && def.Default() != "" {
+				return true
+			}
 		}
 	}
 	return false
 }
 
-func generate(w io.Writer, pkg string, ns *parser.Namespace, definitions []schema.QualifiedName) error {
-	extTypes, err := externalTypeMap(ns)
+// generate writes Go code for the given definitions to w.
+// If wrapper is non-nil, a wrapper type is generated too.
+func generate(w io.Writer, pkg string, ns *parser.Namespace, definitions []schema.QualifiedName, wrapper *wrapperType, opts generateOptions) error {
+	extTypes, err := externalTypeMap(ns, opts.typeMap)
 	if err != nil {
 		return err
 	}
@@ -57,20 +62,27 @@ func generate(w io.Writer, pkg string, ns *parser.Namespace, definitions []schem
 			localDefinitions = append(localDefinitions, name)
 		}
 	}
-	if len(localDefinitions) == 0 {
+	if len(localDefinitions) == 0 && wrapper == nil {
 		return nil
 	}
 	gc := &generateContext{
 		imports:  make(map[string]string),
 		extTypes: extTypes,
+		sealed:   opts.sealed,
+		typeMap:  opts.typeMap,
+		validate: opts.validate,
+		clone:    opts.clone,
+		equal:    opts.equal,
+		schemas:  opts.schemas,
 	}
 	// Add avrotypegen package conditionally when there is a RecordDefinition in the namespace.
-	if shouldImportAvroTypeGen(ns, definitions) {
+	if wrapper != nil || shouldImportAvroTypeGen(ns, definitions) {
 		gc.addImport("github.com/heetch/avro/avrotypegen")
 	}
 	var body bytes.Buffer
 	if err := bodyTemplate.Execute(&body, bodyTemplateParams{
 		Definitions: localDefinitions,
+		Wrapper:     wrapper,
 		NS:          ns,
 		Ctx:         gc,
 	}); err != nil {
@@ -85,7 +97,7 @@ func generate(w io.Writer, pkg string, ns *parser.Namespace, definitions []schem
 	// TODO look at the actual identifier used by the
 	// package to avoid the explicit identifer in more cases.
 	for pkg := range gc.imports {
-		if !strings.Contains(pkg, ".") || strings.HasPrefix(pkg, "github.com/heetch/avro/") {
+		if !strings.Contains(pkg, ".") || pkg == "github.com/heetch/avro" || strings.HasPrefix(pkg, "github.com/heetch/avro/") {
 			gc.imports[pkg] = ""
 		}
 	}
@@ -102,6 +114,35 @@ func generate(w io.Writer, pkg string, ns *parser.Namespace, definitions []schem
 	return nil
 }
 
+// generateOptions holds options that change the
+// code produced by generate.
+type generateOptions struct {
+	// sealed holds the sealed union state shared between
+	// all generated files. If it's non-nil, unions are
+	// represented as sealed interface types where possible.
+	sealed *sealedUnions
+
+	// validate holds whether a Validate method
+	// is generated for each record type.
+	validate bool
+
+	// clone and equal hold whether Clone and Equal
+	// methods are generated for each record, enum
+	// and fixed type.
+	clone bool
+	equal bool
+
+	// typeMap holds the Go types to use for Avro types
+	// instead of the usual ones, or nil if there are none.
+	typeMap *typeMapping
+
+	// schemas holds the schema variable state shared
+	// between all generated files. If it's non-nil, a
+	// schema variable and fingerprint constant are
+	// generated for each record type.
+	schemas *schemaVars
+}
+
 type typeInfo struct {
 	// GoType holds the name of the type used
 	// in Go. The "null" type is represented by
@@ -110,9 +151,17 @@ type typeInfo struct {
 
 	// Union holds type info for all the members of a union.
 	Union []typeInfo
+
+	// Sealed holds whether the union is represented
+	// by a generated interface type rather than interface{}.
+	Sealed bool
 }
 
 func (info typeInfo) Doc() string {
+	if info.Sealed {
+		// The interface type documents itself.
+		return ""
+	}
 	var buf strings.Builder
 	writeUnionComment(&buf, info.Union, "")
 	return buf.String()
@@ -158,9 +207,11 @@ func (gc *generateContext) RecordInfoLiteral(t *schema.RecordDefinition) (string
 		lit, err := gc.defaultFuncLiteral(f.Default(), f.Type())
 		if err != nil {
 			return "", fmt.Errorf("cannot generate code for field %s of record %v: %v", f.Name(), t.AvroName(), err)
-		} else {
-			fprintf(w, "func() interface{} {\nreturn %s\n},\n", lit)
 		}
+		if gt, ok := gc.typeMap.mappedFieldType(t, f); ok {
+			lit = gc.goTypeName(gt) + "(" + lit + ")"
+		}
+		fprintf(w, "func() interface{} {\nreturn %s\n},\n", lit)
 	}
 	if doneDefaults {
 		fprintf(w, "},\n")
@@ -168,7 +219,7 @@ func (gc *generateContext) RecordInfoLiteral(t *schema.RecordDefinition) (string
 
 	doneUnions := false
 	for i, f := range t.Fields() {
-		info := gc.GoTypeOf(f.Type())
+		info := gc.FieldTypeOf(t, f)
 		if canOmitUnionInfo(info) {
 			continue
 		}
@@ -187,6 +238,23 @@ func (gc *generateContext) RecordInfoLiteral(t *schema.RecordDefinition) (string
 	return w.String(), nil
 }
 
+func (gc *generateContext) WrapperInfoLiteral(wt *wrapperType) (string, error) {
+	w := new(strings.Builder)
+	fprintf(w, "avrotypegen.WrapperInfo{\n")
+	def, err := wt.Type.Definition(make(map[schema.QualifiedName]interface{}))
+	if err != nil {
+		return "", err
+	}
+	fprintf(w, "Schema: %s,\n", quote(jsonMarshal(def)))
+	if info := gc.GoTypeOf(wt.Type); !canOmitUnionInfo(info) {
+		fprintf(w, "Union: avrotypegen.UnionInfo")
+		writeUnionInfo(w, info)
+		fprintf(w, ",\n")
+	}
+	fprintf(w, "}")
+	return w.String(), nil
+}
+
 // canOmitUnionInfo reports whether the info for the
 // given union can be omitted from the UnionInfo.
 func canOmitUnionInfo(u typeInfo) bool {
@@ -278,6 +346,21 @@ func isZeroDefault(x interface{}, t schema.AvroType) bool {
 // defaultFuncLiteral returns a Go function definition that
 // returns the default value v as a Go value.
 func (gc *generateContext) defaultFuncLiteral(v interface{}, t schema.AvroType) (string, error) {
+	if gt, ok := gc.typeMap.mappedType(t); ok {
+		// The mapped type must be convertible from
+		// the Go type usually used for t.
+		lit, err := gc.unmappedDefaultFuncLiteral(v, t)
+		if err != nil {
+			return "", err
+		}
+		return gc.goTypeName(gt) + "(" + lit + ")", nil
+	}
+	return gc.unmappedDefaultFuncLiteral(v, t)
+}
+
+// unmappedDefaultFuncLiteral is like defaultFuncLiteral
+// but ignores any type mapping for t itself.
+func (gc *generateContext) unmappedDefaultFuncLiteral(v interface{}, t schema.AvroType) (string, error) {
 	switch t := t.(type) {
 	case *schema.UnionField:
 		// Defaults for unions fields always use the first member
@@ -482,17 +565,56 @@ func writeUnionComment(w io.Writer, union []typeInfo, indent string) {
 	printf("Allowed types for interface{} value:\n")
 	for _, t := range union {
 		printf("\t%s\n", t.GoType)
-		writeUnionComment(w, t.Union, indent+"\t")
+		if !t.Sealed {
+			writeUnionComment(w, t.Union, indent+"\t")
+		}
 	}
 }
 
 type generateContext struct {
 	imports  map[string]string
 	extTypes map[schema.QualifiedName]goType
+	typeMap  *typeMapping
+
+	// sealed holds the sealed union state shared between
+	// all generated files, or nil if sealed unions
+	// aren't enabled.
+	sealed *sealedUnions
+	// sealedUnions and primitiveWrappers hold the
+	// types that need to be generated in this file
+	// to support sealed unions.
+	sealedUnions      []sealedUnion
+	primitiveWrappers []primitiveWrapper
+
+	// validate, clone and equal hold whether Validate,
+	// Clone and Equal methods are generated.
+	validate bool
+	clone    bool
+	equal    bool
+
+	// schemas holds the schema variable state shared between
+	// all generated files, or nil if schema variables
+	// aren't generated.
+	schemas *schemaVars
+}
+
+// FieldTypeOf returns type information for the field f of
+// the record r, taking field type mappings into account.
+func (gc *generateContext) FieldTypeOf(r *schema.RecordDefinition, f *schema.Field) typeInfo {
+	if gt, ok := gc.typeMap.mappedFieldType(r, f); ok {
+		return typeInfo{
+			GoType: gc.goTypeName(gt),
+		}
+	}
+	return gc.GoTypeOf(f.Type())
 }
 
 func (gc *generateContext) GoTypeOf(t schema.AvroType) typeInfo {
 	var info typeInfo
+	if gt, ok := gc.typeMap.mappedType(t); ok {
+		info.GoType = gc.goTypeName(gt)
+		return info
+	}
 	switch t := t.(type) {
 	case *schema.NullField:
 		info.GoType = "avrotypegen.Null"
@@ -552,6 +674,11 @@ func (gc *generateContext) GoTypeOf(t schema.AvroType) typeInfo {
 				},
 			}
 		default:
+			if gc.sealed != nil {
+				if info, ok := gc.sealedUnionType(t); ok {
+					return info
+				}
+			}
 			info.GoType = "interface{}"
 			info.Union = make([]typeInfo, len(types))
 			for i, t := range types {
@@ -562,21 +689,18 @@ func (gc *generateContext) GoTypeOf(t schema.AvroType) typeInfo {
 		inner := gc.GoTypeOf(t.ItemType())
 		info.GoType = "[]" + inner.GoType
 		info.Union = inner.Union
+		info.Sealed = inner.Sealed
 	case *schema.MapField:
 		inner := gc.GoTypeOf(t.ItemType())
 		info.GoType = "map[string]" + inner.GoType
 		info.Union = inner.Union
+		info.Sealed = inner.Sealed
 	case *schema.Reference:
 		gt, ok := gc.extTypes[t.TypeName]
 		if !ok {
 			gt = goTypeForDefinition(t.Def)
 		}
-		name := gt.Name
-		if gt.PkgPath != "" {
-			ident := gc.addImport(gt.PkgPath)
-			name = ident + "." + name
-		}
-		info.GoType = name
+		info.GoType = gc.goTypeName(gt)
 	default:
 		panic(fmt.Sprintf("unknown avro type %T", t))
 	}
@@ -593,6 +717,15 @@ func logicalType(t schema.AvroType) string {
 	return s
 }
 
+// goTypeName returns the name to use for gt in
+// generated code, adding its package to the imports.
+func (gc *generateContext) goTypeName(gt goType) string {
+	if gt.PkgPath == "" {
+		return gt.Name
+	}
+	return gc.addImport(gt.PkgPath) + "." + gt.Name
+}
+
 // addImport adds a package to the required imports.
 func (gc *generateContext) addImport(pkg string) string {
 	if id := gc.imports[pkg]; id != "" {
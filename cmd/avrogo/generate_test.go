@@ -17,7 +17,8 @@ func TestShouldImportAvroTypeGen(t *testing.T) {
 	var eventNameAsRecordDefinition = schema.NewRecordDefinition(eventNameQualifiedName, []avro.QualifiedName{}, []*avro.Field{}, "", map[string]interface{}{})
 	var eventNameAsFixedFieldDefinition = schema.NewFixedDefinition(eventNameQualifiedName, []avro.QualifiedName{}, 142, map[string]interface{}{})
 	var modelDefinitionQualifiedName = schema.QualifiedName{Namespace: "ModelDefinition", Name: "ModelDefinition"}
-	var modelAsEnumDefinition = schema.NewEnumDefinition(modelDefinitionQualifiedName, []avro.QualifiedName{}, []string{"", ""}, "", "defaultValue", map[string]interface{}{})
+	var modelAsEnumDefinition = schema.NewEnumDefinition(modelDefinitionQualifiedName, []avro.QualifiedName{}, []string{"", ""}, "", "", map[string]interface{}{})
+	var modelAsEnumDefinitionWithDefault = schema.NewEnumDefinition(modelDefinitionQualifiedName, []avro.QualifiedName{}, []string{"", ""}, "", "defaultValue", map[string]interface{}{})
 
 	var shouldImportAvroTypeGenTests = []struct {
 		testName                string
@@ -67,6 +68,16 @@ func TestShouldImportAvroTypeGen(t *testing.T) {
 			definitions:             []schema.QualifiedName{modelDefinitionQualifiedName},
 			shouldImportAvroTypeGen: false,
 		},
+		{
+			testName: "true-definition-present-in-namespace-and-is-enum-type-with-default",
+			namespace: &parser.Namespace{
+				Definitions: map[schema.QualifiedName]schema.Definition{
+					modelDefinitionQualifiedName: modelAsEnumDefinitionWithDefault,
+				},
+			},
+			definitions:             []schema.QualifiedName{modelDefinitionQualifiedName},
+			shouldImportAvroTypeGen: true,
+		},
 	}
 
 	c := qt.New(t)
@@ -85,10 +96,10 @@ func TestGenerate(t *testing.T) {
 
 	var buf bytes.Buffer
 	testPackage := "dummy"
-	ns, fileDefinitions, err := parseFiles([]string{"testdata/schema/object.avsc"})
+	ns, fileDefinitions, _, err := parseFiles([]string{"testdata/schema/object.avsc"})
 	assert.NoError(t, err)
 
-	err = generate(&buf, testPackage, ns, fileDefinitions[0])
+	err = generate(&buf, testPackage, ns, fileDefinitions[0], nil, generateOptions{})
 	assert.NoError(t, err)
 	g.Assert(t, "object", buf.Bytes())
 }
@@ -118,7 +118,7 @@ func main() {
 				schemaFiles = append(schemaFiles, f)
 			}
 			var buf bytes.Buffer
-			args := []string{"-p", test.TestName, "schema.avsc"}
+			args := append([]string{"-p", test.TestName}, test.AvrogoFlags...)
 			args = append(args, schemaFiles...)
 			cmd := exec.Command("avrogo", args...)
 			cmd.Stderr = &buf
@@ -16,6 +16,7 @@ type Test struct {
 	GoType        string             `json:"goType"`
 	GoTypeBody    string             `json:"goTypeBody"`
 	GenerateError string             `json:"generateError"`
+	AvrogoFlags   []string           `json:"avrogoFlags"`
 	Subtests      map[string]Subtest `json:"subtests"`
 	OtherTests    string             `json:"otherTests"`
 }
@@ -0,0 +1,75 @@
+package cloneEqual
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func newR() R {
+	return R{
+		B: []byte("b"),
+		T: time.Date(2020, 1, 16, 12, 2, 42, 0, time.UTC),
+		E: ColourGreen,
+		P: &[]string{"p"},
+		U: []byte("u"),
+		A: []Item{{
+			Tags: map[string]string{"a": "x"},
+		}},
+		M: map[string][][]byte{
+			"m": {[]byte("y")},
+		},
+		Next: &R{
+			U: "s",
+		},
+	}
+}
+
+func TestClone(t *testing.T) {
+	c := qt.New(t)
+	r := newR()
+	r1 := r.Clone()
+	c.Assert(r1, qt.DeepEquals, r)
+
+	// Changing the clone doesn't change the original.
+	r1.B[0] = 'x'
+	(*r1.P)[0] = "x"
+	r1.U.([]byte)[0] = 'x'
+	r1.A[0].Tags["a"] = "y"
+	r1.M["m"][0][0] = 'x'
+	r1.Next.U = "t"
+	c.Assert(r, qt.DeepEquals, newR())
+}
+
+func TestEqual(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(newR().Equal(newR()), qt.IsTrue)
+	c.Assert(R{}.Equal(R{}), qt.IsTrue)
+
+	// Times in different locations are equal when
+	// they represent the same instant.
+	r := newR()
+	r.T = r.T.In(time.FixedZone("x", 3600))
+	c.Assert(r.Equal(newR()), qt.IsTrue)
+
+	// Nil and empty slices and maps are equal.
+	c.Assert(R{A: []Item{}, M: map[string][][]byte{}}.Equal(R{}), qt.IsTrue)
+
+	for i, f := range []func(r *R){
+		func(r *R) { r.B[0] = 'x' },
+		func(r *R) { r.T = r.T.Add(1) },
+		func(r *R) { r.E = ColourRed },
+		func(r *R) { r.P = nil },
+		func(r *R) { r.U = "u" },
+		func(r *R) { r.U = nil },
+		func(r *R) { r.A[0].Tags["b"] = "y" },
+		func(r *R) { r.M["m"][0] = nil },
+		func(r *R) { r.Next.U = "t" },
+	} {
+		r := newR()
+		f(&r)
+		c.Check(r.Equal(newR()), qt.IsFalse, qt.Commentf("change %d", i))
+		c.Check(newR().Equal(r), qt.IsFalse, qt.Commentf("change %d", i))
+	}
+}
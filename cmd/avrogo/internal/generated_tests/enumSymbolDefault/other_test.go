@@ -0,0 +1,22 @@
+package enumSymbolDefault
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestSchema(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.TypeOf(MyEnumUnknown)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "enum",
+		"name": "MyEnum",
+		"symbols": ["unknown", "a", "b", "c"],
+		"default": "unknown"
+	}`))
+}
@@ -29,7 +29,7 @@ var tests = testutil.RoundTripTest{
                         "T": "invalid_uuid"
                     }`,
 		OutDataJSON: `null`,
-		ExpectError: map[testutil.ErrorType]string{`unmarshal`: `invalid UUID in Avro encoding: invalid UUID length: 12`},
+		ExpectError: map[testutil.ErrorType]string{`unmarshal`: `R.T \(offset 13\): invalid UUID in Avro encoding: invalid UUID length: 12`},
 	}},
 }
 
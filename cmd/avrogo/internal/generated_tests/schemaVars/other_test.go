@@ -0,0 +1,29 @@
+package schemaVars
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestSchemaVars(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(RAvroType.Name(), qt.Equals, "com.example.R")
+	c.Assert(InnerAvroType.Name(), qt.Equals, "com.example.Inner")
+	c.Assert(RAvroType.Fingerprint(), qt.Equals, RAvroFingerprint)
+	c.Assert(InnerAvroType.Fingerprint(), qt.Equals, InnerAvroFingerprint)
+
+	// The schema is the same one that TypeOf returns.
+	rt, err := avro.TypeOf(R{})
+	c.Assert(err, qt.IsNil)
+	c.Assert(rt.Fingerprint(), qt.Equals, RAvroFingerprint)
+}
+
+func TestRegisterSchemasSignature(t *testing.T) {
+	// RegisterSchemas needs a registry server, so
+	// just check that it's been generated.
+	c := qt.New(t)
+	c.Assert(RegisterSchemas, qt.Not(qt.IsNil))
+}
@@ -0,0 +1,29 @@
+package sealedUnion
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestSealedUnionTypes(t *testing.T) {
+	c := qt.New(t)
+	// U and V share the same interface type.
+	r := R{
+		U: StringValue("a"),
+		V: &A{X: 3},
+		L: []LongOrString{LongValue(1), StringValue("b")},
+	}
+	data, wType, err := avro.Marshal(r)
+	c.Assert(err, qt.IsNil)
+	var r1 R
+	_, err = avro.Unmarshal(data, &r1, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r1, qt.DeepEquals, R{
+		U: StringValue("a"),
+		V: A{X: 3},
+		L: []LongOrString{LongValue(1), StringValue("b")},
+	})
+}
@@ -0,0 +1,21 @@
+package sealedUnionClone
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestClone(t *testing.T) {
+	c := qt.New(t)
+	r := R{U: BytesValue("b")}
+	r1 := r.Clone()
+	c.Assert(r1, qt.DeepEquals, r)
+
+	// Changing the clone doesn't change the original.
+	r1.U.(BytesValue)[0] = 'x'
+	c.Assert(r, qt.DeepEquals, R{U: BytesValue("b")})
+
+	r = R{U: StringValue("s")}
+	c.Assert(r.Clone(), qt.DeepEquals, r)
+}
@@ -0,0 +1,38 @@
+package sealedUnionValidate
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
+)
+
+func TestValidateNilPointer(t *testing.T) {
+	c := qt.New(t)
+	r := R{
+		U: &A{X: 1},
+		V: StringValue("a"),
+	}
+	c.Assert(r.Validate(), qt.IsNil)
+
+	// A nil pointer to a member type can't be encoded,
+	// even when the union allows null.
+	r = R{
+		U: (*A)(nil),
+		V: (*A)(nil),
+	}
+	_, _, err := avro.Marshal(r)
+	c.Assert(err, qt.Not(qt.IsNil))
+	err = r.Validate()
+	c.Assert(err, qt.Not(qt.IsNil))
+	var paths []string
+	for _, err := range err.(avrotypegen.ValidationErrors) {
+		paths = append(paths, err.Error())
+	}
+	c.Assert(paths, qt.DeepEquals, []string{
+		"U: nil value not allowed in union",
+		"V: nil value not allowed in union",
+	})
+}
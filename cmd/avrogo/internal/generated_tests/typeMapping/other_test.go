@@ -0,0 +1,37 @@
+package typeMapping
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/internal/testtypes"
+)
+
+func TestMappedTypes(t *testing.T) {
+	c := qt.New(t)
+	p := int32(2)
+	r := R{
+		N:  1,
+		P:  &p,
+		T:  testtypes.Millis(time.UnixMilli(1579176162001).UTC()),
+		Id: testtypes.ID("x"),
+	}
+	data, wType, err := avro.Marshal(r)
+	c.Assert(err, qt.IsNil)
+	var r1 R
+	_, err = avro.Unmarshal(data, &r1, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(time.Time(r1.T).Equal(time.Time(r.T)), qt.IsTrue)
+	r1.T = r.T
+	c.Assert(*r1.P, qt.Equals, *r.P)
+	r1.P = r.P
+	c.Assert(r1, qt.Equals, r)
+}
+
+func TestMappedDefault(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(R{}.AvroRecord().Defaults[0](), qt.Equals, int32(5))
+}
@@ -0,0 +1,50 @@
+package validate
+
+import (
+	"math"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro/avrotypegen"
+)
+
+func TestValidate(t *testing.T) {
+	c := qt.New(t)
+	r := R{
+		I: 1,
+		V: 2,
+		A: []Item{{N: 3}},
+		M: map[string]Colour{"a": ColourGreen},
+	}
+	c.Assert(r.Validate(), qt.IsNil)
+
+	p := math.MaxInt32 + 1
+	r = R{
+		I: math.MinInt32 - 1,
+		E: Colour(2),
+		P: &p,
+		U: 1.5,
+		A: []Item{{N: 1}, {N: math.MaxInt32 + 1}},
+		M: map[string]Colour{"a": -1},
+		Next: &R{
+			V: "x",
+		},
+	}
+	err := r.Validate()
+	c.Assert(err, qt.ErrorMatches, `8 validation errors: .*`)
+	var paths []string
+	for _, err := range err.(avrotypegen.ValidationErrors) {
+		paths = append(paths, err.Error())
+	}
+	c.Assert(paths, qt.DeepEquals, []string{
+		"I: value out of range for int",
+		"E: Colour value 2 is out of bounds",
+		"P: value out of range for int",
+		"U: value of type float64 not allowed in union",
+		"V: nil value not allowed in union",
+		`A[1].n: value out of range for int`,
+		`M["a"]: Colour value -1 is out of bounds`,
+		"Next.V: value of type string not allowed in union",
+	})
+}
@@ -7,6 +7,13 @@
 // Type names within different schemas may refer to one another;
 // for example to put a shared definition in a separate .avsc file.
 //
+// Files with a .avdl extension are read as Avro IDL protocols, and
+// files with a .avpr extension as JSON-encoded protocols. The types
+// defined in a protocol are generated as if they'd been defined in
+// a schema file. Types from imported files are generated along with
+// the importing protocol unless the imported file is also specified
+// on the command line.
+//
 // Usage:
 //
 //		usage: avrogo [flags] schema-file...
@@ -19,6 +26,97 @@
 //		    	suffix for generated files (default "_gen")
 //	   -tokenize
 //	         if true, generate one dedicated file per qualified name found in the schema files
+//	  -sealed
+//	    	generate a sealed interface type for each union instead of using interface{} where possible
+//	  -clone
+//	    	generate a Clone method for each record, enum and fixed type
+//	  -equal
+//	    	generate an Equal method for each record, enum and fixed type
+//	  -map string
+//	    	read Go type mappings from this JSON or YAML file
+//	  -schemas
+//	    	generate a schema variable and fingerprint constant for each record type, and a RegisterSchemas function
+//	  -validate
+//	    	generate a Validate method for each record type
+//	  -w string
+//	    	name of the wrapper type generated for a schema whose top level type has no name
+//	    	(defaults to a name derived from the file name)
+//
+// When the top level of a schema isn't a definition (for example
+// it's a union of records, an array or a map), a wrapper struct type
+// is also generated with a single Value field that holds the top level
+// value. By default its name is derived from the schema file name,
+// so "user-events.avsc" results in a type named UserEvents.
+// The -w flag can be used to choose a different name when
+// there's only one such schema.
+//
+// By default, a union with more than one non-null member is represented
+// as interface{}. When the -sealed flag is set, a named interface type
+// with an unexported marker method is generated for it instead, and
+// each member type implements the marker method, so that the compiler
+// rejects values that can't be encoded. Primitive members are represented
+// by generated wrapper types such as StringValue and LongValue. The name
+// of the interface is made by joining the member names with "Or",
+// so ["null", "A", "B"] results in AOrB. Unions with members that
+// can't have methods defined on them (arrays, maps, logical types
+// and external types) still use interface{}. All the schemas for a
+// package should be generated by a single avrogo invocation because
+// each interface type is only generated once.
+//
+// When the -validate flag is set, each generated record type also
+// has a Validate method that checks that the value can be encoded
+// with its schema, so that producers can find out about invalid
+// data before calling avro.Marshal. It reports all the problems it
+// finds, each with the path to the offending field; for example an
+// int field holding a value that doesn't fit in 32 bits, an enum
+// value that's out of range or a union field holding a value that
+// isn't one of the union's member types. Record fields are validated
+// recursively.
+//
+// The -clone and -equal flags cause Clone and Equal methods to be
+// generated for each record, enum and fixed type. Clone returns a deep
+// copy of a value, including the contents of pointers, slices, maps
+// and union values. Equal compares two values field by field, following
+// pointers and looking inside unions; time.Time values are compared
+// with their Equal method, and a nil slice or map is considered equal
+// to an empty one because they encode the same way. Types from other
+// packages are copied by assignment and compared with reflect.DeepEqual.
+//
+// The -map flag specifies a file that changes the Go types used
+// for Avro types. The file may be in JSON or YAML format, as
+// determined by its extension, and holds up to three fields, each
+// mapping to a Go type name such as "int32", "time.Time" or
+// "example.com/money.Amount":
+//
+//	# logicalTypes maps from logical type name.
+//	logicalTypes:
+//	  timestamp-millis: example.com/timex.Millis
+//	# types maps from primitive Avro type name or
+//	# fully qualified definition name.
+//	types:
+//	  int: int32
+//	  com.example.Money: example.com/money.Amount
+//	# fields maps from fully qualified record name and field name.
+//	fields:
+//	  com.example.Order.id: example.com/ids.OrderID
+//
+// A field mapping takes precedence over a type mapping, which takes
+// precedence over the usual rules. Definitions mapped to Go types aren't
+// generated, just like definitions with a go.package annotation. As for
+// go.package types, avrogo checks that each mapped Go type is compatible
+// with the schema where it's used, by comparing the schema from avro.TypeOf
+// with the schema; for example, a type implementing avro.AvroRepresenter
+// can be used for a logical type that has no built-in Go representation.
+//
+// When the -schemas flag is set, each generated record type R is
+// accompanied by a package-level RAvroType variable holding its parsed
+// schema and an RAvroFingerprint constant holding the schema's
+// fingerprint (see avro.Type.Fingerprint). A file named
+// "registerschemas" plus the usual suffix is also generated, holding
+// a function that registers all the record schemas with a registry,
+// so that they can be registered at startup with a single call:
+//
+//	func RegisterSchemas(ctx context.Context, r *avroregistry.Registry, subject avroregistry.SubjectStrategy) error
 //
 // By default, a type is generated for each Avro definition
 // in the schema. Some additional metadata fields are
@@ -36,9 +134,13 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+
 	"github.com/actgardner/gogen-avro/v10/parser"
 	"github.com/actgardner/gogen-avro/v10/resolver"
 	"github.com/actgardner/gogen-avro/v10/schema"
@@ -54,6 +156,13 @@ var (
 	testFlag     = flag.Bool("t", strings.HasSuffix(os.Getenv("GOFILE"), "_test.go"), "generated files will have _test.go suffix (defaults to true if $GOFILE is a test file)")
 	suffixFlag   = flag.String("s", "_gen", "suffix for generated files")
 	tokenizeFlag = flag.Bool("tokenize", false, "generate one dedicated file per qualified name found in the input schema files")
+	sealedFlag   = flag.Bool("sealed", false, "generate a sealed interface type for each union instead of using interface{} where possible")
+	cloneFlag    = flag.Bool("clone", false, "generate a Clone method for each record, enum and fixed type")
+	mapFlag      = flag.String("map", "", "read Go type mappings from this JSON or YAML file")
+	schemasFlag  = flag.Bool("schemas", false, "generate a schema variable and fingerprint constant for each record type, and a RegisterSchemas function")
+	equalFlag    = flag.Bool("equal", false, "generate an Equal method for each record, enum and fixed type")
+	validateFlag = flag.Bool("validate", false, "generate a Validate method for each record type")
+	wrapperFlag  = flag.String("w", "", "name of the wrapper type generated for a schema whose top level type has no name (defaults to a name derived from the file name)")
 )
 
 var flag = stdflag.NewFlagSet("", stdflag.ContinueOnError)
@@ -87,35 +196,93 @@ func main1() int {
 }
 
 func generateFiles(files []string) error {
-	ns, fileDefinitions, err := parseFiles(files)
+	ns, fileDefinitions, wrappers, err := parseFiles(files)
 	if err != nil {
 		return err
 	}
+	if *wrapperFlag != "" {
+		var found *wrapperType
+		for _, w := range wrappers {
+			if w == nil {
+				continue
+			}
+			if found != nil {
+				return fmt.Errorf("-w flag specified but both %s and %s have a top level type without a name", found.File, w.File)
+			}
+			found = w
+		}
+		if found != nil {
+			if !isExportedGoIdentifier(*wrapperFlag) {
+				return fmt.Errorf("-w flag %q is not a valid exported Go identifier", *wrapperFlag)
+			}
+			found.Name = *wrapperFlag
+		}
+	}
 
 	outfiles, err := outputPaths(files, *testFlag)
 	if err != nil {
 		return err
 	}
+	opts := generateOptions{
+		validate: *validateFlag,
+		clone:    *cloneFlag,
+		equal:    *equalFlag,
+	}
+	if *sealedFlag {
+		opts.sealed = newSealedUnions()
+	}
+	if *schemasFlag {
+		opts.schemas = new(schemaVars)
+	}
+	if *mapFlag != "" {
+		tm, err := readTypeMapping(*mapFlag)
+		if err != nil {
+			return err
+		}
+		opts.typeMap = tm
+	}
 
 	if *tokenizeFlag {
-		for _, fileDefinition := range fileDefinitions {
+		for i, fileDefinition := range fileDefinitions {
 			for _, qualifiedName := range fileDefinition {
 				outputPath := path.Join(strings.ToLower(qualifiedName.Name) + *suffixFlag + ".go")
 				singleFileList := []schema.QualifiedName{qualifiedName}
 
-				if err := generateFile(outputPath, ns, singleFileList); err != nil {
+				if err := generateFile(outputPath, ns, singleFileList, nil, opts); err != nil {
 					return fmt.Errorf("cannot generate code for %s.%s: %v", qualifiedName.Namespace, qualifiedName.Name, err)
 				}
 			}
+			if w := wrappers[i]; w != nil {
+				outputPath := path.Join(strings.ToLower(w.Name) + *suffixFlag + ".go")
+				if err := generateFile(outputPath, ns, nil, w, opts); err != nil {
+					return fmt.Errorf("cannot generate code for %s: %v", w.Name, err)
+				}
+			}
 		}
 	} else {
 		for i, f := range files {
-			if err := generateFile(outfiles[f], ns, fileDefinitions[i]); err != nil {
+			if err := generateFile(outfiles[f], ns, fileDefinitions[i], wrappers[i], opts); err != nil {
 				return fmt.Errorf("cannot generate code for %s: %v", f, err)
 			}
 		}
 	}
-
+	if opts.schemas != nil {
+		data, err := opts.schemas.registerSchemasFile(*pkgFlag)
+		if err != nil {
+			return fmt.Errorf("cannot generate RegisterSchemas: %v", err)
+		}
+		if data != nil {
+			outFile := outputPath("registerschemas", *testFlag)
+			for f, out := range outfiles {
+				if out == outFile {
+					return fmt.Errorf("cannot generate RegisterSchemas: %s would overwrite the code generated for %s", outFile, f)
+				}
+			}
+			if err := writeGoFile(outFile, data); err != nil {
+				return fmt.Errorf("cannot generate RegisterSchemas: %v", err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -196,9 +363,9 @@ func baseN(name string, n int) (string, bool) {
 	return strings.Join(parts, "_"), ok
 }
 
-func generateFile(outFile string, ns *parser.Namespace, definitions []schema.QualifiedName) error {
+func generateFile(outFile string, ns *parser.Namespace, definitions []schema.QualifiedName, wrapper *wrapperType, opts generateOptions) error {
 	var buf bytes.Buffer
-	if err := generate(&buf, *pkgFlag, ns, definitions); err != nil {
+	if err := generate(&buf, *pkgFlag, ns, definitions, wrapper, opts); err != nil {
 		return err
 	}
 	if buf.Len() == 0 {
@@ -206,9 +373,15 @@ func generateFile(outFile string, ns *parser.Namespace, definitions []schema.Qua
 		// avsc file are external).
 		return nil
 	}
-	resultData, err := format.Source(buf.Bytes())
+	return writeGoFile(outFile, buf.Bytes())
+}
+
+// writeGoFile formats the Go source in data and writes
+// it to outFile within the output directory.
+func writeGoFile(outFile string, data []byte) error {
+	resultData, err := format.Source(data)
 	if err != nil {
-		fmt.Printf("%s\n", buf.Bytes())
+		fmt.Printf("%s\n", data)
 		return fmt.Errorf("cannot format source: %v", err)
 	}
 	if err := os.MkdirAll(*dirFlag, 0777); err != nil {
@@ -225,52 +398,56 @@ func generateFile(outFile string, ns *parser.Namespace, definitions []schema.Qua
 // a namespace containing all of the definitions in all of the files
 // and a slice with an element for each file holding a slice
 // of all the definitions within that file.
-func parseFiles(files []string) (*parser.Namespace, [][]schema.QualifiedName, error) {
+// It also returns a slice with an element for each file
+// holding the wrapper type to generate for that file,
+// or nil if its top level type is a definition.
+func parseFiles(files []string) (*parser.Namespace, [][]schema.QualifiedName, []*wrapperType, error) {
 	var fileDefinitions [][]schema.QualifiedName
+	var wrappers []*wrapperType
 	ns := parser.NewNamespace(false)
+	imports := newProtocolImports(files)
 	for _, f := range files {
+		if isProtocolFile(f) {
+			schemas, err := imports.schemas(f)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			var definitions []schema.QualifiedName
+			for _, data := range schemas {
+				defs, _, err := parseSchema(ns, f, data)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				definitions = append(definitions, defs...)
+			}
+			sortDefinitions(definitions)
+			fileDefinitions = append(fileDefinitions, definitions)
+			wrappers = append(wrappers, nil)
+			continue
+		}
 		data, err := os.ReadFile(f)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
-		var definitions []schema.QualifiedName
-		// Make a new namespace just for this file only
-		// so we can tell which names are defined in this
-		// file alone.
-		singleNS := parser.NewNamespace(false)
-		avroType, err := singleNS.TypeForSchema(data)
+		definitions, avroType, err := parseSchema(ns, f, data)
 		if err != nil {
-			return nil, nil, fmt.Errorf("invalid schema in %s: %v", f, err)
-		}
-		if _, ok := avroType.(*schema.Reference); !ok {
-			// The schema doesn't have a top-level name.
-			// TODO how should we cope with a schema that's not
-			// a definition? In that case we don't have
-			// a name for the type, and we may not be able to define
-			// methods on it because it might be a union type which
-			// is represented by an interface type in Go.
-			// See https://github.com/heetch/avro/issues/13
-			return nil, nil, fmt.Errorf("cannot generate code for schema %q which hasn't got a name (%T)", f, avroType)
-		}
-		for name, def := range singleNS.Definitions {
-			if name != def.AvroName() {
-				// It's an alias, so ignore it.
-				continue
-			}
-			definitions = append(definitions, name)
+			return nil, nil, nil, err
 		}
-		// Sort the definitions so we get deterministic output.
-		// TODO sort topologically so we get top level definitions
-		// before lower level definitions.
-		sort.Slice(definitions, func(i, j int) bool {
-			return definitions[i].String() < definitions[j].String()
-		})
+		sortDefinitions(definitions)
 		fileDefinitions = append(fileDefinitions, definitions)
-		// Parse the schema again but use the global namespace
-		// this time so all the schemas can share the same definitions.
-		if _, err := ns.TypeForSchema(data); err != nil {
-			return nil, nil, fmt.Errorf("cannot parse schema in %s: %v", f, err)
+		if _, ok := avroType.(*schema.Reference); ok {
+			wrappers = append(wrappers, nil)
+			continue
 		}
+		// The schema doesn't have a top-level name (it might be a
+		// union, for example) so we generate a wrapper type to hold
+		// values of the top level type.
+		// See https://github.com/heetch/avro/issues/13
+		wrappers = append(wrappers, &wrapperType{
+			Name: wrapperTypeName(f),
+			File: filepath.Base(f),
+			Type: avroType,
+		})
 	}
 	// Now we've accumulated all the available types,
 	// resolve the names with respect to the complete
@@ -279,8 +456,75 @@ func parseFiles(files []string) (*parser.Namespace, [][]schema.QualifiedName, er
 		if err := resolver.ResolveDefinition(def, ns.Definitions); err != nil {
 			// TODO find out which file(s) the definition came from
 			// and include that file name in the error.
-			return nil, nil, fmt.Errorf("cannot resolve reference %q: %v", name, err)
+			return nil, nil, nil, fmt.Errorf("cannot resolve reference %q: %v", name, err)
 		}
 	}
-	return ns, fileDefinitions, nil
+	return ns, fileDefinitions, wrappers, nil
 }
+
+// parseSchema parses the schema in data, read from the file f,
+// into ns. It returns the names of the definitions in the schema
+// and its top level type.
+func parseSchema(ns *parser.Namespace, f string, data []byte) ([]schema.QualifiedName, schema.AvroType, error) {
+	var definitions []schema.QualifiedName
+	// Make a new namespace just for this schema only
+	// so we can tell which names are defined in it.
+	singleNS := parser.NewNamespace(false)
+	if _, err := singleNS.TypeForSchema(data); err != nil {
+		return nil, nil, fmt.Errorf("invalid schema in %s: %v", f, err)
+	}
+	for name, def := range singleNS.Definitions {
+		if name != def.AvroName() {
+			// It's an alias, so ignore it.
+			continue
+		}
+		definitions = append(definitions, name)
+	}
+	// Parse the schema again but use the global namespace
+	// this time so all the schemas can share the same definitions.
+	avroType, err := ns.TypeForSchema(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse schema in %s: %v", f, err)
+	}
+	return definitions, avroType, nil
+}
+
+// sortDefinitions sorts the definitions so we get deterministic output.
+// TODO sort topologically so we get top level definitions
+// before lower level definitions.
+func sortDefinitions(definitions []schema.QualifiedName) {
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].String() < definitions[j].String()
+	})
+}
+
+// wrapperType describes a Go type that's generated to hold
+// values of a schema whose top level type isn't a definition.
+type wrapperType struct {
+	// Name holds the Go name of the type.
+	Name string
+	// File holds the base name of the schema file.
+	File string
+	// Type holds the top level Avro type of the schema.
+	Type schema.AvroType
+}
+
+// wrapperTypeName returns the default name of the wrapper
+// type generated for the given schema file, derived from
+// the file's base name; for example "user-events.avsc"
+// results in "UserEvents".
+func wrapperTypeName(file string) string {
+	base := filepath.Base(file)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	var buf strings.Builder
+	for _, part := range nonIdentifierPattern.Split(base, -1) {
+		buf.WriteString(cases.Title(language.Und, cases.NoLower).String(part))
+	}
+	name := buf.String()
+	if !isExportedGoIdentifier(name) {
+		name = "Schema" + name
+	}
+	return name
+}
+
+var nonIdentifierPattern = regexp.MustCompile(`[^a-zA-Z0-9]+`)
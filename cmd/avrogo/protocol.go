@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/heetch/avro/internal/avdl"
+)
+
+// isProtocolFile reports whether the given file holds
+// an Avro protocol rather than a schema.
+func isProtocolFile(f string) bool {
+	switch filepath.Ext(f) {
+	case ".avdl", ".avpr":
+		return true
+	}
+	return false
+}
+
+// protocolImports keeps track of the files that types in
+// protocol files come from, so that each type is generated
+// exactly once even when several protocols import the same file.
+type protocolImports struct {
+	// args holds the absolute paths of all the files
+	// specified on the command line.
+	args map[string]bool
+
+	// owner maps the absolute path of each imported file
+	// that isn't on the command line to the protocol file
+	// that its types are generated alongside.
+	owner map[string]string
+}
+
+func newProtocolImports(files []string) *protocolImports {
+	imports := &protocolImports{
+		args:  make(map[string]bool),
+		owner: make(map[string]string),
+	}
+	for _, f := range files {
+		imports.args[absPath(f)] = true
+	}
+	return imports
+}
+
+// schemas reads the protocol in the file f and returns
+// the schemas of the types that should be generated
+// in the Go file for f: those defined in f itself and
+// those imported from files that aren't otherwise
+// being generated.
+func (imports *protocolImports) schemas(f string) ([][]byte, error) {
+	proto, err := avdl.ReadFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read protocol: %v", err)
+	}
+	fabs := absPath(f)
+	var schemas [][]byte
+	for _, t := range proto.Types {
+		tabs := fabs
+		if t.File != "" {
+			tabs = absPath(t.File)
+		}
+		if tabs != fabs {
+			if imports.args[tabs] {
+				// The type will be generated from its own file.
+				continue
+			}
+			if owner, ok := imports.owner[tabs]; ok && owner != fabs {
+				// Another protocol has already imported the file.
+				continue
+			}
+			imports.owner[tabs] = fabs
+		}
+		schema, err := errorAsRecord(t.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid type in %s: %v", f, err)
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
+// errorAsRecord returns the given schema with an error type
+// changed to a record type. Error types are only valid in protocols,
+// but they have the same representation as records.
+func errorAsRecord(schema []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(schema))
+	dec.UseNumber()
+	var x interface{}
+	if err := dec.Decode(&x); err != nil {
+		return nil, err
+	}
+	if m, ok := x.(map[string]interface{}); ok && m["type"] == "error" {
+		m["type"] = "record"
+		return json.Marshal(m)
+	}
+	return schema, nil
+}
+
+func absPath(f string) string {
+	if abs, err := filepath.Abs(f); err == nil {
+		return abs
+	}
+	return f
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/actgardner/gogen-avro/v10/schema"
+
+	"github.com/heetch/avro"
+)
+
+// schemaVars holds the record types generated so far by a
+// single avrogo invocation, so that a RegisterSchemas function
+// covering all of them can be generated at the end.
+type schemaVars struct {
+	records []schemaVarsRecord
+}
+
+// schemaVarsRecord describes a record type that
+// has an associated schema variable.
+type schemaVarsRecord struct {
+	// AvroName holds the fully qualified Avro name of the record.
+	AvroName string
+	// GoName holds the name of the generated Go type.
+	GoName string
+}
+
+// SchemaVars returns the declarations of the schema variable and
+// fingerprint constant for the record type t, or the empty string
+// if they aren't being generated.
+func (gc *generateContext) SchemaVars(t *schema.RecordDefinition) (string, error) {
+	if gc.schemas == nil {
+		return "", nil
+	}
+	schemaStr, err := t.Schema()
+	if err != nil {
+		return "", err
+	}
+	at, err := avro.ParseType(schemaStr)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse generated schema for %s: %v", t.AvroName(), err)
+	}
+	name := defName(t)
+	gc.schemas.records = append(gc.schemas.records, schemaVarsRecord{
+		AvroName: t.AvroName().String(),
+		GoName:   name,
+	})
+	gc.addImport("github.com/heetch/avro")
+	w := new(strings.Builder)
+	fprintf(w, "\n\n// %sAvroType holds the Avro schema of %s.\n", name, name)
+	fprintf(w, "var %sAvroType = avro.MustParseType(%s{}.AvroRecord().Schema)\n", name, name)
+	fprintf(w, "\n// %sAvroFingerprint holds the fingerprint of %sAvroType\n", name, name)
+	fprintf(w, "// as returned by avro.Type.Fingerprint.\n")
+	fprintf(w, "const %sAvroFingerprint uint64 = %#016x\n", name, at.Fingerprint())
+	return w.String(), nil
+}
+
+// registerSchemasFile returns the contents of a Go file holding
+// a RegisterSchemas function that registers all the schemas in sv,
+// or nil if there are none.
+func (sv *schemaVars) registerSchemasFile(pkg string) ([]byte, error) {
+	if len(sv.records) == 0 {
+		return nil, nil
+	}
+	records := append([]schemaVarsRecord(nil), sv.records...)
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].AvroName < records[j].AvroName
+	})
+	var buf bytes.Buffer
+	if err := registerSchemasTemplate.Execute(&buf, registerSchemasTemplateParams{
+		Pkg:     pkg,
+		Records: records,
+	}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type registerSchemasTemplateParams struct {
+	Pkg     string
+	Records []schemaVarsRecord
+}
+
+var registerSchemasTemplate = newTemplate(`
+// Code generated by avrogen. DO NOT EDIT.
+
+package «.Pkg»
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroregistry"
+)
+
+// RegisterSchemas registers the schemas of all the record types
+// generated in this package with the registry r. The subject for
+// each schema is determined by calling subject with the fully
+// qualified Avro name of the record.
+func RegisterSchemas(ctx context.Context, r *avroregistry.Registry, subject avroregistry.SubjectStrategy) error {
+	for _, s := range []struct {
+		name string
+		t    *avro.Type
+	}{
+	«- range .Records»
+		{«printf "%q" .AvroName», «.GoName»AvroType},
+	«- end»
+	} {
+		if _, err := r.Register(ctx, subject(s.name), s.t); err != nil {
+			return fmt.Errorf("cannot register schema for %s: %v", s.name, err)
+		}
+	}
+	return nil
+}
+`[1:])
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/actgardner/gogen-avro/v10/schema"
+)
+
+// sealedUnions holds the state of sealed union generation across
+// all the files generated by a single avrogo invocation. Each union
+// interface and primitive wrapper type is generated only once, in
+// the first file that uses it.
+type sealedUnions struct {
+	// generated holds the names of all the union interface
+	// and wrapper types that have been generated so far.
+	generated map[string]bool
+}
+
+func newSealedUnions() *sealedUnions {
+	return &sealedUnions{
+		generated: make(map[string]bool),
+	}
+}
+
+// sealedUnion describes a Go interface type that's generated
+// to represent an Avro union. Each member of the union implements
+// the interface by means of an unexported marker method.
+type sealedUnion struct {
+	// Name holds the Go name of the interface type.
+	Name string
+	// Members holds the Go types of the non-null members
+	// of the union.
+	Members []string
+}
+
+// Marker returns the name of the marker method of the union.
+func (u sealedUnion) Marker() string {
+	return "is" + u.Name
+}
+
+// MemberList returns the member types in a form
+// suitable for a doc comment, for example "A, B and C".
+func (u sealedUnion) MemberList() string {
+	n := len(u.Members)
+	return strings.Join(u.Members[:n-1], ", ") + " and " + u.Members[n-1]
+}
+
+// primitiveWrapper describes a Go type that's generated to
+// represent a primitive Avro type as a member of a sealed union
+// (methods can't be defined on the predeclared Go types).
+type primitiveWrapper struct {
+	// Name holds the Go name of the type.
+	Name string
+	// AvroType holds the name of the Avro type.
+	AvroType string
+	// GoType holds the underlying Go type.
+	GoType string
+}
+
+// sealedUnionMember returns the name of the Go type used for t
+// as a member of a sealed union, and any primitive wrapper type
+// that must be generated for it. It reports false if t can't be
+// used as a member of a sealed union because it's not possible to
+// define methods on the Go type that represents it.
+func (gc *generateContext) sealedUnionMember(t schema.AvroType) (string, *primitiveWrapper, bool) {
+	if _, ok := gc.typeMap.mappedType(t); ok {
+		return "", nil, false
+	}
+	switch t := t.(type) {
+	case *schema.Reference:
+		if _, ok := gc.extTypes[t.TypeName]; ok {
+			return "", nil, false
+		}
+		gt := goTypeForDefinition(t.Def)
+		if gt.PkgPath != "" {
+			return "", nil, false
+		}
+		return gt.Name, nil, true
+	case *schema.BoolField:
+		return gc.primitiveWrapper(t, "Boolean", "bool")
+	case *schema.IntField:
+		return gc.primitiveWrapper(t, "Int", "int")
+	case *schema.LongField:
+		return gc.primitiveWrapper(t, "Long", "int64")
+	case *schema.FloatField:
+		return gc.primitiveWrapper(t, "Float", "float32")
+	case *schema.DoubleField:
+		return gc.primitiveWrapper(t, "Double", "float64")
+	case *schema.BytesField:
+		return gc.primitiveWrapper(t, "Bytes", "[]byte")
+	case *schema.StringField:
+		return gc.primitiveWrapper(t, "String", "string")
+	}
+	return "", nil, false
+}
+
+// primitiveWrapper returns the wrapper type used for the primitive
+// type t as a member of a sealed union.
+func (gc *generateContext) primitiveWrapper(t schema.AvroType, avroName, goType string) (string, *primitiveWrapper, bool) {
+	if logicalType(t) != "" {
+		// Logical types are represented by types
+		// from other packages.
+		return "", nil, false
+	}
+	w := &primitiveWrapper{
+		Name:     avroName + "Value",
+		AvroType: strings.ToLower(avroName),
+		GoType:   goType,
+	}
+	return w.Name, w, true
+}
+
+// sealedUnionType returns type information for the union t
+// represented as a sealed interface type. It reports false if t
+// can't be represented that way, in which case interface{} should
+// be used instead.
+func (gc *generateContext) sealedUnionType(t *schema.UnionField) (typeInfo, bool) {
+	types := t.AvroTypes()
+	info := typeInfo{
+		Union:  make([]typeInfo, len(types)),
+		Sealed: true,
+	}
+	var members, nameParts []string
+	var wrappers []*primitiveWrapper
+	for i, mt := range types {
+		if isNullField(mt) {
+			info.Union[i] = typeInfo{
+				GoType: nullType,
+			}
+			continue
+		}
+		name, w, ok := gc.sealedUnionMember(mt)
+		if !ok {
+			return typeInfo{}, false
+		}
+		members = append(members, name)
+		if w != nil {
+			wrappers = append(wrappers, w)
+			nameParts = append(nameParts, strings.TrimSuffix(name, "Value"))
+		} else {
+			nameParts = append(nameParts, name)
+		}
+		info.Union[i] = typeInfo{
+			GoType: name,
+		}
+	}
+	if len(members) < 2 {
+		// There's no point in an interface type
+		// with only one implementation.
+		return typeInfo{}, false
+	}
+	// Sort the members so that unions with the same
+	// members in a different order share the same type.
+	sort.Strings(members)
+	sort.Strings(nameParts)
+	info.GoType = strings.Join(nameParts, "Or")
+	su := gc.sealed
+	if !su.generated[info.GoType] {
+		su.generated[info.GoType] = true
+		gc.sealedUnions = append(gc.sealedUnions, sealedUnion{
+			Name:    info.GoType,
+			Members: members,
+		})
+	}
+	for _, w := range wrappers {
+		if !su.generated[w.Name] {
+			su.generated[w.Name] = true
+			gc.primitiveWrappers = append(gc.primitiveWrappers, *w)
+		}
+	}
+	return info, true
+}
+
+// SealedUnions returns the union interface types that
+// should be generated in the current file.
+func (gc *generateContext) SealedUnions() []sealedUnion {
+	return gc.sealedUnions
+}
+
+// PrimitiveWrappers returns the primitive wrapper types
+// that should be generated in the current file.
+func (gc *generateContext) PrimitiveWrappers() []primitiveWrapper {
+	return gc.primitiveWrappers
+}
@@ -55,6 +55,7 @@ import (
 
 type bodyTemplateParams struct {
 	Definitions []schema.QualifiedName
+	Wrapper     *wrapperType
 	NS          *parser.Namespace
 	Ctx         *generateContext
 }
@@ -68,7 +69,7 @@ var bodyTemplate = newTemplate(`
 		type «defName .» struct {
 		«- range $i, $_ := .Fields»
 			«- doc "\t// " .»
-			«- $type := $.Ctx.GoTypeOf .Type»
+			«- $type := $.Ctx.FieldTypeOf $def .»
 			«- doc "\t// " $type»
 			«- if isExportedGoIdentifier .Name»
 				«- .Name» «$type.GoType»
@@ -82,6 +83,10 @@ var bodyTemplate = newTemplate(`
 		func («defName .») AvroRecord() avrotypegen.RecordInfo {
 			return «$.Ctx.RecordInfoLiteral .»
 		}
+		«- $.Ctx.SchemaVars .»
+		«- $.Ctx.ValidateMethod .»
+		«- $.Ctx.CloneMethod .»
+		«- $.Ctx.EqualMethod .»
 	«else if eq (typeof .) "EnumDefinition"»
 		«- import $.Ctx "strconv"»
 		«- import $.Ctx "fmt"»
@@ -127,14 +132,58 @@ var bodyTemplate = newTemplate(`
 			}
 			return fmt.Errorf("unknown value %q for «defName .»", data)
 		}
+		«- if .Default»
+
+		// AvroEnum implements the avrotypegen.AvroEnum interface.
+		func («defName .») AvroEnum() avrotypegen.EnumInfo {
+			return avrotypegen.EnumInfo{
+				Default: «printf "%q" .Default»,
+			}
+		}
+		«- end»
+		«- $.Ctx.CloneMethod .»
+		«- $.Ctx.EqualMethod .»
 	«else if eq (typeof .) "FixedDefinition"»
 		«- doc "// " . -»
 		type «defName .» [«.SizeBytes»]byte
+		«- $.Ctx.CloneMethod .»
+		«- $.Ctx.EqualMethod .»
 	«else»
 		// unknown definition type «printf "%T; name %q" . (typeof .)» .
 	«end»
 	«end»
 «end»
+«- with .Wrapper»
+	// «.Name» holds a value of the top level type of «.File».
+	type «.Name» struct {
+		«- $type := $.Ctx.GoTypeOf .Type»
+		«- doc "\t// " $type»
+		«- "Value"» «$type.GoType»
+	}
+
+	// AvroWrapper implements the avrotypegen.AvroWrapper interface.
+	func («.Name») AvroWrapper() avrotypegen.WrapperInfo {
+		return «$.Ctx.WrapperInfoLiteral .»
+	}
+«end»
+«- range $.Ctx.SealedUnions»
+	«- $u := .»
+
+	// «.Name» represents an Avro union. It's implemented by «.MemberList».
+	// A nil value represents null when the union allows it.
+	type «.Name» interface {
+		«.Marker»()
+	}
+	«range .Members»
+	func («.») «$u.Marker»() {}
+	«- end»
+«end»
+«- range $.Ctx.PrimitiveWrappers»
+
+	// «.Name» represents the Avro «.AvroType» type as
+	// a member of a union.
+	type «.Name» «.GoType»
+«end»
 `[1:])
 
 func defName(def schema.Definition) string {
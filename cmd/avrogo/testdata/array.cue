@@ -24,3 +24,14 @@ tests: simpleArray: subtests: empty: {
 	inData: A: []
 	outData: inData
 }
+
+tests: topLevelArrayOfUnion: {
+	inSchema: {
+		type: "array"
+		items: ["null", "int", "string"]
+	}
+	outSchema: inSchema
+	goType:    "Schema"
+	inData: [null, {int: 1}, {string: "hello"}]
+	outData: inData
+}
@@ -93,3 +93,57 @@ tests: simpleEnum: otherTests: """
 		}`))
 	}
 	"""
+
+tests: enumSymbolDefault: {
+	inSchema: {
+		type: "record"
+		name: "R"
+		fields: [{
+			name: "E"
+			type: {
+				type: "enum"
+				name: "MyEnum"
+				symbols: ["a", "b", "c", "d"]
+			}
+		}]
+	}
+	outSchema: {
+		type: "record"
+		name: "R"
+		fields: [{
+			name: "E"
+			type: {
+				type: "enum"
+				name: "MyEnum"
+				symbols: ["unknown", "a", "b", "c"]
+				default: "unknown"
+			}
+		}]
+	}
+	inData: E: "d"
+	outData: E: "unknown"
+}
+
+tests: enumSymbolDefault: otherTests: """
+	package enumSymbolDefault
+	import (
+		"encoding/json"
+		"testing"
+
+		qt "github.com/frankban/quicktest"
+
+		"github.com/heetch/avro"
+	)
+
+	func TestSchema(t *testing.T) {
+		c := qt.New(t)
+		at, err := avro.TypeOf(MyEnumUnknown)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+			"type": "enum",
+			"name": "MyEnum",
+			"symbols": ["unknown", "a", "b", "c"],
+			"default": "unknown"
+		}`))
+	}
+	"""
@@ -49,7 +49,7 @@ tests: invalidUUID: {
        outSchema: inSchema
        inData: T: "invalid_uuid"
        outData: null
-       expectError: unmarshal: "invalid UUID in Avro encoding: invalid UUID length: 12"
+       expectError: unmarshal: "R.T \\(offset 13\\): invalid UUID in Avro encoding: invalid UUID length: 12"
 }
 
 tests: durationNanos: {
@@ -27,3 +27,17 @@ tests: simpleMap: subtests: empty: {
 	inData: M: {}
 	outData: inData
 }
+
+tests: topLevelMap: {
+	inSchema: {
+		type:   "map"
+		values: "int"
+	}
+	outSchema: inSchema
+	goType:    "Schema"
+	inData: {
+		a: 32
+		b: 54
+	}
+	outData: inData
+}
@@ -194,3 +194,346 @@ tests: underscoreField: {
 	}
 	outData: inData
 }
+
+tests: validate: {
+	avrogoFlags: ["-validate"]
+	inSchema: {
+		name: "R"
+		type: "record"
+		fields: [{
+			name: "I"
+			type: "int"
+		}, {
+			name: "E"
+			type: {
+				type: "enum"
+				name: "Colour"
+				symbols: ["red", "green"]
+			}
+		}, {
+			name: "P"
+			type: ["null", "int"]
+		}, {
+			name: "U"
+			type: ["null", "int", "string"]
+		}, {
+			name: "V"
+			type: ["int", "Colour"]
+		}, {
+			name: "A"
+			type: {
+				type: "array"
+				items: {
+					type: "record"
+					name: "Item"
+					fields: [{
+						name: "n"
+						type: "int"
+					}]
+				}
+			}
+		}, {
+			name: "M"
+			type: {
+				type:   "map"
+				values: "Colour"
+			}
+		}, {
+			name: "Next"
+			type: ["null", "R"]
+		}]
+	}
+	outSchema: inSchema
+	inData: {
+		I: 1
+		E: "green"
+		P: int: 2
+		U: null
+		V: Colour: "red"
+		A: [{n: 3}]
+		M: a: "green"
+		Next: R: {
+			I: 4
+			E: "red"
+			P: null
+			U: string: "x"
+			V: int: 5
+			A: []
+			M: {}
+			Next: null
+		}
+	}
+	outData: inData
+	otherTests: """
+		package validate
+
+		import (
+			"math"
+			"testing"
+
+			qt "github.com/frankban/quicktest"
+
+			"github.com/heetch/avro/avrotypegen"
+		)
+
+		func TestValidate(t *testing.T) {
+			c := qt.New(t)
+			r := R{
+				I: 1,
+				V: 2,
+				A: []Item{{N: 3}},
+				M: map[string]Colour{"a": ColourGreen},
+			}
+			c.Assert(r.Validate(), qt.IsNil)
+
+			p := math.MaxInt32 + 1
+			r = R{
+				I: math.MinInt32 - 1,
+				E: Colour(2),
+				P: &p,
+				U: 1.5,
+				A: []Item{{N: 1}, {N: math.MaxInt32 + 1}},
+				M: map[string]Colour{"a": -1},
+				Next: &R{
+					V: "x",
+				},
+			}
+			err := r.Validate()
+			c.Assert(err, qt.ErrorMatches, `8 validation errors: .*`)
+			var paths []string
+			for _, err := range err.(avrotypegen.ValidationErrors) {
+				paths = append(paths, err.Error())
+			}
+			c.Assert(paths, qt.DeepEquals, []string{
+				"I: value out of range for int",
+				"E: Colour value 2 is out of bounds",
+				"P: value out of range for int",
+				"U: value of type float64 not allowed in union",
+				"V: nil value not allowed in union",
+				`A[1].n: value out of range for int`,
+				`M["a"]: Colour value -1 is out of bounds`,
+				"Next.V: value of type string not allowed in union",
+			})
+		}
+		"""
+}
+
+tests: cloneEqual: {
+	avrogoFlags: ["-clone", "-equal"]
+	inSchema: {
+		name: "R"
+		type: "record"
+		fields: [{
+			name: "B"
+			type: "bytes"
+		}, {
+			name: "T"
+			type: {
+				type:        "long"
+				logicalType: "timestamp-micros"
+			}
+		}, {
+			name: "E"
+			type: {
+				type: "enum"
+				name: "Colour"
+				symbols: ["red", "green"]
+			}
+		}, {
+			name: "P"
+			type: ["null", {
+				type:  "array"
+				items: "string"
+			}]
+		}, {
+			name: "U"
+			type: ["null", "bytes", "string", "Colour"]
+		}, {
+			name: "A"
+			type: {
+				type: "array"
+				items: {
+					type: "record"
+					name: "Item"
+					fields: [{
+						name: "tags"
+						type: {
+							type:   "map"
+							values: "string"
+						}
+					}]
+				}
+			}
+		}, {
+			name: "M"
+			type: {
+				type: "map"
+				values: {
+					type:  "array"
+					items: "bytes"
+				}
+			}
+		}, {
+			name: "Next"
+			type: ["null", "R"]
+		}]
+	}
+	outSchema: inSchema
+	inData: {
+		B: "b"
+		T: 1579176162000001
+		E: "green"
+		P: array: ["p"]
+		U: bytes: "u"
+		A: [{tags: a: "x"}]
+		M: m: ["y"]
+		Next: R: {
+			B: ""
+			T: 0
+			E: "red"
+			P: null
+			U: null
+			A: []
+			M: {}
+			Next: null
+		}
+	}
+	outData: inData
+	otherTests: """
+		package cloneEqual
+
+		import (
+			"testing"
+			"time"
+
+			qt "github.com/frankban/quicktest"
+		)
+
+		func newR() R {
+			return R{
+				B: []byte("b"),
+				T: time.Date(2020, 1, 16, 12, 2, 42, 0, time.UTC),
+				E: ColourGreen,
+				P: &[]string{"p"},
+				U: []byte("u"),
+				A: []Item{{
+					Tags: map[string]string{"a": "x"},
+				}},
+				M: map[string][][]byte{
+					"m": {[]byte("y")},
+				},
+				Next: &R{
+					U: "s",
+				},
+			}
+		}
+
+		func TestClone(t *testing.T) {
+			c := qt.New(t)
+			r := newR()
+			r1 := r.Clone()
+			c.Assert(r1, qt.DeepEquals, r)
+
+			// Changing the clone doesn't change the original.
+			r1.B[0] = 'x'
+			(*r1.P)[0] = "x"
+			r1.U.([]byte)[0] = 'x'
+			r1.A[0].Tags["a"] = "y"
+			r1.M["m"][0][0] = 'x'
+			r1.Next.U = "t"
+			c.Assert(r, qt.DeepEquals, newR())
+		}
+
+		func TestEqual(t *testing.T) {
+			c := qt.New(t)
+			c.Assert(newR().Equal(newR()), qt.IsTrue)
+			c.Assert(R{}.Equal(R{}), qt.IsTrue)
+
+			// Times in different locations are equal when
+			// they represent the same instant.
+			r := newR()
+			r.T = r.T.In(time.FixedZone("x", 3600))
+			c.Assert(r.Equal(newR()), qt.IsTrue)
+
+			// Nil and empty slices and maps are equal.
+			c.Assert(R{A: []Item{}, M: map[string][][]byte{}}.Equal(R{}), qt.IsTrue)
+
+			for i, f := range []func(r *R){
+				func(r *R) { r.B[0] = 'x' },
+				func(r *R) { r.T = r.T.Add(1) },
+				func(r *R) { r.E = ColourRed },
+				func(r *R) { r.P = nil },
+				func(r *R) { r.U = "u" },
+				func(r *R) { r.U = nil },
+				func(r *R) { r.A[0].Tags["b"] = "y" },
+				func(r *R) { r.M["m"][0] = nil },
+				func(r *R) { r.Next.U = "t" },
+			} {
+				r := newR()
+				f(&r)
+				c.Check(r.Equal(newR()), qt.IsFalse, qt.Commentf("change %d", i))
+				c.Check(newR().Equal(r), qt.IsFalse, qt.Commentf("change %d", i))
+			}
+		}
+		"""
+}
+
+tests: schemaVars: {
+	avrogoFlags: ["-schemas"]
+	inSchema: {
+		name:      "R"
+		namespace: "com.example"
+		type:      "record"
+		fields: [{
+			name: "A"
+			type: "int"
+		}, {
+			name: "B"
+			type: {
+				name: "Inner"
+				type: "record"
+				fields: [{
+					name: "S"
+					type: "string"
+				}]
+			}
+		}]
+	}
+	outSchema: inSchema
+	inData: {
+		A: 1
+		B: S: "x"
+	}
+	outData: inData
+	otherTests: """
+		package schemaVars
+
+		import (
+			"testing"
+
+			qt "github.com/frankban/quicktest"
+
+			"github.com/heetch/avro"
+		)
+
+		func TestSchemaVars(t *testing.T) {
+			c := qt.New(t)
+			c.Assert(RAvroType.Name(), qt.Equals, "com.example.R")
+			c.Assert(InnerAvroType.Name(), qt.Equals, "com.example.Inner")
+			c.Assert(RAvroType.Fingerprint(), qt.Equals, RAvroFingerprint)
+			c.Assert(InnerAvroType.Fingerprint(), qt.Equals, InnerAvroFingerprint)
+
+			// The schema is the same one that TypeOf returns.
+			rt, err := avro.TypeOf(R{})
+			c.Assert(err, qt.IsNil)
+			c.Assert(rt.Fingerprint(), qt.Equals, RAvroFingerprint)
+		}
+
+		func TestRegisterSchemasSignature(t *testing.T) {
+			// RegisterSchemas needs a registry server, so
+			// just check that it's been generated.
+			c := qt.New(t)
+			c.Assert(RegisterSchemas, qt.Not(qt.IsNil))
+		}
+		"""
+}
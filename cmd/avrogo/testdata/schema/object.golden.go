@@ -130,6 +130,13 @@ type Child1 int
 			}
 			return fmt.Errorf("unknown value %q for Child1", data)
 		}
+
+		// AvroEnum implements the avrotypegen.AvroEnum interface.
+		func (Child1) AvroEnum() avrotypegen.EnumInfo {
+			return avrotypegen.EnumInfo{
+				Default: "UNSPECIFIED",
+			}
+		}
 	
 	
 
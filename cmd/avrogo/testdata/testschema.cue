@@ -27,6 +27,8 @@ roundTripTest :: {
 	extraSchemas?: [... avro.Schema]
 	goType:      *outSchema.name | string
 	goTypeBody?: string
+	// avrogoFlags holds extra flags to pass to avrogo.
+	avrogoFlags?: [...string]
 	// generateError holds the error expected from invoking avrogo.
 	// If this is specified, there will be no generated test package.
 	generateError?: string
@@ -242,3 +242,239 @@ tests: nestedUnionNestedArray: {
 	inData: F: [[null, {string: "hello"}], [{string: "goodbye"}]]
 	outData: inData
 }
+
+tests: topLevelUnion: {
+	inSchema: [{
+		type: "record"
+		name: "A"
+		fields: [{
+			name: "X"
+			type: "int"
+		}]
+	}, {
+		type: "record"
+		name: "B"
+		fields: [{
+			name: "Y"
+			type: "string"
+		}]
+	}]
+	outSchema: inSchema
+	goType:    "Schema"
+}
+
+tests: topLevelUnion: subtests: a: {
+	inData: A: X: 99
+	outData: inData
+}
+
+tests: topLevelUnion: subtests: b: {
+	inData: B: Y: "hello"
+	outData: inData
+}
+
+tests: sealedUnion: {
+	avrogoFlags: ["-sealed"]
+	inSchema: {
+		type: "record"
+		name: "R"
+		fields: [{
+			name: "U"
+			type: [
+				"null",
+				{
+					type: "record"
+					name: "A"
+					fields: [{
+						name: "X"
+						type: "int"
+					}]
+				},
+				"string",
+			]
+		}, {
+			name: "V"
+			type: ["string", "A"]
+		}, {
+			name: "L"
+			type: {
+				type: "array"
+				items: ["long", "string"]
+			}
+		}]
+	}
+	outSchema: inSchema
+}
+
+tests: sealedUnion: subtests: main: {
+	inData: {
+		U: A: X: 1
+		V: string: "hello"
+		L: [{long: 99}, {string: "x"}]
+	}
+	outData: inData
+}
+
+tests: sealedUnion: subtests: null: {
+	inData: {
+		U: null
+		V: A: X: 2
+		L: []
+	}
+	outData: inData
+}
+
+tests: sealedUnion: otherTests: """
+	package sealedUnion
+
+	import (
+		"testing"
+
+		qt "github.com/frankban/quicktest"
+
+		"github.com/heetch/avro"
+	)
+
+	func TestSealedUnionTypes(t *testing.T) {
+		c := qt.New(t)
+		// U and V share the same interface type.
+		r := R{
+			U: StringValue("a"),
+			V: &A{X: 3},
+			L: []LongOrString{LongValue(1), StringValue("b")},
+		}
+		data, wType, err := avro.Marshal(r)
+		c.Assert(err, qt.IsNil)
+		var r1 R
+		_, err = avro.Unmarshal(data, &r1, wType)
+		c.Assert(err, qt.IsNil)
+		c.Assert(r1, qt.DeepEquals, R{
+			U: StringValue("a"),
+			V: A{X: 3},
+			L: []LongOrString{LongValue(1), StringValue("b")},
+		})
+	}
+	"""
+
+tests: sealedUnionClone: {
+	avrogoFlags: ["-sealed", "-clone"]
+	inSchema: {
+		type: "record"
+		name: "R"
+		fields: [{
+			name: "U"
+			type: ["null", "bytes", "string"]
+		}]
+	}
+	outSchema: inSchema
+}
+
+tests: sealedUnionClone: subtests: bytes: {
+	inData: U: bytes: "hello"
+	outData: inData
+}
+
+tests: sealedUnionClone: subtests: string: {
+	inData: U: string: "hello"
+	outData: inData
+}
+
+tests: sealedUnionClone: otherTests: """
+	package sealedUnionClone
+
+	import (
+		"testing"
+
+		qt "github.com/frankban/quicktest"
+	)
+
+	func TestClone(t *testing.T) {
+		c := qt.New(t)
+		r := R{U: BytesValue("b")}
+		r1 := r.Clone()
+		c.Assert(r1, qt.DeepEquals, r)
+
+		// Changing the clone doesn't change the original.
+		r1.U.(BytesValue)[0] = 'x'
+		c.Assert(r, qt.DeepEquals, R{U: BytesValue("b")})
+
+		r = R{U: StringValue("s")}
+		c.Assert(r.Clone(), qt.DeepEquals, r)
+	}
+	"""
+
+tests: sealedUnionValidate: {
+	avrogoFlags: ["-sealed", "-validate"]
+	inSchema: {
+		type: "record"
+		name: "R"
+		fields: [{
+			name: "U"
+			type: [
+				"null",
+				{
+					type: "record"
+					name: "A"
+					fields: [{
+						name: "X"
+						type: "int"
+					}]
+				},
+				"string",
+			]
+		}, {
+			name: "V"
+			type: ["A", "string"]
+		}]
+	}
+	outSchema: inSchema
+}
+
+tests: sealedUnionValidate: subtests: main: {
+	inData: {
+		U: null
+		V: A: X: 1
+	}
+	outData: inData
+}
+
+tests: sealedUnionValidate: otherTests: """
+	package sealedUnionValidate
+
+	import (
+		"testing"
+
+		qt "github.com/frankban/quicktest"
+
+		"github.com/heetch/avro"
+		"github.com/heetch/avro/avrotypegen"
+	)
+
+	func TestValidateNilPointer(t *testing.T) {
+		c := qt.New(t)
+		r := R{
+			U: &A{X: 1},
+			V: StringValue("a"),
+		}
+		c.Assert(r.Validate(), qt.IsNil)
+
+		// A nil pointer to a member type can't be encoded,
+		// even when the union allows null.
+		r = R{
+			U: (*A)(nil),
+			V: (*A)(nil),
+		}
+		_, _, err := avro.Marshal(r)
+		c.Assert(err, qt.Not(qt.IsNil))
+		err = r.Validate()
+		c.Assert(err, qt.Not(qt.IsNil))
+		var paths []string
+		for _, err := range err.(avrotypegen.ValidationErrors) {
+			paths = append(paths, err.Error())
+		}
+		c.Assert(paths, qt.DeepEquals, []string{
+			"U: nil value not allowed in union",
+			"V: nil value not allowed in union",
+		})
+	}
+	"""
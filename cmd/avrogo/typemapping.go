@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/actgardner/gogen-avro/v10/parser"
+	"github.com/actgardner/gogen-avro/v10/schema"
+)
+
+// typeMapping holds the Go types to use for Avro types,
+// as read from the file specified by the -map flag.
+type typeMapping struct {
+	// logicalTypes maps from logical type name to Go type.
+	logicalTypes map[string]goType
+
+	// types maps from primitive type name or fully
+	// qualified definition name to Go type.
+	types map[string]goType
+
+	// fields maps from fully qualified record name and
+	// field name, joined with a dot, to Go type.
+	fields map[string]goType
+}
+
+// typeMappingFile defines the format of a type mapping file.
+type typeMappingFile struct {
+	LogicalTypes map[string]string `json:"logicalTypes" yaml:"logicalTypes"`
+	Types        map[string]string `json:"types" yaml:"types"`
+	Fields       map[string]string `json:"fields" yaml:"fields"`
+}
+
+// readTypeMapping reads a type mapping from the given file,
+// which may be in JSON or YAML format depending on
+// its extension.
+func readTypeMapping(file string) (*typeMapping, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var f typeMappingFile
+	switch filepath.Ext(file) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&f)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&f)
+		if err == io.EOF {
+			// An empty file holds no mappings.
+			err = nil
+		}
+	default:
+		return nil, fmt.Errorf("cannot determine format of type mapping file %s (need .json or .yaml extension)", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid type mapping file: %v", err)
+	}
+	tm := &typeMapping{}
+	if tm.logicalTypes, err = parseGoTypes(f.LogicalTypes); err != nil {
+		return nil, fmt.Errorf("invalid type mapping file: %v", err)
+	}
+	if tm.types, err = parseGoTypes(f.Types); err != nil {
+		return nil, fmt.Errorf("invalid type mapping file: %v", err)
+	}
+	if tm.fields, err = parseGoTypes(f.Fields); err != nil {
+		return nil, fmt.Errorf("invalid type mapping file: %v", err)
+	}
+	for name, gt := range tm.types {
+		if !isPrimitiveName(name) && gt.PkgPath == "" {
+			return nil, fmt.Errorf("invalid type mapping file: Avro definition %s cannot be represented by predeclared type %s", name, gt.Name)
+		}
+	}
+	return tm, nil
+}
+
+func parseGoTypes(m map[string]string) (map[string]goType, error) {
+	gts := make(map[string]goType)
+	for key, s := range m {
+		gt, err := parseGoType(s)
+		if err != nil {
+			return nil, fmt.Errorf("bad Go type for %s: %v", key, err)
+		}
+		gts[key] = gt
+	}
+	return gts, nil
+}
+
+// parseGoType parses a Go type name in the form "import/path.Name",
+// or just "Name" for a predeclared type such as int32.
+func parseGoType(s string) (goType, error) {
+	i := strings.LastIndex(s, ".")
+	if i == -1 {
+		if predeclaredTypes[s] == nil {
+			return goType{}, fmt.Errorf("%q is not a predeclared Go type", s)
+		}
+		return goType{Name: s}, nil
+	}
+	pkg, name := s[:i], s[i+1:]
+	if pkg == "" || strings.HasSuffix(pkg, "/") || !isExportedGoIdentifier(name) {
+		return goType{}, fmt.Errorf("invalid Go type %q", s)
+	}
+	return goType{
+		PkgPath: pkg,
+		Name:    name,
+	}, nil
+}
+
+// predeclaredTypes holds the predeclared Go types
+// that can be used in a type mapping.
+var predeclaredTypes = map[string]reflect.Type{
+	"bool":    reflect.TypeOf(false),
+	"int":     reflect.TypeOf(int(0)),
+	"int8":    reflect.TypeOf(int8(0)),
+	"int16":   reflect.TypeOf(int16(0)),
+	"int32":   reflect.TypeOf(int32(0)),
+	"int64":   reflect.TypeOf(int64(0)),
+	"uint":    reflect.TypeOf(uint(0)),
+	"uint8":   reflect.TypeOf(uint8(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"float32": reflect.TypeOf(float32(0)),
+	"float64": reflect.TypeOf(float64(0)),
+	"string":  reflect.TypeOf(""),
+}
+
+// primitiveName returns the name of the primitive Avro type t,
+// or the empty string if t isn't primitive. The null type
+// isn't included because it can't be mapped.
+func primitiveName(t schema.AvroType) string {
+	switch t.(type) {
+	case *schema.BoolField:
+		return "boolean"
+	case *schema.IntField:
+		return "int"
+	case *schema.LongField:
+		return "long"
+	case *schema.FloatField:
+		return "float"
+	case *schema.DoubleField:
+		return "double"
+	case *schema.BytesField:
+		return "bytes"
+	case *schema.StringField:
+		return "string"
+	}
+	return ""
+}
+
+func isPrimitiveName(name string) bool {
+	switch name {
+	case "boolean", "int", "long", "float", "double", "bytes", "string":
+		return true
+	}
+	return false
+}
+
+// mappedType returns the Go type that the type mapping
+// specifies for the Avro type t, reporting whether there is one.
+// Definitions aren't included: they're treated as external types.
+func (tm *typeMapping) mappedType(t schema.AvroType) (goType, bool) {
+	if tm == nil {
+		return goType{}, false
+	}
+	if lt := logicalType(t); lt != "" {
+		if gt, ok := tm.logicalTypes[lt]; ok {
+			return gt, true
+		}
+	}
+	if name := primitiveName(t); name != "" {
+		gt, ok := tm.types[name]
+		return gt, ok
+	}
+	return goType{}, false
+}
+
+// mappedFieldType returns the Go type that the type mapping
+// specifies for field f of record r, reporting whether there is one.
+func (tm *typeMapping) mappedFieldType(r *schema.RecordDefinition, f *schema.Field) (goType, bool) {
+	if tm == nil {
+		return goType{}, false
+	}
+	gt, ok := tm.fields[r.AvroName().String()+"."+f.Name()]
+	return gt, ok
+}
+
+// definitionGoType returns the Go type used for the definition def.
+// It's different from goTypeForDefinition because it takes
+// the type mapping into account.
+func (tm *typeMapping) definitionGoType(def schema.Definition) goType {
+	if tm != nil {
+		if gt, ok := tm.types[def.AvroName().String()]; ok {
+			return gt
+		}
+	}
+	return goTypeForDefinition(def)
+}
+
+// mappedUse records a place in the schema where
+// the type mapping specifies a Go type.
+type mappedUse struct {
+	path string
+	t    schema.AvroType
+	gt   goType
+}
+
+// uses returns all the places in the records generated for ns
+// that the type mapping applies to, except for definitions.
+func (tm *typeMapping) uses(ns *parser.Namespace) []mappedUse {
+	if tm == nil {
+		return nil
+	}
+	var names []string
+	defs := make(map[string]*schema.RecordDefinition)
+	for name, def := range ns.Definitions {
+		if def, ok := def.(*schema.RecordDefinition); ok && tm.definitionGoType(def).PkgPath == "" {
+			names = append(names, name.String())
+			defs[name.String()] = def
+		}
+	}
+	sort.Strings(names)
+	var uses []mappedUse
+	var walk func(path string, t schema.AvroType)
+	walk = func(path string, t schema.AvroType) {
+		if gt, ok := tm.mappedType(t); ok {
+			uses = append(uses, mappedUse{path, t, gt})
+			return
+		}
+		switch t := t.(type) {
+		case *schema.ArrayField:
+			walk(path+".[*]", t.ItemType())
+		case *schema.MapField:
+			walk(path+".{*}", t.ItemType())
+		case *schema.UnionField:
+			for i, mt := range t.AvroTypes() {
+				walk(path+fmt.Sprintf("[u%d]", i), mt)
+			}
+		}
+	}
+	for _, name := range names {
+		def := defs[name]
+		for _, f := range def.Fields() {
+			path := name + "." + f.Name()
+			if gt, ok := tm.mappedFieldType(def, f); ok {
+				uses = append(uses, mappedUse{path, f.Type(), gt})
+				continue
+			}
+			walk(path, f.Type())
+		}
+	}
+	return uses
+}
+
+// mappedTypeName returns gt in the form used
+// in type mapping files, for error messages.
+func mappedTypeName(gt goType) string {
+	if gt.PkgPath == "" {
+		return gt.Name
+	}
+	return gt.PkgPath + "." + gt.Name
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/actgardner/gogen-avro/v10/schema"
+)
+
+// ValidateMethod returns the Validate method for the record type t,
+// or the empty string if Validate methods aren't being generated.
+func (gc *generateContext) ValidateMethod(t *schema.RecordDefinition) string {
+	if !gc.validate {
+		return ""
+	}
+	var body strings.Builder
+	for _, f := range t.Fields() {
+		x, path := "r."+fieldGoName(f), strconv.Quote(f.Name())
+		if gt, ok := gc.typeMap.mappedFieldType(t, f); ok {
+			body.WriteString(validateMappedCode(x, path, gt))
+			continue
+		}
+		body.WriteString(gc.validateCode(x, path, f.Type(), 1))
+	}
+	w := new(strings.Builder)
+	fprintf(w, "\n\n// Validate implements avrotypegen.Validator by checking that\n")
+	fprintf(w, "// the value can be encoded with its Avro schema.\n")
+	if body.Len() == 0 {
+		fprintf(w, "func (%s) Validate() error {\nreturn nil\n}\n", defName(t))
+		return w.String()
+	}
+	fprintf(w, "func (r %s) Validate() error {\n", defName(t))
+	fprintf(w, "var errs avrotypegen.ValidationErrors\n")
+	w.WriteString(body.String())
+	fprintf(w, "return errs.Err()\n}\n")
+	return w.String()
+}
+
+// validateCode returns code that adds an error to errs for each
+// problem found in the value x of Avro type t. The path parameter
+// holds a Go expression for the path to x. The depth parameter is
+// used to make variable names unique within nested loops. It
+// returns the empty string if there's nothing to check.
+func (gc *generateContext) validateCode(x, path string, t schema.AvroType, depth int) string {
+	if gt, ok := gc.typeMap.mappedType(t); ok {
+		return validateMappedCode(x, path, gt)
+	}
+	w := new(strings.Builder)
+	switch t := t.(type) {
+	case *schema.IntField:
+		gc.addImport("math")
+		fprintf(w, "if %s < math.MinInt32 || %s > math.MaxInt32 {\n", x, x)
+		fprintf(w, "errs.Add(%s, \"value out of range for int\")\n", path)
+		fprintf(w, "}\n")
+	case *schema.Reference:
+		if _, ok := gc.extTypes[t.TypeName]; ok {
+			fprintf(w, "errs.AddError(%s, avrotypegen.Validate(%s))\n", path, x)
+			break
+		}
+		switch t.Def.(type) {
+		case *schema.EnumDefinition:
+			if goTypeForDefinition(t.Def).PkgPath != "" {
+				break
+			}
+			fprintf(w, "if _, err := %s.MarshalText(); err != nil {\n", paren(x))
+			fprintf(w, "errs.Add(%s, err.Error())\n", path)
+			fprintf(w, "}\n")
+		case *schema.RecordDefinition:
+			// The record might be defined in another package
+			// generated without Validate methods, so use
+			// dynamic dispatch.
+			fprintf(w, "errs.AddError(%s, avrotypegen.Validate(%s))\n", path, x)
+		}
+	case *schema.ArrayField:
+		i, v := "i"+strconv.Itoa(depth), "x"+strconv.Itoa(depth)
+		inner := gc.validateCode(v, fmt.Sprintf("avrotypegen.IndexPath(%s, %s)", path, i), t.ItemType(), depth+1)
+		if inner != "" {
+			fprintf(w, "for %s, %s := range %s {\n%s}\n", i, v, x, inner)
+		}
+	case *schema.MapField:
+		k, v := "k"+strconv.Itoa(depth), "x"+strconv.Itoa(depth)
+		inner := gc.validateCode(v, fmt.Sprintf("avrotypegen.KeyPath(%s, %s)", path, k), t.ItemType(), depth+1)
+		if inner != "" {
+			fprintf(w, "for %s, %s := range %s {\n%s}\n", k, v, x, inner)
+		}
+	case *schema.UnionField:
+		info := gc.GoTypeOf(t)
+		if info.GoType[0] == '*' {
+			// It's a pointer union; nil represents null.
+			v := "x" + strconv.Itoa(depth)
+			inner := gc.validateCode("*"+v, path, nonNullMember(t), depth+1)
+			if inner != "" {
+				fprintf(w, "if %s := %s; %s != nil {\n%s}\n", v, x, v, inner)
+			}
+			break
+		}
+		w.WriteString(gc.validateUnionCode(x, path, t, info, depth))
+	}
+	return w.String()
+}
+
+// validateMappedCode is like validateCode but for a value
+// of a Go type specified by the type mapping.
+func validateMappedCode(x, path string, gt goType) string {
+	if gt.PkgPath == "" {
+		// Predeclared types always fit the
+		// Avro type they're mapped to.
+		return ""
+	}
+	return fmt.Sprintf("errs.AddError(%s, avrotypegen.Validate(%s))\n", path, x)
+}
+
+// validateUnionCode is like validateCode but for a union type t
+// that's represented by an interface type described by info.
+func (gc *generateContext) validateUnionCode(x, path string, t *schema.UnionField, info typeInfo, depth int) string {
+	v := "x" + strconv.Itoa(depth)
+	var cases strings.Builder
+	nullable := false
+	used := false
+	done := make(map[string]bool)
+	for i, mt := range t.AvroTypes() {
+		if isNullField(mt) {
+			nullable = true
+			continue
+		}
+		goType := info.Union[i].GoType
+		if done[goType] {
+			continue
+		}
+		done[goType] = true
+		inner := gc.validateCode(v, path, mt, depth+1)
+		if inner != "" {
+			used = true
+			fprintf(&cases, "case %s:\n%s", goType, inner)
+		} else if !info.Sealed {
+			fprintf(&cases, "case %s:\n", goType)
+		}
+		// Otherwise the compiler ensures that only member
+		// types can be used, so there's no need for a case.
+		if _, ok := mt.(*schema.Reference); ok && info.Sealed {
+			// Pointers to member types implement the
+			// interface too, but a nil pointer can't
+			// be encoded, even when the union
+			// allows null.
+			used = true
+			fprintf(&cases, "case *%s:\nif %s == nil {\nerrs.AddUnionType(%s, nil)\n}", goType, v, path)
+			if inner := gc.validateCode("*"+v, path, mt, depth+1); inner != "" {
+				fprintf(&cases, " else {\n%s}", inner)
+			}
+			fprintf(&cases, "\n")
+		}
+	}
+	if info.Sealed {
+		if !nullable {
+			fprintf(&cases, "case nil:\nerrs.AddUnionType(%s, nil)\n", path)
+		}
+	} else {
+		if nullable {
+			fprintf(&cases, "case nil:\n")
+		}
+		fprintf(&cases, "default:\nerrs.AddUnionType(%s, %s)\n", path, v)
+		used = true
+	}
+	if cases.Len() == 0 {
+		return ""
+	}
+	if used {
+		return fmt.Sprintf("switch %s := %s.(type) {\n%s}\n", v, x, cases.String())
+	}
+	return fmt.Sprintf("switch %s.(type) {\n%s}\n", x, cases.String())
+}
+
+// nonNullMember returns the member of the
+// union t that isn't null.
+func nonNullMember(t *schema.UnionField) schema.AvroType {
+	for _, mt := range t.AvroTypes() {
+		if !isNullField(mt) {
+			return mt
+		}
+	}
+	return nil
+}
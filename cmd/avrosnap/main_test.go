@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro/avroregistry"
+)
+
+func TestExportImport(t *testing.T) {
+	for _, snapshot := range []string{"snap", "snap.tar", "snap.tar.gz"} {
+		t.Run(snapshot, func(t *testing.T) {
+			c := qt.New(t)
+			ctx := context.Background()
+
+			src := newFakeRegistry()
+			src.compat[""] = "BACKWARD"
+			src.compat["a"] = "FULL_TRANSITIVE"
+			src.add("a", 1, 10, `{"type":"record","name":"R","fields":[{"name":"X","type":"long"}]}`)
+			src.add("a", 2, 12, `{"type":"record","name":"R","fields":[{"name":"X","type":"long"},{"name":"Y","type":"string","default":""}]}`)
+			src.add("b", 1, 10, `{"type":"record","name":"R","fields":[{"name":"X","type":"long"}]}`)
+			srcSrv := httptest.NewServer(src)
+			defer srcSrv.Close()
+
+			r, err := newRegistry(srcSrv.URL)
+			c.Assert(err, qt.IsNil)
+			dest := filepath.Join(c.TempDir(), snapshot)
+			err = export(ctx, r, dest)
+			c.Assert(err, qt.IsNil)
+
+			if !isTar(dest) {
+				// Check that the exported directory is usable as a registry.
+				dr, err := avroregistry.OpenDir(dest)
+				c.Assert(err, qt.IsNil)
+				c.Assert(dr.Subjects(), qt.DeepEquals, []string{"a", "b"})
+				s, err := dr.Schema("a", "latest")
+				c.Assert(err, qt.IsNil)
+				c.Assert(s.ID, qt.Equals, int64(12))
+			}
+
+			dst := newFakeRegistry()
+			dstSrv := httptest.NewServer(dst)
+			defer dstSrv.Close()
+			r, err = newRegistry(dstSrv.URL)
+			c.Assert(err, qt.IsNil)
+			err = import_(ctx, r, dest)
+			c.Assert(err, qt.IsNil)
+
+			c.Assert(dst.schemas, qt.DeepEquals, src.schemas)
+			c.Assert(dst.compat, qt.DeepEquals, src.compat)
+			c.Assert(dst.mode, qt.DeepEquals, map[string]string{})
+		})
+	}
+}
+
+func TestImportRequiresImportMode(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+
+	dir := c.TempDir()
+	err := writeDir(dir, map[string][]byte{
+		avroregistry.IndexFile:                 []byte(`{"schemas":[{"subject":"a","version":1,"id":5}]}`),
+		avroregistry.DirSchemaPath("a", 1):     []byte(`"string"`),
+		avroregistry.DirSchemaPath("other", 1): []byte(`"int"`),
+	})
+	c.Assert(err, qt.IsNil)
+
+	dst := newFakeRegistry()
+	dst.forbidMode = true
+	dstSrv := httptest.NewServer(dst)
+	defer dstSrv.Close()
+	r, err := newRegistry(dstSrv.URL)
+	c.Assert(err, qt.IsNil)
+	err = import_(ctx, r, dir)
+	c.Assert(err, qt.ErrorMatches, `cannot set import mode on "a": Avro registry error \(code 42205; HTTP status 422\): mode changes not allowed`)
+}
+
+func TestImportRestoresMode(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+
+	dir := c.TempDir()
+	err := writeDir(dir, map[string][]byte{
+		avroregistry.IndexFile:             []byte(`{"schemas":[{"subject":"a","version":1,"id":5},{"subject":"b","version":1,"id":6}]}`),
+		avroregistry.DirSchemaPath("a", 1): []byte(`"string"`),
+		avroregistry.DirSchemaPath("b", 1): []byte(`"int"`),
+	})
+	c.Assert(err, qt.IsNil)
+
+	dst := newFakeRegistry()
+	dst.mode[""] = "READONLY"
+	dst.mode["b"] = "READWRITE"
+	dstSrv := httptest.NewServer(dst)
+	defer dstSrv.Close()
+	r, err := newRegistry(dstSrv.URL)
+	c.Assert(err, qt.IsNil)
+	err = import_(ctx, r, dir)
+	c.Assert(err, qt.IsNil)
+	// Subject a had no mode of its own before the import,
+	// so it still inherits the global mode afterwards.
+	c.Assert(dst.mode, qt.DeepEquals, map[string]string{
+		"":  "READONLY",
+		"b": "READWRITE",
+	})
+
+	// The mode is restored when the import fails too.
+	dst.failImport = true
+	err = import_(ctx, r, dir)
+	c.Assert(err, qt.ErrorMatches, `cannot import version 1 of "a": .*import failed`)
+	c.Assert(dst.mode, qt.DeepEquals, map[string]string{
+		"":  "READONLY",
+		"b": "READWRITE",
+	})
+}
+
+func TestNewRegistryCredentials(t *testing.T) {
+	c := qt.New(t)
+	var user, password string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, password, _ = req.BasicAuth()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	r, err := newRegistry(strings.Replace(srv.URL, "http://", "http://bob:secret@", 1))
+	c.Assert(err, qt.IsNil)
+	_, err = r.Subjects(context.Background())
+	c.Assert(err, qt.IsNil)
+	c.Assert(user, qt.Equals, "bob")
+	c.Assert(password, qt.Equals, "secret")
+
+	_, err = newRegistry("")
+	c.Assert(err, qt.ErrorMatches, `no registry URL specified .*`)
+}
+
+// fakeRegistry implements the subset of the registry
+// REST API used by avrosnap.
+type fakeRegistry struct {
+	mu         sync.Mutex
+	schemas    map[string]map[int]avroregistry.Schema
+	compat     map[string]string
+	mode       map[string]string
+	forbidMode bool
+	failImport bool
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		schemas: make(map[string]map[int]avroregistry.Schema),
+		compat:  make(map[string]string),
+		mode:    make(map[string]string),
+	}
+}
+
+func (r *fakeRegistry) add(subject string, version int, id int64, schema string) {
+	if r.schemas[subject] == nil {
+		r.schemas[subject] = make(map[int]avroregistry.Schema)
+	}
+	r.schemas[subject][version] = avroregistry.Schema{
+		Subject: subject,
+		Version: version,
+		ID:      id,
+		Schema:  schema,
+	}
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	reply := func(status int, v interface{}) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	replyError := func(status, code int, msg string) {
+		reply(status, map[string]interface{}{
+			"error_code": code,
+			"message":    msg,
+		})
+	}
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case req.Method == "GET" && len(parts) == 1 && parts[0] == "subjects":
+		subjects := []string{}
+		for subject := range r.schemas {
+			subjects = append(subjects, subject)
+		}
+		sort.Strings(subjects)
+		reply(200, subjects)
+	case req.Method == "GET" && len(parts) == 3 && parts[0] == "subjects" && parts[2] == "versions":
+		versions := []int{}
+		for v := range r.schemas[parts[1]] {
+			versions = append(versions, v)
+		}
+		reply(200, versions)
+	case req.Method == "GET" && len(parts) == 4 && parts[0] == "subjects" && parts[2] == "versions":
+		v, _ := strconv.Atoi(parts[3])
+		s, ok := r.schemas[parts[1]][v]
+		if !ok {
+			replyError(404, 40402, "version not found")
+			return
+		}
+		reply(200, s)
+	case req.Method == "POST" && len(parts) == 3 && parts[0] == "subjects" && parts[2] == "versions":
+		var s avroregistry.Schema
+		json.NewDecoder(req.Body).Decode(&s)
+		if r.mode[parts[1]] != "IMPORT" {
+			replyError(422, 42205, "subject not in import mode")
+			return
+		}
+		if r.failImport {
+			replyError(422, 42201, "import failed")
+			return
+		}
+		r.add(parts[1], s.Version, s.ID, s.Schema)
+		reply(200, map[string]int64{"id": s.ID})
+	case parts[0] == "config":
+		subject := ""
+		if len(parts) > 1 {
+			subject = parts[1]
+		}
+		if req.Method == "GET" {
+			mode, ok := r.compat[subject]
+			if !ok {
+				replyError(404, 40408, "compatibility not configured")
+				return
+			}
+			reply(200, map[string]string{"compatibilityLevel": mode})
+			return
+		}
+		var body struct {
+			Compatibility string `json:"compatibility"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		r.compat[subject] = body.Compatibility
+		reply(200, body)
+	case req.Method == "GET" && parts[0] == "mode":
+		if len(parts) > 1 {
+			mode, ok := r.mode[parts[1]]
+			if !ok {
+				replyError(404, 40409, "subject mode not configured")
+				return
+			}
+			reply(200, map[string]string{"mode": mode})
+			return
+		}
+		mode, ok := r.mode[""]
+		if !ok {
+			mode = "READWRITE"
+		}
+		reply(200, map[string]string{"mode": mode})
+	case req.Method == "DELETE" && len(parts) == 2 && parts[0] == "mode":
+		mode, ok := r.mode[parts[1]]
+		if !ok {
+			replyError(404, 40409, "subject mode not configured")
+			return
+		}
+		delete(r.mode, parts[1])
+		reply(200, map[string]string{"mode": mode})
+	case req.Method == "PUT" && len(parts) == 2 && parts[0] == "mode":
+		if r.forbidMode {
+			replyError(422, 42205, "mode changes not allowed")
+			return
+		}
+		var body struct {
+			Mode string `json:"mode"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		r.mode[parts[1]] = body.Mode
+		reply(200, body)
+	default:
+		replyError(404, 404, "not found")
+	}
+}
@@ -1,3 +1,24 @@
+// The avsc2avdl command converts JSON Avro schemas to Avro IDL.
+//
+// Usage:
+//
+//	usage: avsc2avdl [flags] file.avsc
+//	       avsc2avdl [flags] file-or-dir...
+//	  -d string
+//	    	directory to write IDL files to when converting more than one schema (default ".")
+//	  -o string
+//	    	output filename (default stdout)
+//
+// When given a single schema file, it writes a protocol containing
+// all the definitions in the schema.
+//
+// When given more than one file, or a directory (which is searched
+// recursively for .avsc files), it writes a protocol file for each
+// namespace to the directory specified by the -d flag, named after
+// the namespace (for example com.example.avdl; definitions without
+// a namespace go in _.avdl). Definitions that appear in more than
+// one schema are written once, and each file imports the files
+// for the other namespaces that it refers to.
 package main
 
 import (
@@ -5,19 +26,27 @@ import (
 	"encoding/json"
 	stdflag "flag"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
 
+	"github.com/heetch/avro"
 	"github.com/heetch/avro/internal/typeinfo"
 
+	"github.com/actgardner/gogen-avro/v10/parser"
+	"github.com/actgardner/gogen-avro/v10/resolver"
 	"github.com/actgardner/gogen-avro/v10/schema"
 )
 
 var flag = stdflag.NewFlagSet("", stdflag.ContinueOnError)
 
-var outFile = flag.String("o", "", "output filename (default stdout)")
+var (
+	outFile = flag.String("o", "", "output filename (default stdout)")
+	dirFlag = flag.String("d", ".", "directory to write IDL files to when converting more than one schema")
+)
 
 func main() {
 	os.Exit(main1())
@@ -27,17 +56,26 @@ func main() {
 // code instead of calling os.Exit.
 func main1() int {
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "usage: avsc2avdl file.avdl\n")
+		fmt.Fprintf(os.Stderr, "usage: avsc2avdl [flags] file.avsc\n")
+		fmt.Fprintf(os.Stderr, "       avsc2avdl [flags] file-or-dir...\n")
 		flag.PrintDefaults()
 	}
 	if flag.Parse(os.Args[1:]) != nil {
 		return 2
 	}
-	if flag.NArg() != 1 {
+	if flag.NArg() == 0 {
 		flag.Usage()
 		return 2
 	}
-	if err := avsc2avdl(flag.Arg(0), *outFile); err != nil {
+	var err error
+	if flag.NArg() == 1 && !isDir(flag.Arg(0)) {
+		err = avsc2avdl(flag.Arg(0), *outFile)
+	} else if *outFile != "" {
+		err = fmt.Errorf("cannot use -o flag with more than one schema; use -d instead")
+	} else {
+		err = avsc2avdlFiles(flag.Args(), *dirFlag)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "avsc2avdl: %v\n", err)
 		return 1
 	}
@@ -64,33 +102,248 @@ func avsc2avdl(avscFile, outFile string) error {
 		line:     1,
 		done:     make(map[schema.QualifiedName]bool),
 	}
-	g.addDefinition(ref.Def)
 	// Use the top level definition's namespace as the default namespace for
 	// all definitions.
-	namespace := ref.Def.AvroName().Namespace
-	g.pushNamespace(namespace)
-	if namespace != "" {
-		g.printf("@namespace(%q)\n", namespace)
+	g.pushNamespace(ref.Def.AvroName().Namespace)
+	g.addDefinition(ref.Def)
+	g.writeProtocol(nil)
+	if outFile == "" {
+		os.Stdout.Write(g.buf.Bytes())
+		return nil
 	}
-	g.printf("protocol _ {\n")
-	for i := 0; ; i++ {
-		def := g.removeDefinition()
-		if def == nil {
-			break
+	return writeFile(outFile, g.buf.Bytes())
+}
+
+// avsc2avdlFiles converts all the AVSC files in args, which may
+// include directories, and writes an IDL file for each namespace
+// to the directory dir. Definitions that appear in more than
+// one schema are written only once.
+func avsc2avdlFiles(args []string, dir string) error {
+	files, err := schemaFiles(args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no schema files found")
+	}
+	// Parse each file separately, because the parser rejects
+	// repeated definitions that aren't spelled identically (for
+	// example, with and without an explicit namespace). Repeated
+	// definitions are compared by canonical form instead.
+	parsed := make([]*parser.Namespace, len(files))
+	// allDefs holds the first definition of each name.
+	allDefs := make(map[schema.QualifiedName]schema.Definition)
+	// defFiles holds the file that each definition in allDefs came from.
+	defFiles := make(map[schema.QualifiedName]string)
+	var topLevel []schema.QualifiedName
+	for i, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return err
 		}
-		if i > 0 {
-			g.printf("\n")
+		ns := parser.NewNamespace(false)
+		at, err := ns.TypeForSchema(data)
+		if err != nil {
+			return fmt.Errorf("cannot parse schema from %q: %v", f, err)
 		}
-		g.writeDefinition(def)
+		if ref, ok := at.(*schema.Reference); ok {
+			topLevel = append(topLevel, ref.TypeName)
+		}
+		for name, def := range ns.Definitions {
+			if _, ok := allDefs[name]; !ok {
+				allDefs[name] = def
+				defFiles[name] = f
+			}
+		}
+		parsed[i] = ns
 	}
-	g.printf("}\n")
-	if outFile == "" {
-		os.Stdout.Write(g.buf.Bytes())
-	} else {
-		err := os.WriteFile(outFile, g.buf.Bytes(), 0666)
+	// Group the definitions by namespace, retaining
+	// the order in which they were defined.
+	defs := make(map[string][]schema.Definition)
+	for i, ns := range parsed {
+		for _, def := range ns.Roots {
+			if err := resolver.ResolveDefinition(def, allDefs); err != nil {
+				return fmt.Errorf("cannot resolve references in %q: %v", files[i], err)
+			}
+		}
+		for _, def := range ns.Roots {
+			if _, ok := def.(*schema.FileRoot); ok {
+				continue
+			}
+			if allDefs[def.AvroName()] != def {
+				// Repeated definitions are checked below.
+				continue
+			}
+			namespace := def.AvroName().Namespace
+			defs[namespace] = append(defs[namespace], def)
+		}
+	}
+	// Check that repeated definitions are the same, ignoring
+	// any differences that don't affect the canonical form.
+	for i, ns := range parsed {
+		for _, def := range ns.Roots {
+			if _, ok := def.(*schema.FileRoot); ok {
+				continue
+			}
+			name := def.AvroName()
+			first := allDefs[name]
+			if first == def {
+				continue
+			}
+			c0, err := canonicalString(first)
+			if err != nil {
+				return fmt.Errorf("cannot parse definition of %v in %q: %v", name, defFiles[name], err)
+			}
+			c1, err := canonicalString(def)
+			if err != nil {
+				return fmt.Errorf("cannot parse definition of %v in %q: %v", name, files[i], err)
+			}
+			if c0 != c1 {
+				return fmt.Errorf("conflicting definitions for %v in %q and %q", name, defFiles[name], files[i])
+			}
+		}
+	}
+	namespaces := make([]string, 0, len(defs))
+	for namespace := range defs {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return fmt.Errorf("cannot create output directory: %v", err)
+	}
+	for _, namespace := range namespaces {
+		outFile := filepath.Join(dir, protocolFile(namespace))
+		g := &generator{
+			filename: outFile,
+			line:     1,
+			done:     make(map[schema.QualifiedName]bool),
+			split:    true,
+		}
+		g.pushNamespace(namespace)
+		// Write the top level definitions first, followed by
+		// the definitions they refer to, followed by any
+		// other definitions in the namespace.
+		for _, name := range topLevel {
+			g.addDefinition(allDefs[name])
+		}
+		g.pending = defs[namespace]
+		imported := make(map[string]bool)
+		for _, def := range defs[namespace] {
+			for _, ns := range referencedNamespaces(def) {
+				if ns != namespace {
+					imported[ns] = true
+				}
+			}
+		}
+		imports := make([]string, 0, len(imported))
+		for ns := range imported {
+			imports = append(imports, protocolFile(ns))
+		}
+		sort.Strings(imports)
+		g.writeProtocol(imports)
+		if err := writeFile(outFile, g.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canonicalString returns the canonical form of the
+// schema for def.
+func canonicalString(def schema.Definition) (string, error) {
+	d, err := def.Definition(make(map[schema.QualifiedName]interface{}))
+	if err != nil {
+		return "", err
+	}
+	// The definition omits any inherited namespace,
+	// so use the full name instead.
+	if m, ok := d.(map[string]interface{}); ok {
+		m["name"] = def.AvroName().String()
+		delete(m, "namespace")
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	t, err := avro.ParseType(string(data))
+	if err != nil {
+		return "", err
+	}
+	return t.CanonicalString(0), nil
+}
+
+// schemaFiles returns all the schema files named by args. Directories
+// are searched recursively for files with a .avsc extension.
+func schemaFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		if !isDir(arg) {
+			files = append(files, arg)
+			continue
+		}
+		var dirFiles []string
+		err := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(path) == ".avsc" {
+				dirFiles = append(dirFiles, path)
+			}
+			return nil
+		})
 		if err != nil {
-			return fmt.Errorf("cannot create output file: %v", err)
+			return nil, err
 		}
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+// protocolFile returns the name of the IDL file
+// holding the definitions in the given namespace.
+func protocolFile(namespace string) string {
+	if namespace == "" {
+		return "_.avdl"
+	}
+	return namespace + ".avdl"
+}
+
+// referencedNamespaces returns the namespaces of all
+// the named types referred to directly by def.
+func referencedNamespaces(def schema.Definition) []string {
+	var namespaces []string
+	var walk func(at schema.AvroType)
+	walk = func(at schema.AvroType) {
+		switch at := at.(type) {
+		case *schema.Reference:
+			namespaces = append(namespaces, at.TypeName.Namespace)
+		case *schema.ArrayField:
+			walk(at.ItemType())
+		case *schema.MapField:
+			walk(at.ItemType())
+		case *schema.UnionField:
+			for _, t := range at.ItemTypes() {
+				walk(t)
+			}
+		}
+	}
+	if def, ok := def.(*schema.RecordDefinition); ok {
+		for _, field := range def.Fields() {
+			walk(field.Type())
+		}
+	}
+	return namespaces
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func writeFile(outFile string, data []byte) error {
+	if err := os.WriteFile(outFile, data, 0666); err != nil {
+		return fmt.Errorf("cannot create output file: %v", err)
 	}
 	return nil
 }
@@ -102,6 +355,40 @@ type generator struct {
 	queue          []schema.Definition
 	namespaceStack []string
 	done           map[schema.QualifiedName]bool
+
+	// pending holds definitions to write after
+	// the queue is exhausted.
+	pending []schema.Definition
+
+	// split holds whether the definitions are split into
+	// a protocol file per namespace, in which case
+	// definitions in other namespaces are imported
+	// rather than written.
+	split bool
+}
+
+// writeProtocol writes a protocol in the current namespace containing
+// all the queued definitions and any definitions that they
+// refer to. The imports parameter holds the files to import.
+func (g *generator) writeProtocol(imports []string) {
+	if namespace := g.namespace(); namespace != "" {
+		g.printf("@namespace(%q)\n", namespace)
+	}
+	g.printf("protocol _ {\n")
+	for _, f := range imports {
+		g.printf("\timport idl %q;\n", f)
+	}
+	for i := 0; ; i++ {
+		def := g.removeDefinition()
+		if def == nil {
+			break
+		}
+		if i > 0 || len(imports) > 0 {
+			g.printf("\n")
+		}
+		g.writeDefinition(def)
+	}
+	g.printf("}\n")
 }
 
 func (g *generator) writeDefinition(def schema.Definition) {
@@ -121,7 +408,7 @@ func (g *generator) writeDefinition(def schema.Definition) {
 		g.printf("\trecord %s {\n", name.Name)
 		for _, field := range def.Fields() {
 			g.writeMetadata(field, "\t\t")
-			g.printf("\t\t%s %s", g.typeString(field.Type()), field.Name())
+			g.printf("\t\t%s %s", g.typeString(field.Type(), "\t\t"), field.Name())
 			if field.HasDefault() {
 				switch {
 				case isEnum(field.Type()):
@@ -154,9 +441,11 @@ func (g *generator) writeDefinition(def schema.Definition) {
 			}
 			g.printf("\n")
 		}
-		// TODO support enum defaults (gogen-avro doesn't make it easy to get
-		// them - it might not support them currently itself)
-		g.printf("\t}\n")
+		g.printf("\t}")
+		if dflt, ok := def.Attribute("default").(string); ok {
+			g.printf(" = %s;", dflt)
+		}
+		g.printf("\n")
 	case *schema.FixedDefinition:
 		g.writeMetadata(def, "\t")
 		writeNamespace()
@@ -166,9 +455,13 @@ func (g *generator) writeDefinition(def schema.Definition) {
 	}
 }
 
-func (g *generator) typeString(at schema.AvroType) string {
-	// TODO support logical types that are recognised directly
-	// by IDL (decimal, date, time_millis and timestamp_millis)
+// typeString returns the IDL for the type at. The indent
+// parameter holds the indentation of the line that
+// the type starts on.
+func (g *generator) typeString(at schema.AvroType, indent string) string {
+	if keyword, _ := logicalTypeKeyword(at); keyword != "" {
+		return keyword
+	}
 	switch at := at.(type) {
 	case *schema.Reference:
 		g.addDefinition(at.Def)
@@ -193,24 +486,21 @@ func (g *generator) typeString(at schema.AvroType) string {
 	case *schema.StringField:
 		return "string"
 	case *schema.ArrayField:
-		return "array<" + g.typeString(at.ItemType()) + ">"
+		return "array<" + g.typeString(at.ItemType(), indent) + ">"
 	case *schema.MapField:
-		return "map<" + g.typeString(at.ItemType()) + ">"
+		return "map<" + g.typeString(at.ItemType(), indent) + ">"
 	case *schema.UnionField:
 		types := at.ItemTypes()
 		if len(types) > 2 {
 			// It's a long union type; format the types on separate lines.
-			s := "union {\n\t\t\t"
+			s := "union {\n" + indent + "\t"
 			for i, ut := range types {
 				if i > 0 {
-					// TODO it's possible to have nested union types.
-					// To fix that we'll need to have indent as an argument
-					// to typeString.
-					s += ",\n\t\t\t"
+					s += ",\n" + indent + "\t"
 				}
-				s += g.typeString(ut)
+				s += g.typeString(ut, indent+"\t")
 			}
-			s += "\n\t\t}"
+			s += "\n" + indent + "}"
 			return s
 		}
 		s := "union { "
@@ -218,7 +508,7 @@ func (g *generator) typeString(at schema.AvroType) string {
 			if i > 0 {
 				s += ", "
 			}
-			s += g.typeString(ut)
+			s += g.typeString(ut, indent)
 		}
 		s += " }"
 		return s
@@ -227,6 +517,57 @@ func (g *generator) typeString(at schema.AvroType) string {
 	}
 }
 
+// logicalTypeKeyword returns the IDL keyword for the logical type
+// of at, if there is one, and the attributes of at that are
+// represented by the keyword.
+func logicalTypeKeyword(at schema.AvroType) (string, []string) {
+	a, ok := at.(interface {
+		Attribute(name string) interface{}
+	})
+	if !ok {
+		return "", nil
+	}
+	logicalType, _ := a.Attribute("logicalType").(string)
+	switch at.(type) {
+	case *schema.IntField:
+		switch logicalType {
+		case "date":
+			return "date", []string{"logicalType"}
+		case "time-millis":
+			return "time_ms", []string{"logicalType"}
+		}
+	case *schema.LongField:
+		switch logicalType {
+		case "timestamp-millis":
+			return "timestamp_ms", []string{"logicalType"}
+		case "local-timestamp-millis":
+			return "local_timestamp_ms", []string{"logicalType"}
+		}
+	case *schema.StringField:
+		if logicalType == "uuid" {
+			return "uuid", []string{"logicalType"}
+		}
+	case *schema.BytesField:
+		if logicalType != "decimal" {
+			break
+		}
+		precision, ok := a.Attribute("precision").(float64)
+		if !ok || precision != float64(int(precision)) {
+			// The precision is required for decimal values.
+			break
+		}
+		scale := 0.0
+		if s := a.Attribute("scale"); s != nil {
+			scale, ok = s.(float64)
+			if !ok || scale != float64(int(scale)) {
+				break
+			}
+		}
+		return fmt.Sprintf("decimal(%d, %d)", int(precision), int(scale)), []string{"logicalType", "precision", "scale"}
+	}
+	return "", nil
+}
+
 func (g *generator) writeMetadata(d interface{}, indent string) {
 	m := getMetadata(d)
 	if m.doc != "" {
@@ -290,6 +631,12 @@ func getMetadata(d interface{}) metadata {
 		// can be no metadata.
 		if _, ok := d.Type().(*schema.Reference); !ok {
 			m = getMetadata(d.Type())
+			// Attributes represented by an IDL logical type
+			// keyword aren't needed as annotations.
+			_, attrs := logicalTypeKeyword(d.Type())
+			for _, attr := range attrs {
+				delete(m.attrs, attr)
+			}
 		}
 		if m.attrs == nil {
 			m.attrs = make(map[string]interface{})
@@ -299,7 +646,13 @@ func getMetadata(d interface{}) metadata {
 		Definition(scope map[schema.QualifiedName]interface{}) (interface{}, error)
 	}:
 		def, _ := d.Definition(make(map[schema.QualifiedName]interface{}))
-		m.attrs, _ = def.(map[string]interface{})
+		// Copy the attributes because the definition map
+		// is shared with the schema itself.
+		attrs, _ := def.(map[string]interface{})
+		m.attrs = make(map[string]interface{}, len(attrs))
+		for name, val := range attrs {
+			m.attrs[name] = val
+		}
 	default:
 		panic(fmt.Errorf("invalid type %T for definitionOf", d))
 	}
@@ -338,7 +691,7 @@ var stdAttrs = map[reflect.Type][]string{
 	elType(new(*schema.RecordDefinition)): {"name", "namespace", "fields"},
 	elType(new(*schema.FixedDefinition)):  {"name", "namespace", "size"},
 	elType(new(*schema.MapField)):         {"values"},
-	elType(new(*schema.EnumDefinition)):   {"name", "namespace", "symbols"},
+	elType(new(*schema.EnumDefinition)):   {"name", "namespace", "symbols", "default"},
 	elType(new(*schema.ArrayField)):       {"items"},
 	elType(new(*schema.Field)):            {"name", "default"},
 }
@@ -352,11 +705,19 @@ func (g *generator) addDefinition(def schema.Definition) {
 	if g.done[def.AvroName()] {
 		return
 	}
+	if g.split && def.AvroName().Namespace != g.namespaceStack[0] {
+		// The definition is in another protocol file.
+		return
+	}
 	g.queue = append(g.queue, def)
 	g.done[def.AvroName()] = true
 }
 
 func (g *generator) removeDefinition() schema.Definition {
+	for len(g.queue) == 0 && len(g.pending) > 0 {
+		g.addDefinition(g.pending[0])
+		g.pending = g.pending[1:]
+	}
 	if len(g.queue) == 0 {
 		return nil
 	}
@@ -3,11 +3,14 @@ package avro
 import (
 	"fmt"
 	"io"
+	"math"
 	"reflect"
 	"time"
 
 	"github.com/actgardner/gogen-avro/v10/vm"
 	gouuid "github.com/google/uuid"
+
+	"github.com/heetch/avro/internal/typeinfo"
 )
 
 // Unmarshal unmarshals the given Avro-encoded binary data, which must
@@ -19,6 +22,11 @@ import (
 // rules described here:
 // https://avro.apache.org/docs/current/spec.html#Schema+Resolution
 //
+// Unmarshal applies only the default decode limits, which bound the
+// nesting depth but not the size of decoded values (see DecodeLimits).
+// When decoding untrusted data, use Names.WithDecodeLimits to set
+// stricter limits.
+//
 // Unmarshal returns the reader type.
 func Unmarshal(data []byte, x interface{}, wType *Type) (*Type, error) {
 	return globalNames.Unmarshal(data, x, wType)
@@ -37,7 +45,7 @@ func (names *Names) Unmarshal(data []byte, x interface{}, wType *Type) (*Type, e
 		return nil, err
 	}
 	v = v.Elem()
-	return unmarshal(nil, data, prog, v)
+	return unmarshal(nil, data, prog, v, names.limits())
 }
 
 // stackFrame represents the registers that are mutated by the VM interpreter.
@@ -61,6 +69,31 @@ type decoder struct {
 	scan    int
 	r       io.Reader
 	readErr error
+
+	// limits holds the limits to enforce when decoding.
+	limits DecodeLimits
+
+	// allocated holds the number of bytes allocated so far,
+	// as counted against limits.MaxAllocation.
+	allocated int64
+
+	// consumed holds the number of bytes that have been
+	// consumed and discarded from buf.
+	consumed int64
+
+	// depth holds the number of values that the value currently
+	// being decoded is nested inside, as checked against
+	// limits.MaxDepth.
+	depth int
+
+	// trace holds whether path is maintained. It's only set when
+	// decoding again after an error, so that the path isn't
+	// computed when decoding succeeds.
+	trace bool
+
+	// path holds the path to the value currently being decoded
+	// when trace is set.
+	path []valuePathElem
 }
 
 type decodeError struct {
@@ -68,23 +101,33 @@ type decodeError struct {
 }
 
 // unmarshal unmarshals Avro binary data from r and writes it to target
-// following the given program.
-func unmarshal(r io.Reader, buf []byte, prog *decodeProgram, target reflect.Value) (_ *Type, err error) {
+// following the given program, enforcing the given limits.
+func unmarshal(r io.Reader, buf []byte, prog *decodeProgram, target reflect.Value, limits DecodeLimits) (*Type, error) {
 	if debugging {
 		debugf("unmarshal %x into %s", buf, target.Type())
 	}
-	defer func() {
-		switch panicErr := recover().(type) {
-		case *decodeError:
-			err = panicErr.err
-		case nil:
-		default:
-			panic(panicErr)
+	d := newDecoder(r, buf, prog, limits)
+	err := d.decode(target)
+	if err == nil {
+		return prog.readerType, nil
+	}
+	if r == nil {
+		// Decode again into a new value, this time keeping
+		// track of the path to the value that fails.
+		td := newDecoder(nil, buf, prog, limits)
+		td.trace = true
+		if terr := td.decode(reflect.New(target.Type()).Elem()); terr != nil {
+			return nil, td.pathError(terr.err, target.Type())
 		}
-	}()
+	}
+	return nil, d.pathError(err.err, target.Type())
+}
+
+func newDecoder(r io.Reader, buf []byte, prog *decodeProgram, limits DecodeLimits) decoder {
 	d := decoder{
 		r:       r,
 		program: prog,
+		limits:  limits,
 	}
 	if r == nil {
 		d.buf = buf
@@ -92,8 +135,30 @@ func unmarshal(r io.Reader, buf []byte, prog *decodeProgram, target reflect.Valu
 	} else {
 		d.buf = make([]byte, 0, bufSize)
 	}
-	d.eval(target)
-	return prog.readerType, nil
+	return d
+}
+
+// decode decodes into target, returning any error
+// encountered.
+func (d *decoder) decode(target reflect.Value) (err *decodeError) {
+	defer func() {
+		switch panicErr := recover().(type) {
+		case *decodeError:
+			err = panicErr
+		case nil:
+		default:
+			panic(panicErr)
+		}
+	}()
+	if d.program.rootWrapped {
+		target = target.Field(0)
+	}
+	if d.program.rootUnmarshal != nil {
+		d.evalUnmarshal(target, d.program.rootUnmarshal)
+	} else {
+		d.eval(target)
+	}
+	return nil
 }
 
 func (d *decoder) eval(target reflect.Value) {
@@ -117,8 +182,10 @@ func (d *decoder) eval(target reflect.Value) {
 			case vm.Boolean:
 				frame.Boolean = d.readBool()
 			case vm.Int:
-				// TODO bounds check. See https://github.com/heetch/avro/issues/33.
 				frame.Int = d.readLong()
+				if frame.Int < math.MinInt32 || frame.Int > math.MaxInt32 {
+					d.error(fmt.Errorf("int value %d out of range", frame.Int))
+				}
 			case vm.Long:
 				frame.Int = d.readLong()
 			case vm.UnusedLong:
@@ -157,10 +224,10 @@ func (d *decoder) eval(target reflect.Value) {
 					// duration-nanos
 					target.Set(reflect.ValueOf(time.Duration(frame.Int)))
 				default:
-					target.SetInt(frame.Int)
+					d.setInt(target, frame.Int)
 				}
 			case vm.Int:
-				target.SetInt(frame.Int)
+				d.setInt(target, frame.Int)
 			case vm.Float, vm.Double:
 				target.SetFloat(frame.Float)
 			case vm.Bytes:
@@ -197,14 +264,32 @@ func (d *decoder) eval(target reflect.Value) {
 				panic(fmt.Errorf("no makeDefault at PC %d; prog %p", d.pc, &d.program.makeDefault[0]))
 			}
 			v := d.program.makeDefault[d.pc]()
-			target.Field(inst.Operand).Set(v)
+			typeinfo.FieldByIndex(target, d.program.defaultField[d.pc]).Set(v)
 		case vm.Enter:
 			val, isRef := d.program.enter[d.pc](target)
 			if debugging {
 				debugf("enter %d -> %#v (isRef %v) {", inst.Operand, val, isRef)
 			}
+			pc := d.pc
 			d.pc++
-			d.eval(val)
+			d.descend()
+			if d.trace {
+				elem := valuePathElem{
+					kind: pathUnion,
+				}
+				if name := d.program.enterField[pc]; name != "" {
+					elem = valuePathElem{
+						kind: pathField,
+						name: name,
+					}
+				}
+				if val.IsValid() {
+					elem.goType = val.Type()
+				}
+				d.path = append(d.path, elem)
+			}
+			d.evalElem(pc, val)
+			d.ascend()
 			if !isRef {
 				target.Set(val)
 			}
@@ -214,24 +299,48 @@ func (d *decoder) eval(target reflect.Value) {
 			}
 			return
 		case vm.AppendArray:
+			d.alloc(int64(target.Type().Elem().Size()))
 			target.Set(reflect.Append(target, reflect.Zero(target.Type().Elem())))
+			pc := d.pc
 			d.pc++
-			d.eval(target.Index(target.Len() - 1))
+			d.descend()
+			if d.trace {
+				d.path = append(d.path, valuePathElem{
+					kind:   pathIndex,
+					index:  target.Len() - 1,
+					goType: target.Type().Elem(),
+				})
+			}
+			d.evalElem(pc, target.Index(target.Len()-1))
+			d.ascend()
 		case vm.AppendMap:
+			d.alloc(int64(target.Type().Key().Size() + target.Type().Elem().Size()))
+			pc := d.pc
 			d.pc++
 			elem := reflect.New(target.Type().Elem()).Elem()
-			d.eval(elem)
+			d.descend()
+			if d.trace {
+				d.path = append(d.path, valuePathElem{
+					kind:   pathKey,
+					name:   frame.String,
+					goType: elem.Type(),
+				})
+			}
+			key, err := mapKeyValue(target.Type().Key(), frame.String)
+			if err != nil {
+				d.error(err)
+			}
+			d.evalElem(pc, elem)
+			d.ascend()
 			if target.IsNil() {
-				// TODO we'd like to encode (null | map) by using a nil
-				// map value, but because we're only making the map
-				// when we append the first element, all empty maps
-				// will also be nil. Perhaps when SetLong is called on the
-				// union type, we should create the map.
+				// We only make the map when we append the first
+				// element, so empty maps decode as nil, except
+				// for (null | map) unions made by Names.WithNullableFields,
+				// where the map is made when the union is entered.
 				// The same applies to slices.
-				// See https://github.com/heetch/avro/issues/19
 				target.Set(reflect.MakeMap(target.Type()))
 			}
-			target.SetMapIndex(reflect.ValueOf(frame.String), elem)
+			target.SetMapIndex(key, elem)
 		case vm.Call:
 			curr := d.pc
 			d.pc = inst.Operand
@@ -240,6 +349,8 @@ func (d *decoder) eval(target reflect.Value) {
 		case vm.SetExitNull:
 			// This is a no-op by now as it's handled by isRef
 		case vm.HintSize:
+			// The frame holds the item count of the current block.
+			d.checkBlockCount(frame.Int)
 			// This is a performance improvement to put a capacity to slice
 			if target.Kind() == reflect.Slice && target.IsZero() {
 				target.Set(reflect.MakeSlice(target.Type(), 0, inst.Operand))
@@ -286,6 +397,46 @@ func (d *decoder) eval(target reflect.Value) {
 	}
 }
 
+// evalElem evaluates the program into the value entered
+// by the instruction at pc.
+func (d *decoder) evalElem(pc int, target reflect.Value) {
+	if rt := d.program.unmarshal[pc]; rt != nil {
+		d.evalUnmarshal(target, rt)
+	} else {
+		d.eval(target)
+	}
+}
+
+// setInt sets the integer target to x, failing if the value
+// cannot be represented by the target type.
+func (d *decoder) setInt(target reflect.Value, x int64) {
+	if target.OverflowInt(x) {
+		d.error(fmt.Errorf("value %d out of range for %s", x, target.Type()))
+	}
+	target.SetInt(x)
+}
+
+// pathError returns a *DecodeError that describes where
+// the decoder encountered the given error. The rootType
+// argument holds the type of the value being decoded into.
+func (d *decoder) pathError(err error, rootType reflect.Type) error {
+	e := &DecodeError{
+		WriterType: d.program.writerType,
+		ReaderType: d.program.readerType,
+		Offset:     d.consumed + int64(d.scan),
+		Err:        err,
+		GoType:     rootType,
+	}
+	e.Path = valuePath(e.ReaderType, d.path)
+	for i := len(d.path) - 1; i >= 0; i-- {
+		if t := d.path[i].goType; t != nil {
+			e.GoType = t
+			break
+		}
+	}
+	return e
+}
+
 func (d *decoder) error(err error) {
 	panic(&decodeError{
 		err: err,
@@ -34,22 +34,25 @@ func Marshal(x interface{}) ([]byte, *Type, error) {
 	return globalNames.Marshal(x)
 }
 
-func marshalAppend(names *Names, buf []byte, xv reflect.Value) (_ []byte, _ *Type, marshalErr error) {
+func marshalAppend(names *Names, buf []byte, xv reflect.Value) ([]byte, *Type, error) {
 	avroType, enc := typeEncoder(names, xv.Type())
 	e := &encodeState{
 		Buffer: bytes.NewBuffer(buf),
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			if err, ok := r.(*encodeError); ok {
-				marshalErr = err.err
-			} else {
-				panic(r)
-			}
-		}
-	}()
-	enc(e, xv)
-	return e.Bytes(), avroType, nil
+	err := e.run(enc, xv)
+	if err == nil {
+		return e.Bytes(), avroType, nil
+	}
+	// Encode again, this time keeping track of the
+	// path to the value that fails.
+	te := &encodeState{
+		Buffer: new(bytes.Buffer),
+		trace:  new(encodeTrace),
+	}
+	if terr := te.run(enc, xv); terr != nil {
+		e, err = te, terr
+	}
+	return nil, nil, e.pathError(err.err, avroType, xv.Type())
 }
 
 func typeEncoder(names *Names, t reflect.Type) (*Type, encoderFunc) {
@@ -68,7 +71,16 @@ func typeEncoder(names *Names, t reflect.Type) (*Type, encoderFunc) {
 		names:        names,
 		typeEncoders: make(map[reflect.Type]encoderFunc),
 	}
-	enc := b.typeEncoder(at.avroType, t, typeinfo.Info{})
+	var enc encoderFunc
+	if info, ok := typeinfo.ForWrapper(t); ok {
+		// It's a wrapper type, so encode the value inside it.
+		valueEnc := b.typeEncoder(at.avroType, info.Type, info)
+		enc = func(e *encodeState, v reflect.Value) {
+			valueEnc(e, v.Field(0))
+		}
+	} else {
+		enc = b.typeEncoder(at.avroType, t, typeinfo.Info{})
+	}
 	names.goTypeToEncoder.LoadOrStore(t, &encoderInfo{
 		avroType: at,
 		encode:   enc,
@@ -79,11 +91,69 @@ func typeEncoder(names *Names, t reflect.Type) (*Type, encoderFunc) {
 type encodeState struct {
 	*bytes.Buffer
 	scratch [64]byte
+
+	// trace is only set when encoding again after an error,
+	// so that the path to the failing value isn't computed
+	// when encoding succeeds.
+	trace *encodeTrace
+}
+
+type encodeTrace struct {
+	// path holds the path to the value currently being encoded.
+	path []valuePathElem
+}
+
+// run encodes v with enc, returning any error encountered.
+func (e *encodeState) run(enc encoderFunc, v reflect.Value) (err *encodeError) {
+	defer func() {
+		if r := recover(); r != nil {
+			var ok bool
+			if err, ok = r.(*encodeError); !ok {
+				panic(r)
+			}
+		}
+	}()
+	enc(e, v)
+	return nil
 }
 
 // error aborts the encoding by panicking with err wrapped in encodeError.
 func (e *encodeState) error(err error) {
-	panic(&encodeError{err})
+	panic(&encodeError{err: err})
+}
+
+// enter is called when tracing and the encoder starts
+// encoding the nested value described by elem.
+// It should be paired with a call to leave.
+func (e *encodeState) enter(elem valuePathElem) {
+	e.trace.path = append(e.trace.path, elem)
+}
+
+func (e *encodeState) leave() {
+	e.trace.path = e.trace.path[:len(e.trace.path)-1]
+}
+
+// pathError returns a *EncodeError that describes where the
+// encoder encountered the given error. The rootType argument holds
+// the type of the value being encoded and at holds its Avro type.
+func (e *encodeState) pathError(err error, at *Type, rootType reflect.Type) error {
+	ee := &EncodeError{
+		GoType: rootType,
+		Type:   at,
+		Err:    err,
+	}
+	var path []valuePathElem
+	if e.trace != nil {
+		path = e.trace.path
+	}
+	ee.Path = valuePath(at, path)
+	for i := len(path) - 1; i >= 0; i-- {
+		if t := path[i].goType; t != nil {
+			ee.GoType = t
+			break
+		}
+	}
+	return ee
 }
 
 func errorEncoder(err error) encoderFunc {
@@ -106,9 +176,33 @@ type encoderBuilder struct {
 // typeEncoder returns an encoder that encodes values of type t according
 // to the Avro type at.
 func (b *encoderBuilder) typeEncoder(at schema.AvroType, t reflect.Type, info typeinfo.Info) encoderFunc {
+	if ut, ok := at.(*schema.UnionField); ok && isNullableValueUnion(t, info) {
+		// It's a field made nullable by Names.WithNullableFields.
+		// Check this before looking in typeEncoders, which holds
+		// encoders for the non-union type.
+		atypes := ut.ItemTypes()
+		if len(atypes) != 2 {
+			return errorEncoder(fmt.Errorf("unexpected item type count in union"))
+		}
+		return nullableEncoder{
+			encodeElem: b.typeEncoder(atypes[1], t, info.Entries[1]),
+		}.encode
+	}
 	if enc := b.typeEncoders[t]; enc != nil {
 		return enc
 	}
+	if _, rt, ok, err := representationOf(t); ok {
+		if err != nil {
+			return errorEncoder(err)
+		}
+		if !reflect.PtrTo(t).Implements(avroMarshalerType) {
+			return errorEncoder(fmt.Errorf("%s does not implement AvroMarshaler", t))
+		}
+		return marshalerEncoder{
+			reprType: rt,
+			enc:      b.typeEncoder(at, rt, typeinfo.Info{}),
+		}.encode
+	}
 	switch at := at.(type) {
 	case *schema.Reference:
 		switch def := at.Def.(type) {
@@ -118,7 +212,7 @@ func (b *encoderBuilder) typeEncoder(at schema.AvroType, t reflect.Type, info ty
 			}
 			if len(info.Entries) == 0 {
 				// The type itself might contribute information.
-				info1, err := typeinfo.ForType(t)
+				info1, err := typeinfo.ForType(t, b.names.typeinfoOptions())
 				if err != nil {
 					return errorEncoder(fmt.Errorf("cannot get info for %s: %v", info.Type, err))
 				}
@@ -139,19 +233,22 @@ func (b *encoderBuilder) typeEncoder(at schema.AvroType, t reflect.Type, info ty
 				enc(e, v)
 			}
 			fieldEncoders := make([]encoderFunc, len(def.Fields()))
-			indexes := make([]int, len(def.Fields()))
+			indexes := make([][]int, len(def.Fields()))
+			names := make([]string, len(def.Fields()))
 			for i, f := range def.Fields() {
 				fieldInfo, ok := entryByName(info.Entries, f.Name())
 				if !ok {
 					return errorEncoder(fmt.Errorf("field %q not found in %s", f.Name(), t))
 				}
 				fieldIndex := fieldInfo.FieldIndex
-				fieldEncoders[i] = b.typeEncoder(f.Type(), t.Field(fieldIndex).Type, info.Entries[i])
+				fieldEncoders[i] = b.typeEncoder(f.Type(), t.FieldByIndex(fieldIndex).Type, fieldInfo)
 				indexes[i] = fieldIndex
+				names[i] = f.Name()
 			}
 			enc = structEncoder{
 				fieldEncoders: fieldEncoders,
 				fieldIndexes:  indexes,
+				fieldNames:    names,
 			}.encode
 			return enc
 		case *schema.EnumDefinition:
@@ -203,6 +300,9 @@ func (b *encoderBuilder) typeEncoder(at schema.AvroType, t reflect.Type, info ty
 			return errorEncoder(fmt.Errorf("union type is not pointer or interface"))
 		}
 	case *schema.MapField:
+		if !canEncodeMapKey(t.Key()) {
+			return errorEncoder(fmt.Errorf("cannot encode map with key type %s", t.Key()))
+		}
 		return mapEncoder{b.typeEncoder(at.ItemType(), t.Elem(), info)}.encode
 	case *schema.ArrayField:
 		return arrayEncoder{b.typeEncoder(at.ItemType(), t.Elem(), info)}.encode
@@ -307,25 +407,52 @@ func (me mapEncoder) encode(e *encodeState, v reflect.Value) {
 		return
 	}
 	if sortMapKeys {
-		keys := make([]string, 0, n)
+		type entry struct {
+			key string
+			val reflect.Value
+		}
+		entries := make([]entry, 0, n)
 		for iter := v.MapRange(); iter.Next(); {
-			keys = append(keys, iter.Key().String())
+			entries = append(entries, entry{e.mapKey(iter.Key()), iter.Value()})
 		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			kv := reflect.ValueOf(k)
-			stringEncoder(e, kv)
-			me.encodeElem(e, v.MapIndex(kv))
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].key < entries[j].key
+		})
+		for _, ent := range entries {
+			me.encodeEntry(e, v, ent.key, ent.val)
 		}
 	} else {
 		for iter := v.MapRange(); iter.Next(); {
-			stringEncoder(e, iter.Key())
-			me.encodeElem(e, iter.Value())
+			me.encodeEntry(e, v, e.mapKey(iter.Key()), iter.Value())
 		}
 	}
 	e.writeLong(0)
 }
 
+func (me mapEncoder) encodeEntry(e *encodeState, m reflect.Value, key string, val reflect.Value) {
+	e.writeString(key)
+	if e.trace != nil {
+		e.enter(valuePathElem{
+			kind:   pathKey,
+			name:   key,
+			goType: m.Type().Elem(),
+		})
+	}
+	me.encodeElem(e, val)
+	if e.trace != nil {
+		e.leave()
+	}
+}
+
+// mapKey returns the Avro map key for the Go map key k.
+func (e *encodeState) mapKey(k reflect.Value) string {
+	s, err := mapKeyString(k)
+	if err != nil {
+		e.error(err)
+	}
+	return s
+}
+
 type arrayEncoder struct {
 	encodeElem encoderFunc
 }
@@ -337,7 +464,17 @@ func (ae arrayEncoder) encode(e *encodeState, v reflect.Value) {
 		return
 	}
 	for i := 0; i < n; i++ {
+		if e.trace != nil {
+			e.enter(valuePathElem{
+				kind:   pathIndex,
+				index:  i,
+				goType: v.Type().Elem(),
+			})
+		}
 		ae.encodeElem(e, v.Index(i))
+		if e.trace != nil {
+			e.leave()
+		}
 	}
 	e.writeLong(0)
 }
@@ -362,6 +499,11 @@ func (e *encodeState) writeLong(x int64) {
 	e.Write(e.scratch[:n])
 }
 
+func (e *encodeState) writeString(s string) {
+	e.writeLong(int64(len(s)))
+	e.WriteString(s)
+}
+
 func floatEncoder(e *encodeState, v reflect.Value) {
 	binary.LittleEndian.PutUint32(e.scratch[:], math.Float32bits(float32(v.Float())))
 	e.Write(e.scratch[:4])
@@ -379,22 +521,47 @@ func bytesEncoder(e *encodeState, v reflect.Value) {
 }
 
 func stringEncoder(e *encodeState, v reflect.Value) {
-	s := v.String()
-	e.writeLong(int64(len(s)))
-	e.WriteString(s)
+	e.writeString(v.String())
 }
 
 type structEncoder struct {
-	fieldIndexes  []int
+	fieldIndexes  [][]int
 	fieldEncoders []encoderFunc
+	fieldNames    []string
 }
 
 func (se structEncoder) encode(e *encodeState, v reflect.Value) {
-	for i, index := range se.fieldIndexes {
-		se.fieldEncoders[i](e, v.Field(index))
+	for i := range se.fieldIndexes {
+		if e.trace != nil {
+			e.enter(valuePathElem{
+				kind:   pathField,
+				name:   se.fieldNames[i],
+				goType: v.Type().FieldByIndex(se.fieldIndexes[i]).Type,
+			})
+		}
+		se.fieldEncoders[i](e, fieldByIndex(v, se.fieldIndexes[i]))
+		if e.trace != nil {
+			e.leave()
+		}
 	}
 }
 
+// fieldByIndex is like v.FieldByIndex except that when
+// a nil embedded struct pointer is found on the way, it
+// returns the zero value of the field.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(v.Type().Elem().FieldByIndex(index[i:]).Type)
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
 type unionEncoderChoice struct {
 	typ reflect.Type
 	enc encoderFunc
@@ -426,9 +593,52 @@ func (ue unionEncoder) encode(e *encodeState, v reflect.Value) {
 			return
 		}
 	}
+	if vt.Kind() == reflect.Ptr && !v.IsNil() {
+		// A pointer to a member type can implement a sealed
+		// union interface too, so allow that.
+		for i, choice := range ue.choices {
+			if choice.typ == vt.Elem() {
+				e.writeLong(int64(i))
+				choice.enc(e, v.Elem())
+				return
+			}
+		}
+	}
 	e.error(fmt.Errorf("unknown type for union %s", vt))
 }
 
+// isNullableValueUnion reports whether info describes a union
+// of null and t, where t isn't a pointer or interface type,
+// so the zero value of t represents null.
+func isNullableValueUnion(t reflect.Type, info typeinfo.Info) bool {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		return false
+	}
+	return info.IsUnion && len(info.Entries) == 2 && info.Entries[0].Type == nil && info.Entries[1].Type == t
+}
+
+// nullableEncoder encodes a ["null", T] union represented
+// by a value of type T.
+type nullableEncoder struct {
+	encodeElem encoderFunc
+}
+
+func (ne nullableEncoder) encode(e *encodeState, v reflect.Value) {
+	var isNull bool
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		isNull = v.IsNil()
+	default:
+		isNull = v.IsZero()
+	}
+	if isNull {
+		e.writeLong(0)
+		return
+	}
+	e.writeLong(1)
+	ne.encodeElem(e, v)
+}
+
 type ptrUnionEncoder struct {
 	indexes    [2]byte
 	encodeElem encoderFunc
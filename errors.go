@@ -0,0 +1,128 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DecodeError is the error returned when Avro binary data cannot be
+// decoded. Use errors.As to find out more about where the error
+// occurred.
+type DecodeError struct {
+	// Path holds the location of the value that failed to decode,
+	// starting at the name of the reader type, for example
+	// "R.items[3].key" or `R.attrs["x"]`. Union members
+	// don't contribute to the path.
+	Path string
+
+	// GoType holds the Go type that was being decoded into
+	// at the point of failure, if known.
+	GoType reflect.Type
+
+	// WriterType and ReaderType hold the writer and reader
+	// types used for decoding.
+	WriterType *Type
+	ReaderType *Type
+
+	// Offset holds the byte offset within the data
+	// at which the error was detected.
+	Offset int64
+
+	// Err holds the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s (offset %d): %v", e.Path, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeError is the error returned when a Go value cannot be
+// encoded. Use errors.As to find out more about where the error
+// occurred.
+type EncodeError struct {
+	// Path holds the location of the value that failed to encode,
+	// in the same form as DecodeError.Path.
+	Path string
+
+	// GoType holds the Go type of the value that failed
+	// to encode, if known.
+	GoType reflect.Type
+
+	// Type holds the Avro type that was being used for encoding,
+	// if known.
+	Type *Type
+
+	// Err holds the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *EncodeError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+type pathElemKind uint8
+
+const (
+	pathField pathElemKind = iota
+	pathUnion
+	pathIndex
+	pathKey
+)
+
+// valuePathElem holds an element of the path to a value
+// within a decoded or encoded message.
+type valuePathElem struct {
+	kind pathElemKind
+	// name holds the field name or the map key.
+	name string
+	// index holds the array index.
+	index int
+	// goType holds the Go type of the value at this point
+	// in the path, if known.
+	goType reflect.Type
+}
+
+// valuePath returns the string form of the given path
+// rooted at the given type.
+func valuePath(root *Type, path []valuePathElem) string {
+	var buf strings.Builder
+	if root != nil {
+		buf.WriteString(root.Name())
+	}
+	for _, elem := range path {
+		switch elem.kind {
+		case pathField:
+			buf.WriteString(".")
+			buf.WriteString(elem.name)
+		case pathIndex:
+			buf.WriteString("[")
+			buf.WriteString(strconv.Itoa(elem.index))
+			buf.WriteString("]")
+		case pathKey:
+			buf.WriteString("[")
+			buf.WriteString(strconv.Quote(elem.name))
+			buf.WriteString("]")
+		}
+	}
+	return buf.String()
+}
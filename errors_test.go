@@ -0,0 +1,143 @@
+package avro_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	gouuid "github.com/google/uuid"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
+)
+
+func TestDecodeErrorPath(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		Items []int
+		Attrs map[string]string
+	}
+	data, wType, err := avro.Marshal(R{
+		Items: []int{1, 2, 3},
+		Attrs: map[string]string{
+			"x": "not-a-uuid",
+		},
+	})
+	c.Assert(err, qt.IsNil)
+
+	type R1 struct {
+		Items []int
+		Attrs map[string]gouuid.UUID
+	}
+	var x R1
+	_, err = new(avro.Names).Rename("R", "R1").Unmarshal(data, &x, wType)
+	c.Assert(err, qt.ErrorMatches, `R1.Attrs\["x"\] \(offset 19\): invalid UUID in Avro encoding: invalid UUID length: 10`)
+	var decodeErr *avro.DecodeError
+	c.Assert(errors.As(err, &decodeErr), qt.IsTrue)
+	c.Assert(decodeErr.Path, qt.Equals, `R1.Attrs["x"]`)
+	c.Assert(decodeErr.GoType, qt.Equals, reflect.TypeOf(gouuid.UUID{}))
+	c.Assert(decodeErr.WriterType, qt.Equals, wType)
+	c.Assert(decodeErr.ReaderType.Name(), qt.Equals, "R1")
+	c.Assert(decodeErr.Offset, qt.Equals, int64(19))
+
+	// Truncate the data in the middle of the array.
+	var x1 R
+	_, err = avro.Unmarshal(data[:3], &x1, wType)
+	c.Assert(err, qt.ErrorMatches, `R.Items\[2\] \(offset 3\): unexpected EOF`)
+	c.Assert(errors.As(err, &decodeErr), qt.IsTrue)
+	c.Assert(decodeErr.GoType, qt.Equals, reflect.TypeOf(0))
+	c.Assert(errors.Is(err, io.ErrUnexpectedEOF), qt.IsTrue)
+}
+
+func TestDecodeErrorNestedRecord(t *testing.T) {
+	c := qt.New(t)
+	data, wType, err := avro.Marshal(limitsRecord{
+		P: &limitsRecord{
+			S: "hello",
+		},
+	})
+	c.Assert(err, qt.IsNil)
+	var x limitsRecord
+	_, err = avro.Unmarshal(data[:len(data)-8], &x, wType)
+	c.Assert(err, qt.ErrorMatches, `limitsRecord.P.S \(offset 6\): unexpected EOF`)
+	var decodeErr *avro.DecodeError
+	c.Assert(errors.As(err, &decodeErr), qt.IsTrue)
+	c.Assert(decodeErr.GoType, qt.Equals, reflect.TypeOf(""))
+}
+
+type errorsRecord struct {
+	Items []errorsItem
+	Attrs map[string]errorsItem
+}
+
+type errorsItem struct {
+	V interface{}
+}
+
+func (errorsItem) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"V","type":["long","string"]}],"name":"errorsItem","type":"record"}`,
+		Required: []bool{
+			0: true,
+		},
+		Unions: []avrotypegen.UnionInfo{
+			0: {
+				Type: new(interface{}),
+				Union: []avrotypegen.UnionInfo{{
+					Type: new(int64),
+				}, {
+					Type: new(string),
+				}},
+			},
+		},
+	}
+}
+
+func TestEncodeErrorPath(t *testing.T) {
+	c := qt.New(t)
+	_, _, err := avro.Marshal(errorsRecord{
+		Items: []errorsItem{{
+			V: int64(1),
+		}, {
+			V: 1.5,
+		}},
+	})
+	c.Assert(err, qt.ErrorMatches, `errorsRecord.Items\[1\].V: unknown type for union float64`)
+	var encodeErr *avro.EncodeError
+	c.Assert(errors.As(err, &encodeErr), qt.IsTrue)
+	c.Assert(encodeErr.Path, qt.Equals, "errorsRecord.Items[1].V")
+	c.Assert(encodeErr.GoType, qt.Equals, reflect.TypeOf(new(interface{})).Elem())
+	c.Assert(encodeErr.Type.Name(), qt.Equals, "errorsRecord")
+
+	_, _, err = avro.Marshal(errorsRecord{
+		Attrs: map[string]errorsItem{
+			"a": {},
+		},
+	})
+	c.Assert(err, qt.ErrorMatches, `errorsRecord.Attrs\["a"\].V: nil value not allowed`)
+
+	// Errors that aren't associated with any particular
+	// value aren't decorated.
+	_, _, err = avro.Marshal(struct{}{})
+	c.Assert(err, qt.ErrorMatches, `cannot use unnamed type struct {} as Avro type`)
+	c.Assert(errors.As(err, &encodeErr), qt.IsTrue)
+	c.Assert(encodeErr.Path, qt.Equals, "")
+}
+
+func TestEncodeErrorDoesNotAffectLaterEncoding(t *testing.T) {
+	c := qt.New(t)
+	_, _, err := avro.Marshal(errorsRecord{
+		Items: []errorsItem{{}},
+	})
+	c.Assert(err, qt.ErrorMatches, `errorsRecord.Items\[0\].V: nil value not allowed`)
+	data, _, err := avro.Marshal(errorsRecord{
+		Items: []errorsItem{{
+			V: "x",
+		}},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(bytes.HasPrefix(data, []byte{2, 2, 2, 'x'}), qt.IsTrue)
+}
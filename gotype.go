@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/actgardner/gogen-avro/v10/schema"
@@ -51,19 +52,52 @@ type errorSchema struct {
 //	- github.com/google/uuid.UUID encodes as {"type": "string", "logicalType": "string"}
 //	- [N]byte encodes as {"type": "fixed", "name": "go.FixedN", "size": N}
 //	- a named type with underlying type [N]byte encodes as [N]byte but typeName(T) for the name.
+//	- an integer type with a String method that returns a distinct valid Avro symbol
+//		for each value from zero up encodes as {"type": "enum", "name": typeName(T), "symbols": ...}.
+//		Names that aren't valid symbols are sanitized when Names.WithSanitizedEnumSymbols
+//		is used; otherwise the type encodes as an integer. If the type implements
+//		avrotypegen.AvroEnum, the symbols can be specified explicitly instead, and any
+//		default symbol is included so that symbols unknown to the type decode as that symbol.
 //	- []T encodes as {"type": "array", "items": TypeOf(T)}
-//	- map[string]T encodes as {"type": "map", "values": TypeOf(T)}
+//	- map[K]T encodes as {"type": "map", "values": TypeOf(T)}
+//		where K is a string or integer type or implements encoding.TextMarshaler
+//		and encoding.TextUnmarshaler; as with encoding/json, non-string keys
+//		are encoded as their textual representation.
 //	- *T encodes as ["null", TypeOf(T)]
 //	- a named struct type encodes as {"type": "record", "name": typeName(T), "fields": ...}
 //		where the fields are encoded as described below.
-//	- interface types are disallowed.
+//	- an interface type registered with Names.RegisterUnion encodes as
+//		a union of its member types; other interface types are disallowed.
+//	- a type that implements AvroRepresenter encodes as its representation
+//		type, or using the schema it specifies if that's non-empty.
+//	- a type generated by avrogo for a schema without a top level name
+//		(for example a union) encodes as the schema it was generated from.
+//		Such a type can only be used at the top level.
 //
 // Struct fields are encoded as follows:
 //
 //	- unexported struct fields are ignored
-//	- the field name is taken from the Go field name, or from a "json" tag for the field if present.
-//	- the default value for the field is the zero value for the type.
-//	- anonymous struct fields are disallowed (this restriction may be lifted in the future).
+//	- the field name is taken from the Go field name, or from an "avro" or "json" tag
+//		for the field if present, with the avro tag taking precedence.
+//	- the default value for the field is the zero value for the type unless
+//		specified by a "default" option in the avro tag. The fields of a
+//		type generated by avrogo take the defaults from its schema.
+//	- the avro tag can also specify the field's documentation ("doc"), aliases ("aliases"),
+//		sort order ("order") and a logical type for fields of primitive type ("logical"),
+//		for example:
+//		`avro:"created,logical=timestamp-micros,doc='Creation time, in microseconds',aliases=ctime|c"`
+//		The logical type must be one whose values are represented directly by the
+//		primitive type: date or time-millis for int; time-micros, timestamp-millis,
+//		timestamp-micros, local-timestamp-millis, local-timestamp-micros or
+//		duration-nanos for long; uuid for string.
+//	- the fields of an embedded (anonymous) struct field are promoted
+//		following the rules used by encoding/json, so they're encoded as if they
+//		were fields of the outer struct. An embedded struct with a name
+//		in its "avro" or "json" tag is encoded as a single field with that name.
+//		When fields from different embedded structs have the same name,
+//		the least nested one is used; if there's more than one at the same
+//		depth, it's an error unless exactly one of them has its name in a tag.
+//		Embedding a type generated by avrogo is not allowed.
 func TypeOf(x interface{}) (*Type, error) {
 	return globalNames.TypeOf(x)
 }
@@ -93,12 +127,21 @@ func avroTypeOfUncached(names *Names, t reflect.Type) (*Type, error) {
 		names: names,
 		defs:  make(map[reflect.Type]goTypeDef),
 	}
-	// TODO pass in wType so that we can determine a schema
-	// even for partially specified Go types (e.g. interface{} values)
-	// See https://github.com/heetch/avro/issues/34
-	schemaVal, err := gts.schemaForGoType(t)
-	if err != nil {
+	var schemaVal interface{}
+	if w, err := avroWrapperOf(t); err != nil {
 		return nil, err
+	} else if w != nil {
+		// It's a generated wrapper type which holds a value
+		// of the type described by its schema.
+		schemaVal = json.RawMessage(w.AvroWrapper().Schema)
+	} else {
+		// TODO pass in wType so that we can determine a schema
+		// even for partially specified Go types (e.g. interface{} values)
+		// See https://github.com/heetch/avro/issues/34
+		schemaVal, err = gts.schemaForGoType(t)
+		if err != nil {
+			return nil, err
+		}
 	}
 	data, err := json.Marshal(schemaVal)
 	if err != nil {
@@ -148,17 +191,38 @@ func (gts *goTypeSchema) schemaForGoType(t reflect.Type) (interface{}, error) {
 	if t == nil {
 		return "null", nil
 	}
+	if repr, rt, ok, err := representationOf(t); ok {
+		if err != nil {
+			return nil, err
+		}
+		return gts.schemaForRepresentation(t, repr, rt)
+	}
+	if w, err := avroWrapperOf(t); w != nil || err != nil {
+		return nil, fmt.Errorf("wrapper type %s can only be used at the top level", t)
+	}
 	if r := avroRecordOf(t); r != nil {
 		// It's a generated type which comes with its own schema.
 		return gts.define(t, json.RawMessage(r.AvroRecord().Schema), "")
 	}
-	if syms := enumSymbols(t); len(syms) > 0 {
+	syms, err := typeinfo.EnumSymbols(t, gts.names.sanitizeEnumSymbols)
+	if err != nil {
+		return nil, err
+	}
+	if len(syms) > 0 {
 		// It looks like an enum.
-		// TODO should we include a default here?
-		return gts.define(t, map[string]interface{}{
+		def := map[string]interface{}{
 			"type":    "enum",
 			"symbols": syms,
-		}, "")
+		}
+		if e, ok := reflect.Zero(t).Interface().(avrotypegen.AvroEnum); ok {
+			if dflt := e.AvroEnum().Default; dflt != "" {
+				if !slices.Contains(syms, dflt) {
+					return nil, fmt.Errorf("default %q of enum %s is not one of its symbols", dflt, t)
+				}
+				def["default"] = dflt
+			}
+		}
+		return gts.define(t, def, "")
 	}
 	switch t.Kind() {
 	case reflect.Bool:
@@ -192,9 +256,13 @@ func (gts *goTypeSchema) schemaForGoType(t reflect.Type) (interface{}, error) {
 			"items": items,
 		}, nil
 	case reflect.Map:
-		// TODO support the same map keys types that JSON does.
-		if t.Key().Kind() != reflect.String {
-			return nil, fmt.Errorf("map must have string key")
+		// Like encoding/json, we allow integer keys and keys
+		// that implement encoding.TextMarshaler and
+		// encoding.TextUnmarshaler; they're encoded as strings.
+		// The key type must support both so that the map
+		// can be both encoded and decoded.
+		if !canEncodeMapKey(t.Key()) || !canDecodeMapKey(t.Key()) {
+			return nil, fmt.Errorf("map key type %s must be a string or integer type or implement encoding.TextMarshaler and encoding.TextUnmarshaler", t.Key())
 		}
 		values, err := gts.schemaForGoType(t.Elem())
 		if err != nil {
@@ -228,33 +296,59 @@ func (gts *goTypeSchema) schemaForGoType(t reflect.Type) (interface{}, error) {
 		// Note: don't start with nil fields because gogen-avro
 		// doesn't like the nil value.
 		fields := []interface{}{}
-		for i := 0; i < t.NumField(); i++ {
-			f := t.Field(i)
-			if f.Anonymous {
-				return nil, fmt.Errorf("anonymous fields not yet supported (in %s)", t)
-			}
+		goFields, err := typeinfo.Fields(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range goFields {
 			// Technically in Go, every field is optional because
 			// that's the way that the encoding/json package works,
-			// so we'll make them all optional.
-			// TODO  experiment by making optional only the fields that
-			// specify omitempty.
-			name, _ := typeinfo.JSONFieldName(f)
-			if name == "" {
-				continue
-			}
-			ftype, err := gts.schemaForGoType(f.Type)
+			// so we'll make them all optional by giving them a default.
+			// With Names.WithNullableFields, fields that specify omitempty
+			// and slice and map fields are also nullable.
+			tag, err := typeinfo.FieldTag(f)
 			if err != nil {
 				return nil, err
 			}
-			d, err := gts.defaultForType(f.Type)
+			ftype, err := gts.schemaForGoType(f.Type)
 			if err != nil {
 				return nil, err
 			}
-			fields = append(fields, map[string]interface{}{
-				"name":    name,
-				"default": d,
-				"type":    ftype,
-			})
+			if tag.Logical != "" {
+				ftype, err = withLogicalType(f.Type, ftype, tag.Logical)
+				if err != nil {
+					return nil, fmt.Errorf("field %s of %s: %v", f.Name, t, err)
+				}
+			}
+			field := map[string]interface{}{
+				"name": tag.Name,
+				"type": ftype,
+			}
+			if gts.names.typeinfoOptions().Nullable(f.Type, tag) {
+				field["type"] = []interface{}{"null", ftype}
+				field["default"] = nil
+			} else if tag.HasDefault {
+				if _, err := typeinfo.DefaultValue(f.Type, tag.Default, gts.names.typeinfoOptions()); err != nil {
+					return nil, fmt.Errorf("invalid default for field %s of %s: %v", f.Name, t, err)
+				}
+				field["default"] = tag.Default
+			} else {
+				d, err := gts.defaultForType(f.Type)
+				if err != nil {
+					return nil, err
+				}
+				field["default"] = d
+			}
+			if tag.Doc != "" {
+				field["doc"] = tag.Doc
+			}
+			if len(tag.Aliases) > 0 {
+				field["aliases"] = tag.Aliases
+			}
+			if tag.Order != "" {
+				field["order"] = tag.Order
+			}
+			fields = append(fields, field)
 		}
 		def["fields"] = fields
 		return def, nil
@@ -286,8 +380,24 @@ func (gts *goTypeSchema) schemaForGoType(t reflect.Type) (interface{}, error) {
 			elem,
 		}, nil
 	case reflect.Interface:
-		// TODO fill in from the writer schema.
-		return nil, fmt.Errorf("interface types (%s) not yet supported (use avrogo instead)", t)
+		u, ok := gts.names.unions[t]
+		if !ok {
+			// TODO fill in from the writer schema.
+			return nil, fmt.Errorf("interface types (%s) not yet supported (use avrogo or Names.RegisterUnion instead)", t)
+		}
+		members := make([]interface{}, len(u.Union))
+		for i, m := range u.Union {
+			var mt reflect.Type
+			if m.Type != nil {
+				mt = reflect.TypeOf(m.Type).Elem()
+			}
+			ms, err := gts.schemaForGoType(mt)
+			if err != nil {
+				return nil, err
+			}
+			members[i] = ms
+		}
+		return members, nil
 	default:
 		return nil, fmt.Errorf("cannot make Avro schema for Go type %s", t)
 	}
@@ -324,134 +434,21 @@ func (gts *goTypeSchema) define(t reflect.Type, def0 interface{}, defaultName st
 	return def, nil
 }
 
-const maxEnum = 250
-
-// enumSymbols returns the enum symbols represented by the given
-// type. If the type doesn't represent an enum it returns no symbols.
-func enumSymbols(t reflect.Type) []string {
-	k := t.Kind()
-	isSignedInt := reflect.Int <= k && k <= reflect.Int64
-	isUnsignedInt := reflect.Uint <= k && k <= reflect.Uint64
-	if !isSignedInt && !isUnsignedInt {
-		return nil
-	}
-	if _, ok := reflect.Zero(t).Interface().(fmt.Stringer); !ok {
-		return nil
-	}
-	v := reflect.New(t)
-	vs := v.Interface().(fmt.Stringer) // Note: pointer type will also include String method.
-	v = v.Elem()
-	setInt := v.SetInt
-	getIntVal := v.Int
-	if isUnsignedInt {
-		setInt = func(i int64) {
-			v.SetUint(uint64(i))
-		}
-		getIntVal = func() int64 {
-			return int64(v.Uint())
-		}
-	}
-	symOf := func(i int64) (sym string, actual int64, ok bool) {
-		defer func() {
-			// It panics when calling String, which is a decent indication
-			// that it's out of bounds.
-			if recover() != nil {
-				ok = false
-			}
-		}()
-		setInt(i)
-		return vs.String(), getIntVal(), true
-	}
-	// Assume that -1 is out-of-bounds and see what
-	// we get when we call String on it.
-	sym, actual, ok := symOf(-1)
-	const (
-		oobEmpty = iota
-		oobParen
-		oobNumber
-		oobPanic
-	)
-	var oobStyle int
-	// Note: the String implementation created by the stringer tool
-	// returns "T(x)" for an out-of-bounds number x of type T
-	// so we use a bracket as an indicator of "out of bounds".
-	switch {
-	case !ok:
-		oobStyle = oobPanic
-	case sym == "":
-		oobStyle = oobEmpty
-	case strings.Contains(sym, "("):
-		oobStyle = oobParen
-	case sym == fmt.Sprint(actual):
-		oobStyle = oobNumber
-	default:
-		// All our heuristics for detecting out-of-bounds values
-		// are exhausted.
-		return nil
-	}
-	prev := ""
-	var syms []string
-	for i := 0; i < maxEnum; i++ {
-		sym, actual, ok := symOf(int64(i))
-		if !ok || sym == "" {
-			// Panic or empty value are never acceptable.
-			return syms
-		}
-		switch oobStyle {
-		case oobParen:
-			if strings.Contains(sym, "(") {
-				return syms
-			}
-		case oobNumber:
-			if sym == fmt.Sprint(actual) {
-				return syms
-			}
-		}
-		if sym == prev {
-			// If it's the same as the previous value, it might be "unknown"
-			// or something, so treat both it and the previous value as
-			// out-of-bounds.
-			return syms[0 : len(syms)-1]
-		}
-		if !isValidEnumSymbol(sym) {
-			// TODO convert to a valid symbol somehow?
-			// https://github.com/heetch/avro/issues/36
-			return nil
-		}
-		syms = append(syms, sym)
-		prev = sym
-	}
-	// Too many values.
-	return nil
-}
-
-// From https://avro.apache.org/docs/1.9.1/spec.html#Enums :
-//
-//	Every symbol must match the regular expression [A-Za-z_][A-Za-z0-9_]*
-func isValidEnumSymbol(s string) bool {
-	if s == "" || s[0] != '_' && !isAlpha(s[0]) {
-		return false
-	}
-	for i := 1; i < len(s); i++ {
-		if c := s[i]; c != '_' && !isAlpha(c) && !isDigit(c) {
-			return false
+func (gts *goTypeSchema) defaultForType(t reflect.Type) (interface{}, error) {
+	if _, rt, ok, err := representationOf(t); ok {
+		if err != nil {
+			return nil, err
 		}
+		// The default is the zero value of the representation type.
+		return gts.defaultForType(rt)
 	}
-	return true
-}
-
-func isAlpha(c byte) bool {
-	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z'
-}
-
-func isDigit(c byte) bool {
-	return '0' <= c && c <= '9'
-}
-
-func (gts *goTypeSchema) defaultForType(t reflect.Type) (interface{}, error) {
-	// TODO perhaps a Go slice/map should accept a union
-	// of null and array/map? See https://github.com/heetch/avro/issues/19
 	switch t.Kind() {
+	case reflect.Interface:
+		// The default for a union is the default of its first member.
+		if u, ok := gts.names.unions[t]; ok && u.Union[0].Type != nil {
+			return gts.defaultForType(reflect.TypeOf(u.Union[0].Type).Elem())
+		}
+		return nil, nil
 	case reflect.Slice:
 		return reflect.MakeSlice(t, 0, 0).Interface(), nil
 	case reflect.Map:
@@ -468,42 +465,169 @@ func (gts *goTypeSchema) defaultForType(t reflect.Type) (interface{}, error) {
 		case nullType:
 			return nil, nil
 		}
-		if avroRecordOf(t) != nil {
-			// It's a generated type - producing a correctly formed default value
-			// for it needs a bit more work so we punt on doing it for now.
-			// TODO make default values for struct-typed fields work in all cases.
-			return nil, fmt.Errorf("value fields of struct types generated by avrogo are not yet supported (type %s)", t)
+		if r := avroRecordOf(t); r != nil {
+			// It's a generated type, so derive the default
+			// from the schema it was generated from.
+			at, err := ParseType(r.AvroRecord().Schema)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse schema for %s: %v", t, err)
+			}
+			return zeroDefault(at.avroType), nil
+		}
+		goFields, err := typeinfo.Fields(t)
+		if err != nil {
+			return nil, err
 		}
 		fields := make(map[string]interface{})
-		for i := 0; i < t.NumField(); i++ {
-			f := t.Field(i)
-			if f.Anonymous {
-				return nil, fmt.Errorf("anonymous fields not yet supported (in %s)", t)
+		for _, f := range goFields {
+			tag, err := typeinfo.FieldTag(f)
+			if err != nil {
+				return nil, err
+			}
+			if gts.names.typeinfoOptions().Nullable(f.Type, tag) {
+				fields[tag.Name] = nil
+				continue
 			}
-			name, _ := typeinfo.JSONFieldName(f)
-			if name == "" {
+			if tag.HasDefault {
+				fields[tag.Name] = tag.Default
 				continue
 			}
 			v, err := gts.defaultForType(f.Type)
 			if err != nil {
 				return nil, err
 			}
-			fields[name] = v
+			fields[tag.Name] = v
 		}
 		return fields, nil
 	default:
 		if def, ok := gts.defs[t]; ok {
 			if o, ok := def.schema.(map[string]interface{}); ok && o["type"] == "enum" {
-				return reflect.Zero(t).Interface().(fmt.Stringer).String(), nil
+				return o["symbols"].([]string)[0], nil
 			}
 		}
 		return reflect.Zero(t).Interface(), nil
 	}
 }
 
+// zeroDefault returns the default value for the Avro type at
+// that corresponds to the zero value of a Go type generated
+// for it by avrogo, except that record fields take the defaults
+// specified in the schema, as they do when decoded.
+func zeroDefault(at schema.AvroType) interface{} {
+	switch at := at.(type) {
+	case *schema.Reference:
+		switch def := at.Def.(type) {
+		case *schema.RecordDefinition:
+			fields := make(map[string]interface{})
+			for _, f := range def.Fields() {
+				if f.HasDefault() {
+					fields[f.Name()] = f.Default()
+				} else {
+					fields[f.Name()] = zeroDefault(f.Type())
+				}
+			}
+			return fields
+		case *schema.EnumDefinition:
+			return def.Symbols()[0]
+		case *schema.FixedDefinition:
+			return strings.Repeat("\u0000", def.SizeBytes())
+		}
+	case *schema.UnionField:
+		return zeroDefault(at.ItemTypes()[0])
+	case *schema.ArrayField:
+		return []interface{}{}
+	case *schema.MapField:
+		return map[string]interface{}{}
+	case *schema.BoolField:
+		return false
+	case *schema.IntField, *schema.LongField, *schema.FloatField, *schema.DoubleField:
+		return 0
+	case *schema.StringField, *schema.BytesField:
+		return ""
+	}
+	return nil
+}
+
+// withLogicalType returns the schema s for a field of type t
+// with the given logical type specified in its avro tag.
+// Logical types can be added to primitive types only;
+// types with their own logical type can't be changed.
+func withLogicalType(t reflect.Type, s interface{}, logical string) (interface{}, error) {
+	if u, ok := s.([]interface{}); ok && len(u) == 2 && u[0] == "null" {
+		// It's a pointer type, so add the logical type
+		// to the non-null member.
+		elem, err := withLogicalType(t.Elem(), u[1], logical)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"null", elem}, nil
+	}
+	if m, ok := s.(map[string]interface{}); ok {
+		if lt := m["logicalType"]; lt == logical {
+			return s, nil
+		}
+		if logical == timestampMillis && t == timeType {
+			// TODO support timestamp-millis for time.Time.
+			// See https://github.com/heetch/avro/issues/3
+			return nil, fmt.Errorf("logical type %q not supported for %s", logical, t)
+		}
+		return nil, fmt.Errorf("cannot use logical type %q for %s", logical, t)
+	}
+	if p, ok := s.(string); ok {
+		for _, lt := range tagLogicalTypes[p] {
+			if lt == logical {
+				return map[string]interface{}{
+					"type":        p,
+					"logicalType": logical,
+				}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("cannot use logical type %q for %s", logical, t)
+}
+
+// tagLogicalTypes holds the logical types that can be specified in
+// an avro tag, keyed by the primitive type they apply to. These
+// are the logical types that are represented directly by values
+// of the primitive type, so the field's value is encoded unchanged.
+// Others, such as decimal, need attributes that can't be specified
+// in a tag.
+var tagLogicalTypes = map[string][]string{
+	"int": {
+		"date",
+		"time-millis",
+	},
+	"long": {
+		"time-micros",
+		timestampMillis,
+		timestampMicros,
+		"local-timestamp-millis",
+		"local-timestamp-micros",
+		durationNanos,
+	},
+	"string": {
+		uuid,
+	},
+}
+
 func avroRecordOf(t reflect.Type) avrotypegen.AvroRecord {
-	r, _ := reflect.Zero(t).Interface().(avrotypegen.AvroRecord)
-	return r
+	return typeinfo.AvroRecordOf(t)
+}
+
+// avroWrapperOf returns the AvroWrapper implementation
+// of t, or nil if t doesn't implement AvroWrapper.
+func avroWrapperOf(t reflect.Type) (avrotypegen.AvroWrapper, error) {
+	if t == nil {
+		return nil, nil
+	}
+	w, ok := reflect.Zero(t).Interface().(avrotypegen.AvroWrapper)
+	if !ok {
+		return nil, nil
+	}
+	if t.Kind() != reflect.Struct || t.NumField() != 1 || t.Field(0).PkgPath != "" {
+		return nil, fmt.Errorf("wrapper type %s must be a struct with a single exported field", t)
+	}
+	return w, nil
 }
 
 var nullType = reflect.TypeOf(Null{})
@@ -2,6 +2,7 @@ package avro_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -10,6 +11,7 @@ import (
 	gouuid "github.com/google/uuid"
 
 	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
 	"github.com/heetch/avro/internal/testtypes"
 )
 
@@ -356,13 +358,565 @@ func TestGoTypeWithStructField(t *testing.T) {
 }`))
 }
 
+type Metadata struct {
+	ID   string
+	Time int
+}
+
+type Audit struct {
+	CreatedBy string `json:"createdBy"`
+}
+
+type embeddedUnexported struct {
+	Region string
+}
+
+func TestGoTypeWithEmbeddedStruct(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		Metadata
+		*Audit
+		embeddedUnexported
+		Name string
+		// ID shadows Metadata.ID because it's less nested.
+		ID int
+	}
+	wType := mustTypeOf(R{})
+	c.Assert(wType.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "Time",
+			"type": "long",
+			"default": 0
+		}, {
+			"name": "createdBy",
+			"type": "string",
+			"default": ""
+		}, {
+			"name": "Region",
+			"type": "string",
+			"default": ""
+		}, {
+			"name": "Name",
+			"type": "string",
+			"default": ""
+		}, {
+			"name": "ID",
+			"type": "long",
+			"default": 0
+		}]
+	}`))
+	r := R{
+		Metadata: Metadata{
+			ID:   "ignored",
+			Time: 1234,
+		},
+		Audit: &Audit{
+			CreatedBy: "bob",
+		},
+		embeddedUnexported: embeddedUnexported{
+			Region: "eu",
+		},
+		Name: "x",
+		ID:   99,
+	}
+	data, _, err := avro.Marshal(r)
+	c.Assert(err, qt.IsNil)
+	var r1 R
+	_, err = avro.Unmarshal(data, &r1, wType)
+	c.Assert(err, qt.IsNil)
+	r.Metadata.ID = ""
+	c.Assert(*r1.Audit, qt.Equals, *r.Audit)
+	r1.Audit = r.Audit
+	c.Assert(r1, qt.Equals, r)
+
+	// A nil embedded pointer encodes as the zero value
+	// of its fields.
+	r.Audit = nil
+	data, _, err = avro.Marshal(r)
+	c.Assert(err, qt.IsNil)
+	r1 = R{}
+	_, err = avro.Unmarshal(data, &r1, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(*r1.Audit, qt.Equals, Audit{})
+	r1.Audit = nil
+	c.Assert(r1, qt.Equals, r)
+}
+
+func TestGoTypeWithEmbeddedStructDefault(t *testing.T) {
+	c := qt.New(t)
+	// When the writer schema doesn't have a promoted field,
+	// it's set to its default value.
+	type W struct {
+		Name string
+	}
+	type R struct {
+		Name string
+		*Audit
+	}
+	data, wType, err := avro.Marshal(W{Name: "x"})
+	c.Assert(err, qt.IsNil)
+	r := R{
+		Audit: &Audit{CreatedBy: "alice"},
+	}
+	_, err = avro.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r, qt.DeepEquals, R{
+		Name:  "x",
+		Audit: &Audit{},
+	})
+}
+
+func TestGoTypeWithNamedEmbeddedStruct(t *testing.T) {
+	c := qt.New(t)
+	// An embedded struct with a name in its json tag
+	// is treated as an ordinary field.
+	type R struct {
+		Metadata `json:"meta"`
+	}
+	c.Assert(mustTypeOf(R{}).String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "meta",
+			"type": {
+				"type": "record",
+				"name": "Metadata",
+				"fields": [{
+					"name": "ID",
+					"type": "string",
+					"default": ""
+				}, {
+					"name": "Time",
+					"type": "long",
+					"default": 0
+				}]
+			},
+			"default": {"ID": "", "Time": 0}
+		}]
+	}`))
+}
+
+type embeddedA struct {
+	X int
+}
+
+type embeddedB struct {
+	X int
+}
+
+type embeddedC struct {
+	X int `json:"X"`
+}
+
+func TestGoTypeWithEmbeddedStructConflict(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		embeddedA
+		embeddedB
+	}
+	_, err := avro.TypeOf(R{})
+	c.Assert(err, qt.ErrorMatches, `ambiguous field name "X" in avro_test.R \(embeddedA.X and embeddedB.X\)`)
+
+	// A tagged field takes precedence.
+	type R1 struct {
+		embeddedA
+		embeddedC
+	}
+	data, wType, err := avro.Marshal(R1{
+		embeddedA: embeddedA{X: 1},
+		embeddedC: embeddedC{X: 2},
+	})
+	c.Assert(err, qt.IsNil)
+	var r R1
+	_, err = avro.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r, qt.Equals, R1{
+		embeddedC: embeddedC{X: 2},
+	})
+}
+
+func TestGoTypeWithEmbeddedGeneratedStruct(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		TestRecord
+	}
+	_, err := avro.TypeOf(R{})
+	c.Assert(err, qt.ErrorMatches, `cannot embed avro_test.TestRecord in avro_test.R because it was generated by avrogo \(use a named field instead\)`)
+}
+
 func TestGoTypeWithGeneratedGoStruct(t *testing.T) {
 	c := qt.New(t)
 	type R struct {
 		F TestRecord
 	}
+	// The default is derived from the generated schema,
+	// so A takes its default value.
+	c.Assert(mustTypeOf(R{}).String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "F",
+			"default": {"A": 42, "B": 0},
+			"type": {
+				"type": "record",
+				"name": "TestRecord",
+				"fields": [{
+					"name": "A",
+					"default": 42,
+					"type": {"type": "int"}
+				}, {
+					"name": "B",
+					"type": {"type": "int"}
+				}]
+			}
+		}]
+	}`))
+
+	// When the writer schema doesn't have the field,
+	// it's decoded as the default value.
+	type W struct{}
+	data, wType, err := avro.Marshal(W{})
+	c.Assert(err, qt.IsNil)
+	var r R
+	_, err = avro.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r, qt.DeepEquals, R{
+		F: TestRecord{A: 42},
+	})
+
+	// The round trip works too.
+	data, wType, err = avro.Marshal(R{F: TestRecord{A: 1, B: 2}})
+	c.Assert(err, qt.IsNil)
+	r = R{}
+	_, err = avro.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r, qt.DeepEquals, R{
+		F: TestRecord{A: 1, B: 2},
+	})
+}
+
+func TestGoTypeWithGeneratedGoStructAvroTagDefault(t *testing.T) {
+	c := qt.New(t)
+	// An explicit default can override some fields
+	// of a generated type; the others take their
+	// defaults from the schema.
+	type R struct {
+		F TestRecord `avro:",default={\"B\":7}"`
+	}
+	type W struct{}
+	data, wType, err := avro.Marshal(W{})
+	c.Assert(err, qt.IsNil)
+	var r R
+	_, err = avro.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r, qt.DeepEquals, R{
+		F: TestRecord{A: 42, B: 7},
+	})
+}
+
+func TestGoTypeWithAvroTags(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A       int               `json:"a" avro:"id,doc='The identifier, which is unique',aliases=ident|key,order=descending"`
+		B       string            `json:"b" avro:",default=hello"`
+		C       []int             `avro:"c,default=[1,2,3]"`
+		Created int64             `avro:"created,logical=timestamp-millis"`
+		E       testtypes.Enum    `avro:",default=Two"`
+		M       map[string]string `avro:",default={\"x\":\"y\"}"`
+		P       *string           `avro:",logical=uuid"`
+		Ignored int               `json:"ignored" avro:"-"`
+		J       int               `json:"-" avro:"j"`
+		N       string            `avro:"n,doc=The user's name"`
+	}
+	c.Assert(mustTypeOf(R{}).String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "id",
+			"doc": "The identifier, which is unique",
+			"aliases": ["ident", "key"],
+			"order": "descending",
+			"default": 0,
+			"type": "long"
+		}, {
+			"name": "b",
+			"default": "hello",
+			"type": "string"
+		}, {
+			"name": "c",
+			"default": [1, 2, 3],
+			"type": {
+				"type": "array",
+				"items": "long"
+			}
+		}, {
+			"name": "created",
+			"default": 0,
+			"type": {
+				"type": "long",
+				"logicalType": "timestamp-millis"
+			}
+		}, {
+			"name": "E",
+			"default": "Two",
+			"type": {
+				"type": "enum",
+				"name": "Enum",
+				"symbols": ["One", "Two", "Three"]
+			}
+		}, {
+			"name": "M",
+			"default": {"x": "y"},
+			"type": {
+				"type": "map",
+				"values": "string"
+			}
+		}, {
+			"name": "P",
+			"default": null,
+			"type": ["null", {
+				"type": "string",
+				"logicalType": "uuid"
+			}]
+		}, {
+			"name": "j",
+			"default": 0,
+			"type": "long"
+		}, {
+			"name": "n",
+			"doc": "The user's name",
+			"default": "",
+			"type": "string"
+		}]
+	}`))
+}
+
+func TestGoTypeWithAvroTagDefaults(t *testing.T) {
+	c := qt.New(t)
+	// Fields missing from the writer schema are
+	// set to the defaults specified in the avro tags,
+	// including those inside struct-typed fields.
+	type Inner struct {
+		X string `avro:",default=inner"`
+	}
+	type W struct {
+		Ident int
+	}
+	type R struct {
+		ID    int               `avro:",aliases=Ident"`
+		B     string            `avro:",default=hello"`
+		C     []int             `avro:",default=[1,2,3]"`
+		E     testtypes.Enum    `avro:",default=Three"`
+		M     map[string]string `avro:",default={\"x\":\"y\"}"`
+		Bytes []byte            `avro:",default=\"\\u00ff\\u0001\""`
+		In    Inner
+	}
+	data, wType, err := avro.Marshal(W{Ident: 99})
+	c.Assert(err, qt.IsNil)
+	var r R
+	_, err = avro.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r, qt.DeepEquals, R{
+		ID:    99,
+		B:     "hello",
+		C:     []int{1, 2, 3},
+		E:     testtypes.EnumThree,
+		M:     map[string]string{"x": "y"},
+		Bytes: []byte{0xff, 1},
+		In:    Inner{X: "inner"},
+	})
+}
+
+func TestGoTypeAvroTagErrors(t *testing.T) {
+	c := qt.New(t)
+	type UnknownOption struct {
+		A int `avro:",foo=bar"`
+	}
+	type InvalidOrder struct {
+		A int `avro:",order=sideways"`
+	}
+	type UnterminatedQuote struct {
+		A int `avro:",doc='x"`
+	}
+	type BadDefault struct {
+		A int `avro:",default=hello"`
+	}
+	type BadEnumDefault struct {
+		E testtypes.Enum `avro:",default=Four"`
+	}
+	type NonNullPointerDefault struct {
+		P *int `avro:",default=1"`
+	}
+	type LogicalTypeOnRecord struct {
+		M Metadata `avro:",logical=date"`
+	}
+	type TimestampMillisOnTime struct {
+		T time.Time `avro:",logical=timestamp-millis"`
+	}
+	type DecimalOnBytes struct {
+		B []byte `avro:",logical=decimal"`
+	}
+	type UUIDOnLong struct {
+		A int64 `avro:",logical=uuid"`
+	}
+	tests := []struct {
+		val         interface{}
+		expectError string
+	}{{
+		val:         UnknownOption{},
+		expectError: `invalid avro tag on field A of avro_test.UnknownOption: unknown option "foo"`,
+	}, {
+		val:         InvalidOrder{},
+		expectError: `invalid avro tag on field A of avro_test.InvalidOrder: invalid order "sideways"`,
+	}, {
+		val:         UnterminatedQuote{},
+		expectError: `invalid avro tag on field A of avro_test.UnterminatedQuote: unterminated quote in ",doc='x"`,
+	}, {
+		val:         BadDefault{},
+		expectError: `invalid default for field A of avro_test.BadDefault: invalid default "hello" for int`,
+	}, {
+		val:         BadEnumDefault{},
+		expectError: `invalid default for field E of avro_test.BadEnumDefault: invalid default "Four" for testtypes.Enum`,
+	}, {
+		val:         NonNullPointerDefault{},
+		expectError: `invalid default for field P of avro_test.NonNullPointerDefault: cannot use non-null default for \*int`,
+	}, {
+		val:         LogicalTypeOnRecord{},
+		expectError: `field M of avro_test.LogicalTypeOnRecord: cannot use logical type "date" for avro_test.Metadata`,
+	}, {
+		val:         TimestampMillisOnTime{},
+		expectError: `field T of avro_test.TimestampMillisOnTime: logical type "timestamp-millis" not supported for time.Time`,
+	}, {
+		val:         DecimalOnBytes{},
+		expectError: `field B of avro_test.DecimalOnBytes: cannot use logical type "decimal" for \[\]uint8`,
+	}, {
+		val:         UUIDOnLong{},
+		expectError: `field A of avro_test.UUIDOnLong: cannot use logical type "uuid" for int64`,
+	}}
+	for _, test := range tests {
+		c.Run(fmt.Sprintf("%T", test.val), func(c *qt.C) {
+			_, err := avro.TypeOf(test.val)
+			c.Assert(err, qt.ErrorMatches, test.expectError)
+		})
+	}
+}
+
+// pointKey is used to test map keys that implement
+// encoding.TextMarshaler and encoding.TextUnmarshaler.
+type pointKey struct {
+	X, Y int
+}
+
+func (p pointKey) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", p.X, p.Y)), nil
+}
+
+func (p *pointKey) UnmarshalText(data []byte) error {
+	if _, err := fmt.Sscanf(string(data), "%d,%d", &p.X, &p.Y); err != nil {
+		return fmt.Errorf("invalid point %q", data)
+	}
+	return nil
+}
+
+// marshalOnlyKey implements encoding.TextMarshaler
+// but not encoding.TextUnmarshaler.
+type marshalOnlyKey struct {
+	X int
+}
+
+func (k marshalOnlyKey) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprint(k.X)), nil
+}
+
+type namedString string
+
+func TestGoTypeWithNonStringMapKeys(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		Ints   map[int]string
+		Uints  map[uint8]bool
+		Points map[pointKey]int
+		Named  map[namedString]int
+	}
+	c.Assert(mustTypeOf(R{}).String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "Ints",
+			"default": {},
+			"type": {"type": "map", "values": "string"}
+		}, {
+			"name": "Uints",
+			"default": {},
+			"type": {"type": "map", "values": "boolean"}
+		}, {
+			"name": "Points",
+			"default": {},
+			"type": {"type": "map", "values": "long"}
+		}, {
+			"name": "Named",
+			"default": {},
+			"type": {"type": "map", "values": "long"}
+		}]
+	}`))
+	x := R{
+		Ints:   map[int]string{-1: "a", 20: "b"},
+		Uints:  map[uint8]bool{255: true},
+		Points: map[pointKey]int{{1, 2}: 3},
+		Named:  map[namedString]int{"n": 1},
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.IsNil)
+	var y R
+	_, err = avro.Unmarshal(data, &y, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(y, qt.DeepEquals, x)
+
+	// The keys are encoded as strings, so they can
+	// be read into a map with string keys.
+	type S struct {
+		Ints   map[string]string
+		Points map[string]int
+	}
+	var z S
+	_, err = avro.Unmarshal(data, &z, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(z, qt.DeepEquals, S{
+		Ints:   map[string]string{"-1": "a", "20": "b"},
+		Points: map[string]int{"1,2": 3},
+	})
+}
+
+func TestGoTypeWithInvalidMapKey(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		M map[float64]int
+	}
 	_, err := avro.TypeOf(R{})
-	c.Assert(err, qt.ErrorMatches, `value fields of struct types generated by avrogo are not yet supported \(type avro_test.TestRecord\)`)
+	c.Assert(err, qt.ErrorMatches, `map key type float64 must be a string or integer type or implement encoding.TextMarshaler and encoding.TextUnmarshaler`)
+
+	// A key type that can only be marshaled isn't allowed either.
+	type MarshalOnly struct {
+		M map[marshalOnlyKey]int
+	}
+	_, err = avro.TypeOf(MarshalOnly{})
+	c.Assert(err, qt.ErrorMatches, `map key type avro_test.marshalOnlyKey must be a string or integer type or implement encoding.TextMarshaler and encoding.TextUnmarshaler`)
+
+	type W struct {
+		Ints map[string]string
+	}
+	type IntsR struct {
+		Ints map[int8]string
+	}
+	data, wType, err := avro.Marshal(W{
+		Ints: map[string]string{"300": "x"},
+	})
+	c.Assert(err, qt.IsNil)
+	var r IntsR
+	_, err = avro.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.ErrorMatches, `IntsR.Ints\["300"\] \(offset 5\): invalid map key "300" for int8`)
 }
 
 func TestGoTypeStringerEnum(t *testing.T) {
@@ -431,6 +985,158 @@ func TestGoTypeEnumOOBEmpty(t *testing.T) {
 	}`))
 }
 
+func TestGoTypeEnumWithDefault(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		E DefaultEnum
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "E",
+			"default": "Unknown",
+			"type": {
+				"type": "enum",
+				"name": "DefaultEnum",
+				"symbols": ["Unknown", "a", "b"],
+				"default": "Unknown"
+			}
+		}]
+	}`))
+
+	// A symbol added by the writer decodes as the default.
+	wType, err := avro.ParseType(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "E",
+			"type": {
+				"type": "enum",
+				"name": "DefaultEnum",
+				"symbols": ["a", "b", "c"]
+			}
+		}]
+	}`)
+	c.Assert(err, qt.Equals, nil)
+	for i, want := range []DefaultEnum{1, 2, 0} {
+		var r R
+		_, err = avro.Unmarshal([]byte{byte(i * 2)}, &r, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(r.E, qt.Equals, want)
+	}
+}
+
+func TestGoTypeEnumWithInvalidDefault(t *testing.T) {
+	c := qt.New(t)
+	_, err := avro.TypeOf(BadDefaultEnum(0))
+	c.Assert(err, qt.ErrorMatches, `default "c" of enum avro_test.BadDefaultEnum is not one of its symbols`)
+}
+
+func TestGoTypeEnumWithInvalidSymbols(t *testing.T) {
+	c := qt.New(t)
+	// Without WithSanitizedEnumSymbols, names that aren't
+	// valid symbols mean that the type isn't an enum.
+	type R struct {
+		E StatusEnum
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "E",
+			"default": 0,
+			"type": "long"
+		}]
+	}`))
+}
+
+func TestGoTypeEnumWithSanitizedSymbols(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		E StatusEnum
+		F StatusEnum `avro:",default=in_progress"`
+	}
+	names := new(avro.Names).WithSanitizedEnumSymbols()
+	at, err := names.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "E",
+			"default": "not_started",
+			"type": {
+				"type": "enum",
+				"name": "StatusEnum",
+				"symbols": ["not_started", "in_progress", "_2nd_attempt", "done"]
+			}
+		}, {
+			"name": "F",
+			"default": "in_progress",
+			"type": "StatusEnum"
+		}]
+	}`))
+	data, wType, err := names.Marshal(R{
+		E: 2,
+		F: 3,
+	})
+	c.Assert(err, qt.Equals, nil)
+	var r R
+	_, err = names.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(r, qt.Equals, R{
+		E: 2,
+		F: 3,
+	})
+}
+
+func TestGoTypeEnumWithClashingSanitizedSymbols(t *testing.T) {
+	c := qt.New(t)
+	// Names that sanitize to the same symbol mean
+	// that the type is encoded as an integer.
+	names := new(avro.Names).WithSanitizedEnumSymbols()
+	for _, x := range []interface{}{ClashingEnum(0), OpEnum(0)} {
+		for _, names := range []*avro.Names{new(avro.Names), names} {
+			at, err := names.TypeOf(x)
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(at.String(), qt.Equals, `"long"`)
+		}
+	}
+}
+
+func TestGoTypeEnumWithExplicitSymbols(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.TypeOf(SymbolsEnum(0))
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "enum",
+		"name": "SymbolsEnum",
+		"symbols": ["RED", "GREEN", "BLUE"],
+		"default": "RED"
+	}`))
+	wType, err := avro.ParseType(`{
+		"type": "enum",
+		"name": "SymbolsEnum",
+		"symbols": ["BLUE", "GREEN"]
+	}`)
+	c.Assert(err, qt.Equals, nil)
+	var x SymbolsEnum
+	_, err = avro.Unmarshal([]byte{0}, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, SymbolsEnum(2))
+}
+
+func TestGoTypeEnumSymbolErrors(t *testing.T) {
+	c := qt.New(t)
+	_, err := avro.TypeOf(BadSymbolsEnum(0))
+	c.Assert(err, qt.ErrorMatches, `enum avro_test.BadSymbolsEnum has invalid symbol "in-progress"`)
+}
+
 func TestProtobufGeneratedType(t *testing.T) {
 	c := qt.New(t)
 	at, err := avro.TypeOf(testtypes.MessageB{})
@@ -523,3 +1229,95 @@ func (e OOBEmptyEnum) String() string {
 	}
 	return enumValues[e]
 }
+
+// DefaultEnum is an enum with an Unknown zero value
+// that's used for symbols it doesn't know about.
+type DefaultEnum int
+
+var defaultEnumValues = []string{"Unknown", "a", "b"}
+
+func (e DefaultEnum) String() string {
+	if e < 0 || int(e) >= len(defaultEnumValues) {
+		return ""
+	}
+	return defaultEnumValues[e]
+}
+
+func (DefaultEnum) AvroEnum() avrotypegen.EnumInfo {
+	return avrotypegen.EnumInfo{
+		Default: "Unknown",
+	}
+}
+
+type BadDefaultEnum int
+
+func (e BadDefaultEnum) String() string {
+	if e < 0 || int(e) >= len(enumValues) {
+		return ""
+	}
+	return enumValues[e]
+}
+
+func (BadDefaultEnum) AvroEnum() avrotypegen.EnumInfo {
+	return avrotypegen.EnumInfo{
+		Default: "c",
+	}
+}
+
+// StatusEnum is an enum with names that
+// aren't valid Avro symbols.
+type StatusEnum int
+
+var statusEnumValues = []string{"not started", "in-progress", "2nd attempt", "done"}
+
+func (e StatusEnum) String() string {
+	if e < 0 || int(e) >= len(statusEnumValues) {
+		return ""
+	}
+	return statusEnumValues[e]
+}
+
+// SymbolsEnum is an enum that specifies its symbols explicitly.
+type SymbolsEnum int
+
+func (SymbolsEnum) AvroEnum() avrotypegen.EnumInfo {
+	return avrotypegen.EnumInfo{
+		Symbols: []string{"RED", "GREEN", "BLUE"},
+		Default: "RED",
+	}
+}
+
+type ClashingEnum int
+
+var clashingEnumValues = []string{"a-b", "a b"}
+
+func (e ClashingEnum) String() string {
+	if e < 0 || int(e) >= len(clashingEnumValues) {
+		return ""
+	}
+	return clashingEnumValues[e]
+}
+
+type BadSymbolsEnum int
+
+func (BadSymbolsEnum) AvroEnum() avrotypegen.EnumInfo {
+	return avrotypegen.EnumInfo{
+		Symbols: []string{"in-progress"},
+	}
+}
+
+// OpEnum has String names that are
+// all sanitized to the same symbol.
+type OpEnum int
+
+func (op OpEnum) String() string {
+	switch op {
+	case 0:
+		return "+"
+	case 1:
+		return "-"
+	case 2:
+		return "*"
+	}
+	return fmt.Sprintf("OpEnum(%d)", int(op))
+}
@@ -0,0 +1,254 @@
+// Package avdl reads Avro protocols, either written in
+// Avro IDL (.avdl files) or in JSON (.avpr files).
+//
+// See https://avro.apache.org/docs/current/idl-language/
+// for a description of the IDL language.
+package avdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Protocol holds an Avro protocol.
+type Protocol struct {
+	// Name holds the name of the protocol.
+	Name string
+
+	// Namespace holds the namespace of the protocol.
+	// Types that don't specify a namespace are
+	// in this namespace.
+	Namespace string
+
+	// Doc holds the protocol's documentation.
+	Doc string
+
+	// Types holds all the types defined in the protocol,
+	// including those from imported files, in the order
+	// that they're defined.
+	Types []Type
+
+	// Messages holds the messages defined by the protocol.
+	Messages []Message
+
+	// props holds any extra properties of the protocol.
+	props object
+}
+
+// Type holds a type defined in a protocol.
+type Type struct {
+	// File holds the name of the file that the type
+	// was defined in. When the type was imported,
+	// this will be the name of the imported file.
+	File string
+
+	// Schema holds the JSON Avro schema of the type. References
+	// to other types in the protocol are by name. If the
+	// protocol has a namespace, the schema always specifies
+	// its namespace explicitly, so it can be used
+	// independently of the protocol.
+	Schema json.RawMessage
+}
+
+// Message holds a message defined in a protocol.
+type Message struct {
+	// Name holds the name of the message.
+	Name string
+
+	// Definition holds the JSON definition of the message.
+	Definition json.RawMessage
+}
+
+// MarshalJSON implements json.Marshaler by
+// returning the protocol in .avpr form.
+func (p *Protocol) MarshalJSON() ([]byte, error) {
+	var o object
+	o = o.add("protocol", p.Name)
+	if p.Namespace != "" {
+		o = o.add("namespace", p.Namespace)
+	}
+	if p.Doc != "" {
+		o = o.add("doc", p.Doc)
+	}
+	o = append(o, p.props...)
+	types := make([]json.RawMessage, len(p.Types))
+	for i, t := range p.Types {
+		types[i] = t.Schema
+	}
+	o = o.add("types", types)
+	var messages object
+	for _, m := range p.Messages {
+		messages = messages.add(m.Name, m.Definition)
+	}
+	if messages == nil {
+		messages = object{}
+	}
+	o = o.add("messages", messages)
+	return json.Marshal(o)
+}
+
+// ReadFile reads the protocol in the given file, which must
+// have either a .avdl or a .avpr extension. Imported files are
+// read relative to the directory containing the file.
+func ReadFile(filename string) (*Protocol, error) {
+	return newImporter().readFile(filename)
+}
+
+// ParseIDL parses the Avro IDL in data. The filename is used for
+// error messages and as the base for relative import paths.
+func ParseIDL(filename string, data []byte) (*Protocol, error) {
+	return newImporter().parseIDL(filename, data)
+}
+
+// ParseProtocol parses the JSON-encoded Avro protocol in data,
+// in the format used by .avpr files.
+func ParseProtocol(data []byte) (*Protocol, error) {
+	return parseProtocol("", data)
+}
+
+func parseProtocol(filename string, data []byte) (*Protocol, error) {
+	var p struct {
+		Protocol  string            `json:"protocol"`
+		Namespace string            `json:"namespace"`
+		Doc       string            `json:"doc"`
+		Types     []json.RawMessage `json:"types"`
+		Messages  json.RawMessage   `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid protocol: %v", err)
+	}
+	if p.Protocol == "" {
+		return nil, fmt.Errorf("invalid protocol: no protocol name")
+	}
+	proto := &Protocol{
+		Name:      p.Protocol,
+		Namespace: p.Namespace,
+		Doc:       p.Doc,
+	}
+	for _, t := range p.Types {
+		schema, err := withNamespace(t, p.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("invalid type in protocol: %v", err)
+		}
+		proto.Types = append(proto.Types, Type{
+			File:   filename,
+			Schema: schema,
+		})
+	}
+	if len(p.Messages) > 0 {
+		messages, err := decodeObject(p.Messages)
+		if err != nil {
+			return nil, fmt.Errorf("invalid messages in protocol: %v", err)
+		}
+		for _, m := range messages {
+			proto.Messages = append(proto.Messages, Message{
+				Name:       m.key,
+				Definition: m.value.(json.RawMessage),
+			})
+		}
+	}
+	return proto, nil
+}
+
+// withNamespace returns the given schema with the namespace
+// set explicitly to ns if it's a named type that doesn't
+// already specify one.
+func withNamespace(schema json.RawMessage, ns string) (json.RawMessage, error) {
+	if ns == "" || !bytes.HasPrefix(bytes.TrimSpace(schema), []byte("{")) {
+		return schema, nil
+	}
+	o, err := decodeObject(schema)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := o.get("namespace"); ok {
+		return schema, nil
+	}
+	var name string
+	if v, ok := o.get("name"); ok {
+		json.Unmarshal(v.(json.RawMessage), &name)
+	}
+	if name == "" || strings.Contains(name, ".") {
+		return schema, nil
+	}
+	o = o.insertAfter("name", "namespace", ns)
+	return json.Marshal(o)
+}
+
+// importer reads protocol files, keeping track of
+// the files that have already been imported.
+type importer struct {
+	imported map[string]bool
+}
+
+func newImporter() *importer {
+	return &importer{
+		imported: make(map[string]bool),
+	}
+}
+
+func (imp *importer) readFile(filename string) (*Protocol, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	switch ext := filepath.Ext(filename); ext {
+	case ".avdl":
+		return imp.parseIDL(filename, data)
+	case ".avpr":
+		imp.markImported(filename)
+		p, err := parseProtocol(filename, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		return p, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown protocol file extension %q", filename, ext)
+	}
+}
+
+func (imp *importer) parseIDL(filename string, data []byte) (*Protocol, error) {
+	imp.markImported(filename)
+	p := &parser{
+		lexer: lexer{
+			filename: filename,
+			src:      data,
+		},
+		imp:   imp,
+		names: make(map[string]bool),
+	}
+	return p.parse()
+}
+
+// markImported marks the given file as imported and reports
+// whether it had already been imported.
+func (imp *importer) markImported(filename string) bool {
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	if imp.imported[filename] {
+		return true
+	}
+	imp.imported[filename] = true
+	return false
+}
+
+// importSchema reads the .avsc file with the given name
+// and returns it as a type.
+func (imp *importer) importSchema(filename string) (Type, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Type{}, err
+	}
+	if !json.Valid(data) {
+		return Type{}, fmt.Errorf("%s: invalid JSON schema", filename)
+	}
+	return Type{
+		File:   filename,
+		Schema: json.RawMessage(bytes.TrimSpace(data)),
+	}, nil
+}
@@ -0,0 +1,230 @@
+package avdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokAnnotation
+	tokPunct
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokEOF:
+		return "end of file"
+	case tokIdent:
+		return "identifier"
+	case tokString:
+		return "string"
+	case tokNumber:
+		return "number"
+	case tokAnnotation:
+		return "annotation"
+	case tokPunct:
+		return "punctuation"
+	}
+	return "unknown token"
+}
+
+// token holds a lexical token.
+type token struct {
+	kind tokenKind
+
+	// text holds the text of the token. For strings, this
+	// is the unquoted value; for annotations, it's
+	// the annotation name without the leading @;
+	// for quoted identifiers, it's the identifier
+	// without the back quotes.
+	text string
+
+	// doc holds the text of any documentation comment
+	// immediately preceding the token.
+	doc string
+
+	// pos holds the byte offset of the token in the source.
+	pos int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return t.kind.String()
+	case tokString:
+		return fmt.Sprintf("%q", t.text)
+	case tokAnnotation:
+		return "@" + t.text
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// lexer splits Avro IDL source into tokens.
+type lexer struct {
+	filename string
+	src      []byte
+	pos      int
+}
+
+// parseError is used as a panic value to signal a syntax error.
+type parseError struct {
+	err error
+}
+
+// errorf panics with an error at the given byte offset.
+func (l *lexer) errorf(pos int, f string, a ...interface{}) {
+	line := 1 + bytes.Count(l.src[:pos], []byte("\n"))
+	col := 1 + pos - (bytes.LastIndexByte(l.src[:pos], '\n') + 1)
+	panic(&parseError{
+		err: fmt.Errorf("%s:%d:%d: %s", l.filename, line, col, fmt.Sprintf(f, a...)),
+	})
+}
+
+// skipSpace skips white space and comments, returning the
+// text of the last documentation comment found.
+func (l *lexer) skipSpace() string {
+	doc := ""
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRune(l.src[l.pos:])
+		switch {
+		case unicode.IsSpace(r):
+			l.pos += size
+		case bytes.HasPrefix(l.src[l.pos:], []byte("//")):
+			i := bytes.IndexByte(l.src[l.pos:], '\n')
+			if i == -1 {
+				l.pos = len(l.src)
+			} else {
+				l.pos += i + 1
+			}
+		case bytes.HasPrefix(l.src[l.pos:], []byte("/*")):
+			start := l.pos
+			i := bytes.Index(l.src[l.pos+2:], []byte("*/"))
+			if i == -1 {
+				l.errorf(start, "unterminated comment")
+			}
+			l.pos += 2 + i + 2
+			comment := string(l.src[start:l.pos])
+			if strings.HasPrefix(comment, "/**") && comment != "/**/" {
+				doc = docText(comment[3 : len(comment)-2])
+			}
+		default:
+			return doc
+		}
+	}
+	return doc
+}
+
+// next returns the next token from the source.
+func (l *lexer) next() token {
+	doc := l.skipSpace()
+	tok := token{
+		doc: doc,
+		pos: l.pos,
+	}
+	if l.pos >= len(l.src) {
+		tok.kind = tokEOF
+		return tok
+	}
+	c := l.src[l.pos]
+	switch {
+	case c == '"':
+		// Use the JSON decoder to parse the string so that
+		// escapes are treated the same as in JSON values.
+		var s string
+		l.jsonValue(&s)
+		tok.kind = tokString
+		tok.text = s
+	case c == '`':
+		i := bytes.IndexByte(l.src[l.pos+1:], '`')
+		if i == -1 {
+			l.errorf(l.pos, "unterminated quoted identifier")
+		}
+		tok.kind = tokIdent
+		tok.text = string(l.src[l.pos+1 : l.pos+1+i])
+		l.pos += i + 2
+	case c == '@':
+		l.pos++
+		// Annotation names may contain hyphens (for example
+		// @java-class) as well as the usual identifier characters.
+		tok.kind = tokAnnotation
+		tok.text = l.scan(func(r rune) bool {
+			return isIdentRune(r) || r == '-'
+		})
+		if tok.text == "" {
+			l.errorf(tok.pos, "missing annotation name")
+		}
+	case c >= '0' && c <= '9' || c == '-':
+		tok.kind = tokNumber
+		l.pos++
+		tok.text = string(c) + l.scan(func(r rune) bool {
+			return r >= '0' && r <= '9'
+		})
+	case strings.IndexByte("{}()[]<>;,=?", c) >= 0:
+		tok.kind = tokPunct
+		tok.text = string(c)
+		l.pos++
+	default:
+		tok.kind = tokIdent
+		tok.text = l.scan(isIdentRune)
+		if tok.text == "" {
+			r, _ := utf8.DecodeRune(l.src[l.pos:])
+			l.errorf(l.pos, "unexpected character %q", r)
+		}
+	}
+	return tok
+}
+
+// scan consumes and returns all the runes for which ok returns true.
+func (l *lexer) scan(ok func(r rune) bool) string {
+	start := l.pos
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRune(l.src[l.pos:])
+		if !ok(r) {
+			break
+		}
+		l.pos += size
+	}
+	return string(l.src[start:l.pos])
+}
+
+// jsonValue decodes the JSON value at the current position into x
+// and moves past it.
+func (l *lexer) jsonValue(x interface{}) {
+	l.skipSpace()
+	start := l.pos
+	dec := json.NewDecoder(bytes.NewReader(l.src[l.pos:]))
+	dec.UseNumber()
+	if err := dec.Decode(x); err != nil {
+		l.errorf(start, "invalid JSON value: %v", err)
+	}
+	l.pos += int(dec.InputOffset())
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// docText returns the documentation text from the body of a
+// /** ... */ comment, removing leading asterisks and
+// indentation from each line.
+func docText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i > 0 {
+			line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		}
+		lines[i] = line
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,97 @@
+package avdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// object represents a JSON object that retains
+// the order of its members when marshaled.
+type object []member
+
+type member struct {
+	key   string
+	value interface{}
+}
+
+// add returns o with the given member added.
+func (o object) add(key string, value interface{}) object {
+	return append(o, member{key, value})
+}
+
+// get returns the value of the member with the given key.
+func (o object) get(key string) (interface{}, bool) {
+	for _, m := range o {
+		if m.key == key {
+			return m.value, true
+		}
+	}
+	return nil, false
+}
+
+// insertAfter returns o with the given member added after
+// the member with the key after, or at the end if there's
+// no such member.
+func (o object) insertAfter(after, key string, value interface{}) object {
+	for i, m := range o {
+		if m.key == after {
+			o1 := make(object, 0, len(o)+1)
+			o1 = append(o1, o[:i+1]...)
+			o1 = append(o1, member{key, value})
+			return append(o1, o[i+1:]...)
+		}
+	}
+	return o.add(key, value)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal %q: %v", m.key, err)
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeObject decodes the JSON object in data, retaining
+// the order of its members. Each member value is
+// a json.RawMessage.
+func decodeObject(data []byte) (object, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected JSON object")
+	}
+	o := object{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		o = o.add(tok.(string), value)
+	}
+	return o, nil
+}
@@ -0,0 +1,730 @@
+package avdl
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// parser parses a single Avro IDL file.
+type parser struct {
+	lexer
+	imp *importer
+
+	// tok holds the current token.
+	tok token
+
+	// proto holds the protocol being parsed.
+	proto *Protocol
+
+	// types holds the types defined so far. Types defined in
+	// the IDL file itself are not marshaled until the
+	// end of the parse so that references to types defined
+	// later in the file can be resolved.
+	types []pendingType
+
+	// messages holds the messages defined so far.
+	messages []pendingMessage
+
+	// names holds the full names of all the types
+	// defined so far, including imported types.
+	names map[string]bool
+}
+
+type pendingType struct {
+	file   string
+	schema interface{}
+}
+
+type pendingMessage struct {
+	name string
+	def  interface{}
+}
+
+// logicalTypes holds the IDL keywords that represent logical types.
+var logicalTypes = map[string]object{
+	"date":               {{"type", "int"}, {"logicalType", "date"}},
+	"time_ms":            {{"type", "int"}, {"logicalType", "time-millis"}},
+	"timestamp_ms":       {{"type", "long"}, {"logicalType", "timestamp-millis"}},
+	"local_timestamp_ms": {{"type", "long"}, {"logicalType", "local-timestamp-millis"}},
+	"uuid":               {{"type", "string"}, {"logicalType", "uuid"}},
+}
+
+var primitiveTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"int":     true,
+	"long":    true,
+	"float":   true,
+	"double":  true,
+	"bytes":   true,
+	"string":  true,
+}
+
+// annotation holds an IDL annotation such as @namespace("x").
+type annotation struct {
+	name  string
+	value interface{}
+	// pos holds the byte offset of the annotation in the source.
+	pos int
+}
+
+func (p *parser) parse() (proto *Protocol, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			pe, ok := e.(*parseError)
+			if !ok {
+				panic(e)
+			}
+			proto, err = nil, pe.err
+		}
+	}()
+	p.next()
+	p.parseProtocol()
+	if p.tok.kind != tokEOF {
+		p.errorf(p.tok.pos, "unexpected %v after protocol", p.tok)
+	}
+	for _, t := range p.types {
+		data, err := json.Marshal(t.schema)
+		if err != nil {
+			p.errorf(0, "cannot marshal schema: %v", err)
+		}
+		p.proto.Types = append(p.proto.Types, Type{
+			File:   t.file,
+			Schema: data,
+		})
+	}
+	for _, m := range p.messages {
+		data, err := json.Marshal(m.def)
+		if err != nil {
+			p.errorf(0, "cannot marshal message %s: %v", m.name, err)
+		}
+		p.proto.Messages = append(p.proto.Messages, Message{
+			Name:       m.name,
+			Definition: data,
+		})
+	}
+	return p.proto, nil
+}
+
+func (p *parser) next() {
+	p.tok = p.lexer.next()
+}
+
+// is reports whether the current token is the given
+// punctuation or keyword.
+func (p *parser) is(text string) bool {
+	return (p.tok.kind == tokPunct || p.tok.kind == tokIdent) && p.tok.text == text
+}
+
+// expect checks that the current token is the given
+// punctuation or keyword and moves to the next token.
+func (p *parser) expect(text string) {
+	if !p.is(text) {
+		p.errorf(p.tok.pos, "expected %q, found %v", text, p.tok)
+	}
+	p.next()
+}
+
+// ident returns the current identifier token and moves
+// to the next token.
+func (p *parser) ident() string {
+	if p.tok.kind != tokIdent {
+		p.errorf(p.tok.pos, "expected identifier, found %v", p.tok)
+	}
+	s := p.tok.text
+	p.next()
+	return s
+}
+
+// number returns the value of the current number token
+// and moves to the next token.
+func (p *parser) number() int {
+	if p.tok.kind != tokNumber {
+		p.errorf(p.tok.pos, "expected number, found %v", p.tok)
+	}
+	n, err := strconv.Atoi(p.tok.text)
+	if err != nil {
+		p.errorf(p.tok.pos, "invalid number %q", p.tok.text)
+	}
+	p.next()
+	return n
+}
+
+// json parses the JSON value immediately following
+// the current token, and moves to the token after it.
+func (p *parser) json() interface{} {
+	var x interface{}
+	p.lexer.jsonValue(&x)
+	p.next()
+	return x
+}
+
+// annotations parses any annotations at the current position.
+func (p *parser) annotations() []annotation {
+	var anns []annotation
+	for p.tok.kind == tokAnnotation {
+		name, pos := p.tok.text, p.tok.pos
+		p.next()
+		if !p.is("(") {
+			p.errorf(p.tok.pos, "expected \"(\" after @%s, found %v", name, p.tok)
+		}
+		value := p.json()
+		p.expect(")")
+		anns = append(anns, annotation{name, value, pos})
+	}
+	return anns
+}
+
+func (p *parser) parseProtocol() {
+	doc := p.tok.doc
+	anns := p.annotations()
+	if doc == "" {
+		doc = p.tok.doc
+	}
+	p.expect("protocol")
+	p.proto = &Protocol{
+		Name: p.ident(),
+		Doc:  doc,
+	}
+	for _, ann := range anns {
+		if ann.name == "namespace" {
+			p.proto.Namespace = p.stringAnnotation(ann)
+		} else {
+			p.proto.props = p.proto.props.add(ann.name, ann.value)
+		}
+	}
+	p.expect("{")
+	for !p.is("}") {
+		if p.tok.kind == tokEOF {
+			p.errorf(p.tok.pos, "unexpected end of file in protocol")
+		}
+		p.parseDeclaration()
+	}
+	p.next()
+}
+
+func (p *parser) stringAnnotation(ann annotation) string {
+	s, ok := ann.value.(string)
+	if !ok {
+		p.errorf(ann.pos, "@%s annotation must be a string", ann.name)
+	}
+	return s
+}
+
+func (p *parser) parseDeclaration() {
+	if p.is("import") {
+		p.parseImport()
+		return
+	}
+	doc := p.tok.doc
+	pos := p.tok.pos
+	anns := p.annotations()
+	if doc == "" {
+		doc = p.tok.doc
+	}
+	switch {
+	case p.is("record") || p.is("error"):
+		p.parseRecord(doc, anns)
+	case p.is("enum"):
+		p.parseEnum(doc, anns)
+	case p.is("fixed"):
+		p.parseFixed(doc, anns)
+	default:
+		p.parseMessage(pos, doc, anns)
+	}
+}
+
+func (p *parser) parseImport() {
+	p.next()
+	kind := p.ident()
+	if p.tok.kind != tokString {
+		p.errorf(p.tok.pos, "expected import file name, found %v", p.tok)
+	}
+	filename := p.tok.text
+	pos := p.tok.pos
+	p.next()
+	p.expect(";")
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(filepath.Dir(p.filename), filename)
+	}
+	switch kind {
+	case "idl", "protocol":
+		if p.imp.markImported(filename) {
+			return
+		}
+		proto, err := p.imp.readFile(filename)
+		if err != nil {
+			p.errorf(pos, "cannot import %s: %v", kind, err)
+		}
+		for _, t := range proto.Types {
+			p.addImported(pos, t)
+		}
+		for _, m := range proto.Messages {
+			p.messages = append(p.messages, pendingMessage{
+				name: m.Name,
+				def:  m.Definition,
+			})
+		}
+	case "schema":
+		if p.imp.markImported(filename) {
+			return
+		}
+		t, err := p.imp.importSchema(filename)
+		if err != nil {
+			p.errorf(pos, "cannot import schema: %v", err)
+		}
+		p.addImported(pos, t)
+	default:
+		p.errorf(pos, "unknown import kind %q", kind)
+	}
+}
+
+// addImported adds an imported type to the protocol.
+func (p *parser) addImported(pos int, t Type) {
+	var x interface{}
+	if err := json.Unmarshal(t.Schema, &x); err != nil {
+		p.errorf(pos, "invalid schema in %s: %v", t.File, err)
+	}
+	for _, name := range schemaNames(x, "") {
+		p.define(pos, name)
+	}
+	p.types = append(p.types, pendingType{
+		file:   t.File,
+		schema: t.Schema,
+	})
+}
+
+// define records that the type with the given full name
+// has been defined.
+func (p *parser) define(pos int, fullName string) {
+	if p.names[fullName] {
+		p.errorf(pos, "duplicate definition of %s", fullName)
+	}
+	p.names[fullName] = true
+}
+
+// namedType parses the name of a named type declaration,
+// returning the new type with the common attributes set.
+func (p *parser) namedType(typ, doc string, anns []annotation) (object, string) {
+	pos := p.tok.pos
+	name := p.ident()
+	ns := p.proto.Namespace
+	var aliases interface{}
+	var props object
+	for _, ann := range anns {
+		switch ann.name {
+		case "namespace":
+			ns = p.stringAnnotation(ann)
+		case "aliases":
+			aliases = ann.value
+		default:
+			props = props.add(ann.name, ann.value)
+		}
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		ns, name = name[:i], name[i+1:]
+	}
+	p.define(pos, qualify(ns, name))
+	o := object{
+		{"type", typ},
+		{"name", name},
+	}
+	if ns != "" {
+		o = o.add("namespace", ns)
+	}
+	if doc != "" {
+		o = o.add("doc", doc)
+	}
+	if aliases != nil {
+		o = o.add("aliases", aliases)
+	}
+	return append(o, props...), ns
+}
+
+func (p *parser) parseRecord(doc string, anns []annotation) {
+	typ := p.ident()
+	o, ns := p.namedType(typ, doc, anns)
+	p.expect("{")
+	fields := []object{}
+	for !p.is("}") {
+		fields = append(fields, p.parseFields(ns)...)
+	}
+	p.next()
+	// Add the fields after any other attributes so that
+	// the ordering matches the usual .avsc layout.
+	o = o.insertAfter(lastAttr(o), "fields", fields)
+	p.types = append(p.types, pendingType{
+		file:   p.filename,
+		schema: o,
+	})
+}
+
+// lastAttr returns the key of the last standard named type
+// attribute in o.
+func lastAttr(o object) string {
+	last := "name"
+	for _, m := range o {
+		switch m.key {
+		case "namespace", "doc", "aliases":
+			last = m.key
+		}
+	}
+	return last
+}
+
+// parseFields parses a field declaration, which may
+// declare several fields of the same type.
+func (p *parser) parseFields(ns string) []object {
+	doc := p.tok.doc
+	var fieldAnns, typeAnns []annotation
+	for _, ann := range p.annotations() {
+		switch ann.name {
+		case "order", "aliases":
+			fieldAnns = append(fieldAnns, ann)
+		default:
+			typeAnns = append(typeAnns, ann)
+		}
+	}
+	if doc == "" {
+		doc = p.tok.doc
+	}
+	typ, nullable := p.parseType(ns, typeAnns)
+	var fields []object
+	for {
+		fieldDoc := doc
+		if p.tok.doc != "" {
+			fieldDoc = p.tok.doc
+		}
+		anns := append(fieldAnns[:len(fieldAnns):len(fieldAnns)], p.annotations()...)
+		if fieldDoc == "" {
+			fieldDoc = p.tok.doc
+		}
+		f := object{{"name", p.ident()}}
+		ftype := typ
+		var dflt interface{}
+		hasDefault := false
+		if p.is("=") {
+			dflt = p.json()
+			hasDefault = true
+		}
+		if nullable {
+			if hasDefault && dflt != nil {
+				ftype = []interface{}{typ, "null"}
+			} else {
+				ftype = []interface{}{"null", typ}
+			}
+		}
+		f = f.add("type", ftype)
+		if fieldDoc != "" {
+			f = f.add("doc", fieldDoc)
+		}
+		if hasDefault {
+			f = f.add("default", dflt)
+		}
+		for _, ann := range anns {
+			f = f.add(ann.name, ann.value)
+		}
+		fields = append(fields, f)
+		if !p.is(",") {
+			break
+		}
+		p.next()
+	}
+	p.expect(";")
+	return fields
+}
+
+// parseType parses a type reference. Type references in the
+// scope of a named type with namespace ns are resolved
+// relative to that namespace. It reports whether the type was
+// followed by a "?", making it nullable.
+func (p *parser) parseType(ns string, anns []annotation) (interface{}, bool) {
+	anns = append(anns, p.annotations()...)
+	pos := p.tok.pos
+	name := p.ident()
+	var t interface{}
+	switch {
+	case name == "array" || name == "map":
+		p.expect("<")
+		elem, nullable := p.parseType(ns, nil)
+		if nullable {
+			elem = []interface{}{"null", elem}
+		}
+		p.expect(">")
+		key := "items"
+		if name == "map" {
+			key = "values"
+		}
+		t = object{{"type", name}, {key, elem}}
+	case name == "union":
+		p.expect("{")
+		members := []interface{}{}
+		for !p.is("}") {
+			mt, nullable := p.parseType(ns, nil)
+			if nullable {
+				p.errorf(pos, "nullable type not allowed inside union")
+			}
+			members = append(members, mt)
+			if !p.is(",") {
+				break
+			}
+			p.next()
+		}
+		p.expect("}")
+		if len(anns) > 0 {
+			p.errorf(pos, "annotations not allowed on union types")
+		}
+		t = members
+	case name == "decimal":
+		p.expect("(")
+		precision := p.number()
+		p.expect(",")
+		scale := p.number()
+		p.expect(")")
+		t = object{
+			{"type", "bytes"},
+			{"logicalType", "decimal"},
+			{"precision", precision},
+			{"scale", scale},
+		}
+	case logicalTypes[name] != nil:
+		t = append(object(nil), logicalTypes[name]...)
+	case primitiveTypes[name]:
+		t = name
+	default:
+		t = &ref{
+			name: name,
+			ns:   ns,
+			p:    p,
+		}
+	}
+	if len(anns) > 0 {
+		o, ok := t.(object)
+		if !ok {
+			o = object{{"type", t}}
+		}
+		for _, ann := range anns {
+			o = o.add(ann.name, ann.value)
+		}
+		t = o
+	}
+	nullable := false
+	if p.is("?") {
+		nullable = true
+		p.next()
+	}
+	return t, nullable
+}
+
+func (p *parser) parseEnum(doc string, anns []annotation) {
+	p.next()
+	o, _ := p.namedType("enum", doc, anns)
+	p.expect("{")
+	symbols := []string{}
+	for !p.is("}") {
+		symbols = append(symbols, p.ident())
+		if !p.is(",") {
+			break
+		}
+		p.next()
+	}
+	p.expect("}")
+	o = o.insertAfter(lastAttr(o), "symbols", symbols)
+	if p.is("=") {
+		p.next()
+		o = o.insertAfter("symbols", "default", p.ident())
+		p.expect(";")
+	}
+	p.types = append(p.types, pendingType{
+		file:   p.filename,
+		schema: o,
+	})
+}
+
+func (p *parser) parseFixed(doc string, anns []annotation) {
+	p.next()
+	o, _ := p.namedType("fixed", doc, anns)
+	p.expect("(")
+	size := p.number()
+	p.expect(")")
+	p.expect(";")
+	o = o.insertAfter(lastAttr(o), "size", size)
+	p.types = append(p.types, pendingType{
+		file:   p.filename,
+		schema: o,
+	})
+}
+
+func (p *parser) parseMessage(pos int, doc string, anns []annotation) {
+	ns := p.proto.Namespace
+	var response interface{}
+	if p.is("void") {
+		p.next()
+		response = "null"
+	} else {
+		t, nullable := p.parseType(ns, nil)
+		if nullable {
+			t = []interface{}{"null", t}
+		}
+		response = t
+	}
+	name := p.ident()
+	for _, m := range p.messages {
+		if m.name == name {
+			p.errorf(pos, "duplicate message %s", name)
+		}
+	}
+	p.expect("(")
+	request := []object{}
+	for !p.is(")") {
+		fields := p.parseFormal(ns)
+		request = append(request, fields)
+		if !p.is(",") {
+			break
+		}
+		p.next()
+	}
+	p.expect(")")
+	var m object
+	if doc != "" {
+		m = m.add("doc", doc)
+	}
+	for _, ann := range anns {
+		m = m.add(ann.name, ann.value)
+	}
+	m = m.add("request", request)
+	m = m.add("response", response)
+	switch {
+	case p.is("oneway"):
+		p.next()
+		m = m.add("one-way", true)
+	case p.is("throws"):
+		p.next()
+		var errors []interface{}
+		for {
+			errors = append(errors, &ref{
+				name: p.ident(),
+				ns:   ns,
+				p:    p,
+			})
+			if !p.is(",") {
+				break
+			}
+			p.next()
+		}
+		m = m.add("errors", errors)
+	}
+	p.expect(";")
+	p.messages = append(p.messages, pendingMessage{
+		name: name,
+		def:  m,
+	})
+}
+
+// parseFormal parses a formal parameter of a message.
+func (p *parser) parseFormal(ns string) object {
+	doc := p.tok.doc
+	t, nullable := p.parseType(ns, nil)
+	name := p.ident()
+	f := object{{"name", name}}
+	var dflt interface{}
+	hasDefault := false
+	if p.is("=") {
+		dflt = p.json()
+		hasDefault = true
+	}
+	if nullable {
+		if hasDefault && dflt != nil {
+			t = []interface{}{t, "null"}
+		} else {
+			t = []interface{}{"null", t}
+		}
+	}
+	f = f.add("type", t)
+	if doc != "" {
+		f = f.add("doc", doc)
+	}
+	if hasDefault {
+		f = f.add("default", dflt)
+	}
+	return f
+}
+
+// ref represents a reference to a named type.
+type ref struct {
+	// name holds the name as written.
+	name string
+	// ns holds the namespace of the enclosing
+	// named type.
+	ns string
+	p  *parser
+}
+
+// MarshalJSON implements json.Marshaler by resolving the
+// reference. The name is looked up in the enclosing
+// namespace, then in the protocol's namespace and finally
+// in the null namespace. It's written as a full
+// name unless it's in the enclosing namespace.
+func (r *ref) MarshalJSON() ([]byte, error) {
+	full := r.name
+	if !strings.Contains(r.name, ".") {
+		for _, ns := range []string{r.ns, r.p.proto.Namespace, ""} {
+			if name := qualify(ns, r.name); r.p.names[name] {
+				full = name
+				break
+			}
+		}
+	}
+	if i := strings.LastIndex(full, "."); i >= 0 && full[:i] == r.ns {
+		full = full[i+1:]
+	}
+	return json.Marshal(full)
+}
+
+func qualify(ns, name string) string {
+	if ns == "" {
+		return name
+	}
+	return ns + "." + name
+}
+
+// schemaNames returns the full names of all the named types
+// defined in the given schema, which has been decoded
+// from JSON.
+func schemaNames(x interface{}, ns string) []string {
+	var names []string
+	switch x := x.(type) {
+	case []interface{}:
+		for _, t := range x {
+			names = append(names, schemaNames(t, ns)...)
+		}
+	case map[string]interface{}:
+		switch x["type"] {
+		case "record", "error", "enum", "fixed":
+		default:
+			for _, key := range []string{"type", "items", "values"} {
+				if t, ok := x[key]; ok {
+					names = append(names, schemaNames(t, ns)...)
+				}
+			}
+			return names
+		}
+		name, _ := x["name"].(string)
+		if tns, ok := x["namespace"].(string); ok {
+			ns = tns
+		}
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			ns, name = name[:i], name[i+1:]
+		}
+		names = append(names, qualify(ns, name))
+		fields, _ := x["fields"].([]interface{})
+		for _, f := range fields {
+			if f, ok := f.(map[string]interface{}); ok {
+				names = append(names, schemaNames(f["type"], ns)...)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,736 @@
+package avdl_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro/internal/avdl"
+)
+
+var parseTests = []struct {
+	testName string
+	idl      string
+	expect   string
+}{{
+	testName: "EmptyProtocol",
+	idl: `
+/** The protocol. */
+@namespace("com.example")
+protocol P {
+}`,
+	expect: `{
+		"protocol": "P",
+		"namespace": "com.example",
+		"doc": "The protocol.",
+		"types": [],
+		"messages": {}
+	}`,
+}, {
+	testName: "Record",
+	idl: `
+@namespace("com.example")
+protocol P {
+	/** A record
+	 * with two lines of doc.
+	 */
+	record R {
+		int a;
+		/** Field b. */
+		string b = "hello", c;
+		array<long> d = [];
+		map<R> e;
+		boolean? f;
+		R? g = null;
+		union { null, int, string } h;
+	}
+}`,
+	expect: `{
+		"protocol": "P",
+		"namespace": "com.example",
+		"types": [{
+			"type": "record",
+			"name": "R",
+			"namespace": "com.example",
+			"doc": "A record\nwith two lines of doc.",
+			"fields": [
+				{"name": "a", "type": "int"},
+				{"name": "b", "type": "string", "doc": "Field b.", "default": "hello"},
+				{"name": "c", "type": "string", "doc": "Field b."},
+				{"name": "d", "type": {"type": "array", "items": "long"}, "default": []},
+				{"name": "e", "type": {"type": "map", "values": "R"}},
+				{"name": "f", "type": ["null", "boolean"]},
+				{"name": "g", "type": ["null", "R"], "default": null},
+				{"name": "h", "type": ["null", "int", "string"]}
+			]
+		}],
+		"messages": {}
+	}`,
+}, {
+	testName: "NullableWithNonNullDefault",
+	idl: `
+protocol P {
+	record R {
+		int? a = 1;
+	}
+}`,
+	expect: `{
+		"protocol": "P",
+		"types": [{
+			"type": "record",
+			"name": "R",
+			"fields": [
+				{"name": "a", "type": ["int", "null"], "default": 1}
+			]
+		}],
+		"messages": {}
+	}`,
+}, {
+	testName: "EnumAndFixed",
+	idl: `
+protocol P {
+	/** An enum. */
+	@aliases(["OldE"])
+	enum E {
+		A, B, C
+	} = B;
+	@namespace("other")
+	fixed F(16);
+	error Failure {
+		string message;
+	}
+}`,
+	expect: `{
+		"protocol": "P",
+		"types": [{
+			"type": "enum",
+			"name": "E",
+			"doc": "An enum.",
+			"aliases": ["OldE"],
+			"symbols": ["A", "B", "C"],
+			"default": "B"
+		}, {
+			"type": "fixed",
+			"name": "F",
+			"namespace": "other",
+			"size": 16
+		}, {
+			"type": "error",
+			"name": "Failure",
+			"fields": [
+				{"name": "message", "type": "string"}
+			]
+		}],
+		"messages": {}
+	}`,
+}, {
+	testName: "EscapedIdentifiers",
+	idl: `
+protocol ` + "`protocol`" + ` {
+	record ` + "`record`" + ` {
+		int ` + "`int`" + `;
+		` + "`record`" + `? ` + "`error`" + `;
+	}
+}`,
+	expect: `{
+		"protocol": "protocol",
+		"types": [{
+			"type": "record",
+			"name": "record",
+			"fields": [
+				{"name": "int", "type": "int"},
+				{"name": "error", "type": ["null", "record"]}
+			]
+		}],
+		"messages": {}
+	}`,
+}, {
+	testName: "LogicalTypes",
+	idl: `
+protocol P {
+	record R {
+		date a;
+		time_ms b;
+		timestamp_ms c;
+		local_timestamp_ms d;
+		uuid e;
+		decimal(10, 2) f;
+		@logicalType("timestamp-micros") long g;
+	}
+}`,
+	expect: `{
+		"protocol": "P",
+		"types": [{
+			"type": "record",
+			"name": "R",
+			"fields": [
+				{"name": "a", "type": {"type": "int", "logicalType": "date"}},
+				{"name": "b", "type": {"type": "int", "logicalType": "time-millis"}},
+				{"name": "c", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+				{"name": "d", "type": {"type": "long", "logicalType": "local-timestamp-millis"}},
+				{"name": "e", "type": {"type": "string", "logicalType": "uuid"}},
+				{"name": "f", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+				{"name": "g", "type": {"type": "long", "logicalType": "timestamp-micros"}}
+			]
+		}],
+		"messages": {}
+	}`,
+}, {
+	testName: "FieldAnnotations",
+	idl: `
+protocol P {
+	record R {
+		@order("descending") @aliases(["x", "y"]) int a;
+		int b, @order("ignore") c;
+	}
+}`,
+	expect: `{
+		"protocol": "P",
+		"types": [{
+			"type": "record",
+			"name": "R",
+			"fields": [
+				{"name": "a", "type": "int", "order": "descending", "aliases": ["x", "y"]},
+				{"name": "b", "type": "int"},
+				{"name": "c", "type": "int", "order": "ignore"}
+			]
+		}],
+		"messages": {}
+	}`,
+}, {
+	testName: "Defaults",
+	idl: `
+protocol P {
+	enum E { X, Y }
+	record R {
+		E e = "Y";
+		map<int> m = {"a": 1};
+		double d = 1.5;
+		long l = -3;
+		boolean b = true;
+		bytes s = "ÿ";
+	}
+}`,
+	expect: `{
+		"protocol": "P",
+		"types": [{
+			"type": "enum",
+			"name": "E",
+			"symbols": ["X", "Y"]
+		}, {
+			"type": "record",
+			"name": "R",
+			"fields": [
+				{"name": "e", "type": "E", "default": "Y"},
+				{"name": "m", "type": {"type": "map", "values": "int"}, "default": {"a": 1}},
+				{"name": "d", "type": "double", "default": 1.5},
+				{"name": "l", "type": "long", "default": -3},
+				{"name": "b", "type": "boolean", "default": true},
+				{"name": "s", "type": "bytes", "default": "ÿ"}
+			]
+		}],
+		"messages": {}
+	}`,
+}, {
+	testName: "Messages",
+	idl: `
+@namespace("com.example")
+protocol P {
+	error Oops {
+		string why;
+	}
+	/** Says hello. */
+	string hello(string greeting, int? times = null) throws Oops;
+	void ping() oneway;
+	@deprecated(true) array<string> list();
+}`,
+	expect: `{
+		"protocol": "P",
+		"namespace": "com.example",
+		"types": [{
+			"type": "error",
+			"name": "Oops",
+			"namespace": "com.example",
+			"fields": [
+				{"name": "why", "type": "string"}
+			]
+		}],
+		"messages": {
+			"hello": {
+				"doc": "Says hello.",
+				"request": [
+					{"name": "greeting", "type": "string"},
+					{"name": "times", "type": ["null", "int"], "default": null}
+				],
+				"response": "string",
+				"errors": ["Oops"]
+			},
+			"ping": {
+				"request": [],
+				"response": "null",
+				"one-way": true
+			},
+			"list": {
+				"deprecated": true,
+				"request": [],
+				"response": {"type": "array", "items": "string"}
+			}
+		}
+	}`,
+}, {
+	testName: "Namespaces",
+	idl: `
+@namespace("a")
+protocol P {
+	record R1 {
+		R2 x;
+		b.R3 y;
+	}
+	record R2 {
+	}
+	@namespace("b")
+	record R3 {
+		R1 z;
+		R3 w;
+	}
+	record c.R4 {
+	}
+}`,
+	expect: `{
+		"protocol": "P",
+		"namespace": "a",
+		"types": [{
+			"type": "record",
+			"name": "R1",
+			"namespace": "a",
+			"fields": [
+				{"name": "x", "type": "R2"},
+				{"name": "y", "type": "b.R3"}
+			]
+		}, {
+			"type": "record",
+			"name": "R2",
+			"namespace": "a",
+			"fields": []
+		}, {
+			"type": "record",
+			"name": "R3",
+			"namespace": "b",
+			"fields": [
+				{"name": "z", "type": "a.R1"},
+				{"name": "w", "type": "R3"}
+			]
+		}, {
+			"type": "record",
+			"name": "R4",
+			"namespace": "c",
+			"fields": []
+		}],
+		"messages": {}
+	}`,
+}}
+
+func TestParseIDL(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range parseTests {
+		c.Run(test.testName, func(c *qt.C) {
+			p, err := avdl.ParseIDL("test.avdl", []byte(test.idl))
+			c.Assert(err, qt.IsNil)
+			data, err := json.Marshal(p)
+			c.Assert(err, qt.IsNil)
+			c.Assert(data, qt.JSONEquals, json.RawMessage(test.expect))
+		})
+	}
+}
+
+var parseErrorTests = []struct {
+	testName    string
+	idl         string
+	expectError string
+}{{
+	testName:    "Empty",
+	idl:         ``,
+	expectError: `test.avdl:1:1: expected "protocol", found end of file`,
+}, {
+	testName:    "MissingProtocolName",
+	idl:         `protocol {}`,
+	expectError: `test.avdl:1:10: expected identifier, found "{"`,
+}, {
+	testName: "UnexpectedEOF",
+	idl: `protocol P {
+	record R {
+		int a;
+	}
+`,
+	expectError: `test.avdl:5:1: unexpected end of file in protocol`,
+}, {
+	testName:    "TrailingTokens",
+	idl:         `protocol P {} x`,
+	expectError: `test.avdl:1:15: unexpected "x" after protocol`,
+}, {
+	testName: "MissingSemicolon",
+	idl: `protocol P {
+	record R {
+		int a
+	}
+}`,
+	expectError: `test.avdl:4:2: expected ";", found "}"`,
+}, {
+	testName: "UnexpectedCharacter",
+	idl: `protocol P {
+	record R {
+		int a: 1;
+	}
+}`,
+	expectError: `test.avdl:3:8: unexpected character ':'`,
+}, {
+	testName: "UnterminatedComment",
+	idl: `protocol P {
+	/* never ends
+}`,
+	expectError: `test.avdl:2:2: unterminated comment`,
+}, {
+	testName:    "UnterminatedQuotedIdentifier",
+	idl:         "protocol `P {}",
+	expectError: `test.avdl:1:10: unterminated quoted identifier`,
+}, {
+	testName: "InvalidDefault",
+	idl: `protocol P {
+	record R {
+		int a = {;
+	}
+}`,
+	expectError: `test.avdl:3:11: invalid JSON value: invalid character ';' looking for beginning of object key string`,
+}, {
+	testName:    "AnnotationWithoutValue",
+	idl:         `@namespace protocol P {}`,
+	expectError: `test.avdl:1:12: expected "\(" after @namespace, found "protocol"`,
+}, {
+	testName:    "MissingAnnotationName",
+	idl:         `@ protocol P {}`,
+	expectError: `test.avdl:1:1: missing annotation name`,
+}, {
+	testName:    "NonStringNamespace",
+	idl:         `@namespace(1) protocol P {}`,
+	expectError: `test.avdl:1:1: @namespace annotation must be a string`,
+}, {
+	testName: "NonStringTypeNamespace",
+	idl: `protocol P {
+	@namespace(["x"])
+	record R {}
+}`,
+	expectError: `test.avdl:2:2: @namespace annotation must be a string`,
+}, {
+	testName: "DuplicateType",
+	idl: `protocol P {
+	record R {}
+	enum R { A }
+}`,
+	expectError: `test.avdl:3:7: duplicate definition of R`,
+}, {
+	testName: "DuplicateMessage",
+	idl: `protocol P {
+	void m();
+	int m();
+}`,
+	expectError: `test.avdl:3:2: duplicate message m`,
+}, {
+	testName: "NullableInUnion",
+	idl: `protocol P {
+	record R {
+		union { null, int? } a;
+	}
+}`,
+	expectError: `test.avdl:3:3: nullable type not allowed inside union`,
+}, {
+	testName: "AnnotatedUnion",
+	idl: `protocol P {
+	record R {
+		@foo("bar") union { null, int } a;
+	}
+}`,
+	expectError: `test.avdl:3:15: annotations not allowed on union types`,
+}, {
+	testName: "BadFixedSize",
+	idl: `protocol P {
+	fixed F(x);
+}`,
+	expectError: `test.avdl:2:10: expected number, found "x"`,
+}, {
+	testName: "BadDecimal",
+	idl: `protocol P {
+	record R {
+		decimal(10) a;
+	}
+}`,
+	expectError: `test.avdl:3:13: expected ",", found "\)"`,
+}, {
+	testName: "UnknownImportKind",
+	idl: `protocol P {
+	import foo "x.avsc";
+}`,
+	expectError: `test.avdl:2:13: unknown import kind "foo"`,
+}, {
+	testName: "MissingImportFile",
+	idl: `protocol P {
+	import schema "does-not-exist.avsc";
+}`,
+	expectError: `test.avdl:2:16: cannot import schema: open does-not-exist.avsc: .*`,
+}}
+
+func TestParseIDLErrors(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range parseErrorTests {
+		c.Run(test.testName, func(c *qt.C) {
+			p, err := avdl.ParseIDL("test.avdl", []byte(test.idl))
+			c.Assert(err, qt.ErrorMatches, test.expectError)
+			c.Assert(p, qt.IsNil)
+		})
+	}
+}
+
+func TestImports(t *testing.T) {
+	c := qt.New(t)
+	dir := c.TempDir()
+	writeFile(c, filepath.Join(dir, "main.avdl"), `
+@namespace("com.example")
+protocol Main {
+	import idl "sub/common.avdl";
+	import schema "sub/id.avsc";
+	import protocol "other.avpr";
+	// Importing the same file again has no effect.
+	import idl "sub/common.avdl";
+
+	record R {
+		Common c;
+		Id id;
+		com.other.O o;
+	}
+}`)
+	writeFile(c, filepath.Join(dir, "sub", "common.avdl"), `
+@namespace("com.example")
+protocol Common {
+	// Imports are relative to the importing file.
+	import schema "id.avsc";
+
+	record Common {
+		Id id;
+	}
+	void ping();
+}`)
+	writeFile(c, filepath.Join(dir, "sub", "id.avsc"), `{
+	"type": "fixed",
+	"name": "com.example.Id",
+	"size": 16
+}`)
+	writeFile(c, filepath.Join(dir, "other.avpr"), `{
+	"protocol": "Other",
+	"namespace": "com.other",
+	"types": [{
+		"type": "enum",
+		"name": "O",
+		"symbols": ["A"]
+	}],
+	"messages": {}
+}`)
+	p, err := avdl.ReadFile(filepath.Join(dir, "main.avdl"))
+	c.Assert(err, qt.IsNil)
+
+	files := make([]string, len(p.Types))
+	for i, t := range p.Types {
+		files[i], err = filepath.Rel(dir, t.File)
+		c.Assert(err, qt.IsNil)
+	}
+	c.Assert(files, qt.DeepEquals, []string{
+		"sub/id.avsc",
+		"sub/common.avdl",
+		"other.avpr",
+		"main.avdl",
+	})
+	data, err := json.Marshal(p)
+	c.Assert(err, qt.IsNil)
+	c.Assert(data, qt.JSONEquals, json.RawMessage(`{
+		"protocol": "Main",
+		"namespace": "com.example",
+		"types": [{
+			"type": "fixed",
+			"name": "com.example.Id",
+			"size": 16
+		}, {
+			"type": "record",
+			"name": "Common",
+			"namespace": "com.example",
+			"fields": [
+				{"name": "id", "type": "Id"}
+			]
+		}, {
+			"type": "enum",
+			"name": "O",
+			"namespace": "com.other",
+			"symbols": ["A"]
+		}, {
+			"type": "record",
+			"name": "R",
+			"namespace": "com.example",
+			"fields": [
+				{"name": "c", "type": "Common"},
+				{"name": "id", "type": "Id"},
+				{"name": "o", "type": "com.other.O"}
+			]
+		}],
+		"messages": {
+			"ping": {
+				"request": [],
+				"response": "null"
+			}
+		}
+	}`))
+}
+
+func TestImportErrors(t *testing.T) {
+	c := qt.New(t)
+	dir := c.TempDir()
+	writeFile(c, filepath.Join(dir, "bad.avdl"), `protocol Bad {
+	record R {
+		int a
+	}
+}`)
+	writeFile(c, filepath.Join(dir, "dup.avsc"), `{"type": "fixed", "name": "R", "size": 1}`)
+	writeFile(c, filepath.Join(dir, "invalid.avsc"), `{"type": `)
+
+	tests := []struct {
+		testName    string
+		idl         string
+		expectError string
+	}{{
+		testName: "ErrorInImportedFile",
+		idl: `protocol P {
+	import idl "bad.avdl";
+}`,
+		expectError: `.*main.avdl:2:13: cannot import idl: .*bad.avdl:4:2: expected ";", found "}"`,
+	}, {
+		testName: "DuplicateImportedType",
+		idl: `protocol P {
+	record R {}
+	import schema "dup.avsc";
+}`,
+		expectError: `.*main.avdl:3:16: duplicate definition of R`,
+	}, {
+		testName: "InvalidSchema",
+		idl: `protocol P {
+	import schema "invalid.avsc";
+}`,
+		expectError: `.*main.avdl:2:16: cannot import schema: .*invalid.avsc: invalid JSON schema`,
+	}}
+	for _, test := range tests {
+		c.Run(test.testName, func(c *qt.C) {
+			_, err := avdl.ParseIDL(filepath.Join(dir, "main.avdl"), []byte(test.idl))
+			c.Assert(err, qt.ErrorMatches, test.expectError)
+		})
+	}
+}
+
+func TestParseProtocol(t *testing.T) {
+	c := qt.New(t)
+	p, err := avdl.ParseProtocol([]byte(`{
+		"protocol": "P",
+		"namespace": "com.example",
+		"types": [
+			{"type": "record", "name": "R", "fields": []},
+			{"type": "enum", "name": "other.E", "symbols": ["A"]}
+		],
+		"messages": {
+			"m": {"request": [], "response": "R"}
+		}
+	}`))
+	c.Assert(err, qt.IsNil)
+	c.Assert(p.Name, qt.Equals, "P")
+	c.Assert(p.Types, qt.HasLen, 2)
+	// The protocol namespace is added to types that don't have one.
+	c.Assert(string(p.Types[0].Schema), qt.JSONEquals, json.RawMessage(`{"type": "record", "name": "R", "namespace": "com.example", "fields": []}`))
+	c.Assert(string(p.Types[1].Schema), qt.JSONEquals, json.RawMessage(`{"type": "enum", "name": "other.E", "symbols": ["A"]}`))
+	c.Assert(p.Messages, qt.HasLen, 1)
+	c.Assert(p.Messages[0].Name, qt.Equals, "m")
+
+	_, err = avdl.ParseProtocol([]byte(`{"types": []}`))
+	c.Assert(err, qt.ErrorMatches, `invalid protocol: no protocol name`)
+}
+
+func TestSchemas(t *testing.T) {
+	c := qt.New(t)
+	p, err := avdl.ParseIDL("test.avdl", []byte(`
+@namespace("com.example")
+protocol P {
+	enum E { A, B }
+	error Failure {
+		E e1;
+		E e2;
+	}
+	record R {
+		Failure f;
+	}
+}`))
+	c.Assert(err, qt.IsNil)
+	schemas, err := p.Schemas()
+	c.Assert(err, qt.IsNil)
+	c.Assert(schemas, qt.HasLen, 3)
+	c.Assert(schemas[0].Name, qt.Equals, "com.example.E")
+	c.Assert(schemas[1].Name, qt.Equals, "com.example.Failure")
+	// The error type becomes a record, and E is defined
+	// where it's first used, without repeating the
+	// enclosing namespace.
+	c.Assert(string(schemas[1].Schema), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "Failure",
+		"namespace": "com.example",
+		"fields": [{
+			"name": "e1",
+			"type": {
+				"type": "enum",
+				"name": "E",
+				"symbols": ["A", "B"]
+			}
+		}, {
+			"name": "e2",
+			"type": "E"
+		}]
+	}`))
+
+	// R refers to all the other types, so the
+	// combined schema is R with the others inline.
+	combined, err := p.CombinedSchema()
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(combined), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"namespace": "com.example",
+		"fields": [{
+			"name": "f",
+			"type": {
+				"type": "record",
+				"name": "Failure",
+				"fields": [{
+					"name": "e1",
+					"type": {
+						"type": "enum",
+						"name": "E",
+						"symbols": ["A", "B"]
+					}
+				}, {
+					"name": "e2",
+					"type": "E"
+				}]
+			}
+		}]
+	}`))
+}
+
+func writeFile(c *qt.C, path, content string) {
+	err := os.MkdirAll(filepath.Dir(path), 0o777)
+	c.Assert(err, qt.IsNil)
+	err = os.WriteFile(path, []byte(content), 0o666)
+	c.Assert(err, qt.IsNil)
+}
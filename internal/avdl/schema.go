@@ -0,0 +1,333 @@
+package avdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema holds a self-contained Avro schema for
+// a named type in a protocol.
+type Schema struct {
+	// Name holds the full name of the type.
+	Name string
+
+	// Schema holds the JSON schema. All the types referred
+	// to by the schema are defined inline where they're first
+	// used.
+	Schema json.RawMessage
+}
+
+// Schemas returns a self-contained schema for each type
+// defined at the top level of the protocol, in order.
+//
+// Error types are only valid inside protocols, so
+// they're converted to record types.
+func (p *Protocol) Schemas() ([]Schema, error) {
+	in, err := newInliner(p)
+	if err != nil {
+		return nil, err
+	}
+	schemas := make([]Schema, 0, len(in.types))
+	for _, t := range in.types {
+		in.seen = make(map[string]bool)
+		data, err := json.Marshal(in.inline(t.schema, ""))
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, Schema{
+			Name:   t.name,
+			Schema: data,
+		})
+	}
+	return schemas, nil
+}
+
+// CombinedSchema returns a single schema that defines all the
+// types in the protocol. Each type is defined where it's first
+// used; if that leaves only a single top level type, the
+// schema is that type, otherwise it's a union of all the
+// remaining top level types.
+func (p *Protocol) CombinedSchema() (json.RawMessage, error) {
+	in, err := newInliner(p)
+	if err != nil {
+		return nil, err
+	}
+	// First find out which types are used by others, so that
+	// they can be defined inline by those types.
+	used := make(map[string]bool)
+	for _, t := range in.types {
+		in.seen = make(map[string]bool)
+		in.inline(t.schema, "")
+		for name := range in.seen {
+			if name != t.name {
+				used[name] = true
+			}
+		}
+	}
+	in.seen = make(map[string]bool)
+	var union []interface{}
+	for _, t := range in.types {
+		if !used[t.name] {
+			union = append(union, in.inline(t.schema, ""))
+		}
+	}
+	// Define any types left over (for example mutually
+	// recursive types that aren't referred to by any
+	// other type).
+	for _, t := range in.types {
+		if !in.seen[t.name] {
+			union = append(union, in.inline(t.schema, ""))
+		}
+	}
+	if len(union) == 1 {
+		return json.Marshal(union[0])
+	}
+	return json.Marshal(union)
+}
+
+// inliner produces schemas with named types
+// defined inline.
+type inliner struct {
+	// types holds the top level types in the protocol.
+	types []namedSchema
+
+	// defs maps from full type name to the definition of
+	// that type, including types defined inside other types.
+	defs map[string]namedDef
+
+	// seen holds the full names of the types that have been
+	// defined in the current schema.
+	seen map[string]bool
+}
+
+type namedSchema struct {
+	name   string
+	schema object
+}
+
+type namedDef struct {
+	schema object
+	// ns holds the namespace enclosing the definition.
+	ns string
+}
+
+func newInliner(p *Protocol) (*inliner, error) {
+	in := &inliner{
+		defs: make(map[string]namedDef),
+	}
+	for _, t := range p.Types {
+		v, err := decodeValue(t.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema in protocol: %v", err)
+		}
+		o, ok := v.(object)
+		if !ok || !isNamed(o) {
+			return nil, fmt.Errorf("protocol type is not a named type")
+		}
+		name, _ := typeName(o, "")
+		in.types = append(in.types, namedSchema{
+			name:   name,
+			schema: o,
+		})
+		in.addDefs(o, "")
+	}
+	return in, nil
+}
+
+// addDefs adds all the named types defined in v
+// to in.defs.
+func (in *inliner) addDefs(v interface{}, ns string) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, t := range v {
+			in.addDefs(t, ns)
+		}
+	case object:
+		if !isNamed(v) {
+			for _, key := range []string{"type", "items", "values"} {
+				if t, ok := v.get(key); ok {
+					in.addDefs(t, ns)
+				}
+			}
+			return
+		}
+		name, tns := typeName(v, ns)
+		in.defs[name] = namedDef{
+			schema: v,
+			ns:     ns,
+		}
+		fields, _ := v.get("fields")
+		for _, f := range asSlice(fields) {
+			if f, ok := f.(object); ok {
+				t, _ := f.get("type")
+				in.addDefs(t, tns)
+			}
+		}
+	}
+}
+
+// inline returns v with all references to types not yet
+// defined replaced by their definitions. The ns parameter
+// holds the enclosing namespace.
+func (in *inliner) inline(v interface{}, ns string) interface{} {
+	switch v := v.(type) {
+	case string:
+		if primitiveTypes[v] {
+			return v
+		}
+		name := v
+		if !strings.Contains(name, ".") {
+			name = qualify(ns, v)
+			if _, ok := in.defs[name]; !ok {
+				name = v
+			}
+		}
+		def, ok := in.defs[name]
+		if !ok || in.seen[name] {
+			return v
+		}
+		return in.inlineNamed(def.schema, def.ns, ns)
+	case []interface{}:
+		v1 := make([]interface{}, len(v))
+		for i, t := range v {
+			v1[i] = in.inline(t, ns)
+		}
+		return v1
+	case object:
+		if !isNamed(v) {
+			v1 := make(object, len(v))
+			for i, m := range v {
+				switch m.key {
+				case "type", "items", "values":
+					m.value = in.inline(m.value, ns)
+				}
+				v1[i] = m
+			}
+			return v1
+		}
+		return in.inlineNamed(v, ns, ns)
+	}
+	return v
+}
+
+// inlineNamed is like inline but for the named type definition o,
+// originally defined in the namespace defNS, to be defined in
+// the enclosing namespace ns.
+func (in *inliner) inlineNamed(o object, defNS, ns string) object {
+	name, tns := typeName(o, defNS)
+	in.seen[name] = true
+	o1 := make(object, 0, len(o)+1)
+	for _, m := range o {
+		switch m.key {
+		case "type":
+			if m.value == "error" {
+				m.value = "record"
+			}
+		case "name":
+			o1 = append(o1, m)
+			if n, _ := m.value.(string); tns != ns && !strings.Contains(n, ".") {
+				// The namespace isn't implied by the
+				// enclosing namespace.
+				o1 = o1.add("namespace", tns)
+			}
+			continue
+		case "namespace":
+			// The namespace is added after the name
+			// when it's needed.
+			continue
+		case "fields":
+			fields := asSlice(m.value)
+			fields1 := make([]interface{}, len(fields))
+			for i, f := range fields {
+				if f, ok := f.(object); ok {
+					f1 := make(object, len(f))
+					for j, fm := range f {
+						if fm.key == "type" {
+							fm.value = in.inline(fm.value, tns)
+						}
+						f1[j] = fm
+					}
+					fields1[i] = f1
+				} else {
+					fields1[i] = f
+				}
+			}
+			m.value = fields1
+		}
+		o1 = append(o1, m)
+	}
+	return o1
+}
+
+// isNamed reports whether o is the definition of a named type.
+func isNamed(o object) bool {
+	t, _ := o.get("type")
+	switch t {
+	case "record", "error", "enum", "fixed":
+		return true
+	}
+	return false
+}
+
+// typeName returns the full name and namespace of the named
+// type definition o, defined inside the namespace ns.
+func typeName(o object, ns string) (string, string) {
+	name, _ := o.get("name")
+	s, _ := name.(string)
+	if tns, ok := o.get("namespace"); ok {
+		ns, _ = tns.(string)
+	}
+	if i := strings.LastIndex(s, "."); i >= 0 {
+		return s, s[:i]
+	}
+	return qualify(ns, s), ns
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+// decodeValue decodes the JSON value in data, decoding objects
+// as object values so that their member order is retained.
+func decodeValue(data []byte) (interface{}, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte("{")):
+		o, err := decodeObject(data)
+		if err != nil {
+			return nil, err
+		}
+		for i, m := range o {
+			v, err := decodeValue(m.value.(json.RawMessage))
+			if err != nil {
+				return nil, err
+			}
+			o[i].value = v
+		}
+		return o, nil
+	case bytes.HasPrefix(data, []byte("[")):
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return nil, err
+		}
+		s := make([]interface{}, len(elems))
+		for i, e := range elems {
+			v, err := decodeValue(e)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = v
+		}
+		return s, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var x interface{}
+	if err := dec.Decode(&x); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
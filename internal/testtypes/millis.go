@@ -0,0 +1,34 @@
+package testtypes
+
+import (
+	"time"
+
+	"github.com/heetch/avro"
+)
+
+// Millis represents a time with millisecond precision.
+// It's represented in Avro as a long with the
+// timestamp-millis logical type.
+type Millis time.Time
+
+// AvroRepresentation implements avro.AvroRepresenter.
+func (Millis) AvroRepresentation() avro.Representation {
+	return avro.Representation{
+		Type:   new(int64),
+		Schema: `{"type": "long", "logicalType": "timestamp-millis"}`,
+	}
+}
+
+// MarshalAvro implements avro.AvroMarshaler.
+func (m Millis) MarshalAvro() (interface{}, error) {
+	return time.Time(m).UnixMilli(), nil
+}
+
+// UnmarshalAvro implements avro.AvroUnmarshaler.
+func (m *Millis) UnmarshalAvro(x interface{}) error {
+	*m = Millis(time.UnixMilli(x.(int64)).UTC())
+	return nil
+}
+
+// ID is a string identifier.
+type ID string
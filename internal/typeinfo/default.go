@@ -0,0 +1,266 @@
+package typeinfo
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// DefaultValue returns the Go value of type t for the Avro
+// default value v, as decoded from JSON by encoding/json.
+//
+// Struct fields not mentioned in an object value are given
+// their own default value.
+func DefaultValue(t reflect.Type, v interface{}, opts Options) (reflect.Value, error) {
+	rv := reflect.New(t).Elem()
+	if err := setDefault(rv, v, opts); err != nil {
+		return reflect.Value{}, err
+	}
+	return rv, nil
+}
+
+// structDefault returns the default value for the struct
+// type t when it has no explicit default, which differs from
+// the zero value when any of its fields specify defaults.
+func structDefault(t reflect.Type, opts Options) (reflect.Value, error) {
+	return DefaultValue(t, map[string]interface{}{}, opts)
+}
+
+func setDefault(rv reflect.Value, v interface{}, opts Options) error {
+	t := rv.Type()
+	if v == nil {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			return nil
+		}
+		if t == nullType {
+			return nil
+		}
+		return fmt.Errorf("null is not a valid default for %s", t)
+	}
+	switch t {
+	case timeType:
+		n, err := defaultInt(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid default for time.Time: %v", err)
+		}
+		// timestamp-micros
+		rv.Set(reflect.ValueOf(time.Unix(n/1e6, n%1e6*1e3)))
+		return nil
+	case uuidType:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("default for %s must be a string", t)
+		}
+		if s == "" {
+			return nil
+		}
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return fmt.Errorf("invalid default for %s: %v", t, err)
+		}
+		rv.Set(reflect.ValueOf(id))
+		return nil
+	}
+	if s, ok := v.(string); ok && t.Kind() != reflect.String {
+		if u, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("default for %s must be a boolean", t)
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s, ok := v.(string); ok && t != durationType {
+			return setEnumDefault(rv, s, opts)
+		}
+		n, err := defaultInt(v, t.Bits())
+		if err != nil {
+			return fmt.Errorf("invalid default for %s: %v", t, err)
+		}
+		rv.SetInt(n)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		if s, ok := v.(string); ok {
+			return setEnumDefault(rv, s, opts)
+		}
+		n, err := defaultInt(v, t.Bits()+1)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid default for %s: %v out of range", t, v)
+		}
+		rv.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("default for %s must be a number", t)
+		}
+		rv.SetFloat(f)
+	case reflect.String:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("default for %s must be a string", t)
+		}
+		rv.SetString(s)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b, err := defaultBytes(v)
+			if err != nil {
+				return fmt.Errorf("invalid default for %s: %v", t, err)
+			}
+			rv.SetBytes(b)
+			return nil
+		}
+		items, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("default for %s must be an array", t)
+		}
+		rv.Set(reflect.MakeSlice(t, len(items), len(items)))
+		for i, item := range items {
+			if err := setDefault(rv.Index(i), item, opts); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		b, err := defaultBytes(v)
+		if err != nil {
+			return fmt.Errorf("invalid default for %s: %v", t, err)
+		}
+		if len(b) != t.Len() {
+			return fmt.Errorf("default for %s has wrong length %d", t, len(b))
+		}
+		reflect.Copy(rv, reflect.ValueOf(b))
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok || t.Key().Kind() != reflect.String {
+			return fmt.Errorf("default for %s must be an object", t)
+		}
+		rv.Set(reflect.MakeMapWithSize(t, len(m)))
+		for k, mv := range m {
+			ev := reflect.New(t.Elem()).Elem()
+			if err := setDefault(ev, mv, opts); err != nil {
+				return err
+			}
+			rv.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), ev)
+		}
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("default for %s must be an object", t)
+		}
+		generated := AvroRecordOf(t) != nil
+		if generated {
+			rv.Set(recordDefault(t))
+		}
+		fields, err := Fields(t)
+		if err != nil {
+			return err
+		}
+		for _, f := range fields {
+			tag, err := FieldTag(f)
+			if err != nil {
+				return err
+			}
+			fv, ok := m[tag.Name]
+			if !ok {
+				switch {
+				case tag.HasDefault:
+					fv = tag.Default
+				case generated:
+					// The field already holds its default.
+					continue
+				case f.Type.Kind() == reflect.Struct && isEmbeddableStruct(f.Type):
+					// The field's own fields might have defaults.
+					fv = map[string]interface{}{}
+				default:
+					continue
+				}
+			}
+			if err := setDefault(FieldByIndex(rv, f.Index), fv, opts); err != nil {
+				return fmt.Errorf("field %s: %v", tag.Name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("cannot use non-null default for %s", t)
+	}
+	return nil
+}
+
+// recordDefault returns the default value for the type t
+// generated by avrogo, in which each field holds the default
+// specified by the RecordInfo.Defaults for t, or its
+// zero value if there's none. Required fields holding other
+// generated record types hold their default values in turn.
+func recordDefault(t reflect.Type) reflect.Value {
+	r := AvroRecordOf(t).AvroRecord()
+	v := reflect.New(t).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i).Type
+		switch {
+		case i < len(r.Defaults) && r.Defaults[i] != nil:
+			v.Field(i).Set(reflect.ValueOf(r.Defaults[i]()))
+		case i < len(r.Required) && r.Required[i] && ft.Kind() == reflect.Struct && AvroRecordOf(ft) != nil:
+			v.Field(i).Set(recordDefault(ft))
+		}
+	}
+	return v
+}
+
+// setEnumDefault sets the enum value rv to the value
+// for the given symbol.
+func setEnumDefault(rv reflect.Value, symbol string, opts Options) error {
+	syms, err := EnumSymbols(rv.Type(), opts.SanitizeEnumSymbols)
+	if err != nil {
+		return err
+	}
+	for i, sym := range syms {
+		if sym == symbol {
+			if rv.CanInt() {
+				rv.SetInt(int64(i))
+			} else {
+				rv.SetUint(uint64(i))
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid default %q for %s", symbol, rv.Type())
+}
+
+func defaultInt(v interface{}, bits int) (int64, error) {
+	f, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%v is not a number", v)
+	}
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("%v is not an integer", v)
+	}
+	if limit := float64(int64(1) << (bits - 1)); bits < 64 && (f < -limit || f >= limit) {
+		return 0, fmt.Errorf("%v out of range", v)
+	}
+	return int64(f), nil
+}
+
+// defaultBytes returns the bytes represented by the Avro
+// JSON default value v, which holds one code point per byte.
+func defaultBytes(v interface{}) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a string", v)
+	}
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, fmt.Errorf("code point %U out of range for bytes", r)
+		}
+		b = append(b, byte(r))
+	}
+	return b, nil
+}
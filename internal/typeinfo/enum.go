@@ -0,0 +1,215 @@
+package typeinfo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/heetch/avro/avrotypegen"
+)
+
+const maxEnum = 250
+
+// EnumSymbols returns the enum symbols represented by the given
+// type. If the type doesn't represent an enum it returns no symbols.
+//
+// An integer type represents an enum if it implements
+// avrotypegen.AvroEnum and specifies its symbols explicitly,
+// or if its String method looks like it returns a distinct valid
+// Avro symbol for each value from zero up.
+//
+// If sanitize is true, names that aren't valid Avro symbols are
+// sanitized by replacing invalid characters with underscores and
+// prefixing a leading digit with an underscore. If that results in
+// two identical symbols, the type isn't treated as an enum.
+//
+// In all cases, the symbol at index i corresponds
+// to the Go value i.
+func EnumSymbols(t reflect.Type, sanitize bool) ([]string, error) {
+	k := t.Kind()
+	if !(reflect.Int <= k && k <= reflect.Int64) && !(reflect.Uint <= k && k <= reflect.Uint64) {
+		return nil, nil
+	}
+	if e, ok := reflect.Zero(t).Interface().(avrotypegen.AvroEnum); ok {
+		if syms := e.AvroEnum().Symbols; syms != nil {
+			if err := checkEnumSymbols(t, syms); err != nil {
+				return nil, err
+			}
+			return syms, nil
+		}
+	}
+	names := stringerSymbols(t)
+	if names == nil {
+		return nil, nil
+	}
+	syms := make([]string, len(names))
+	seen := make(map[string]bool)
+	for i, name := range names {
+		sym := name
+		if !isValidEnumSymbol(sym) {
+			if !sanitize {
+				return nil, nil
+			}
+			sym = sanitizeEnumSymbol(name)
+		}
+		if seen[sym] {
+			return nil, nil
+		}
+		seen[sym] = true
+		syms[i] = sym
+	}
+	return syms, nil
+}
+
+// checkEnumSymbols checks that the explicitly specified
+// symbols for the enum type t are valid.
+func checkEnumSymbols(t reflect.Type, syms []string) error {
+	if len(syms) == 0 {
+		return fmt.Errorf("enum %s has no symbols", t)
+	}
+	seen := make(map[string]bool)
+	for _, sym := range syms {
+		if !isValidEnumSymbol(sym) {
+			return fmt.Errorf("enum %s has invalid symbol %q", t, sym)
+		}
+		if seen[sym] {
+			return fmt.Errorf("enum %s has duplicate symbol %q", t, sym)
+		}
+		seen[sym] = true
+	}
+	return nil
+}
+
+// stringerSymbols returns the names returned by the String method
+// of t for each value from zero up, or nil if t doesn't look like
+// an enum.
+func stringerSymbols(t reflect.Type) []string {
+	if _, ok := reflect.Zero(t).Interface().(fmt.Stringer); !ok {
+		return nil
+	}
+	isUnsignedInt := reflect.Uint <= t.Kind() && t.Kind() <= reflect.Uint64
+	v := reflect.New(t)
+	vs := v.Interface().(fmt.Stringer) // Note: pointer type will also include String method.
+	v = v.Elem()
+	setInt := v.SetInt
+	getIntVal := v.Int
+	if isUnsignedInt {
+		setInt = func(i int64) {
+			v.SetUint(uint64(i))
+		}
+		getIntVal = func() int64 {
+			return int64(v.Uint())
+		}
+	}
+	symOf := func(i int64) (sym string, actual int64, ok bool) {
+		defer func() {
+			// It panics when calling String, which is a decent indication
+			// that it's out of bounds.
+			if recover() != nil {
+				ok = false
+			}
+		}()
+		setInt(i)
+		return vs.String(), getIntVal(), true
+	}
+	// Assume that -1 is out-of-bounds and see what
+	// we get when we call String on it.
+	sym, actual, ok := symOf(-1)
+	const (
+		oobEmpty = iota
+		oobParen
+		oobNumber
+		oobPanic
+	)
+	var oobStyle int
+	// Note: the String implementation created by the stringer tool
+	// returns "T(x)" for an out-of-bounds number x of type T
+	// so we use a bracket as an indicator of "out of bounds".
+	switch {
+	case !ok:
+		oobStyle = oobPanic
+	case sym == "":
+		oobStyle = oobEmpty
+	case strings.Contains(sym, "("):
+		oobStyle = oobParen
+	case sym == fmt.Sprint(actual):
+		oobStyle = oobNumber
+	default:
+		// All our heuristics for detecting out-of-bounds values
+		// are exhausted.
+		return nil
+	}
+	prev := ""
+	var syms []string
+	for i := 0; i < maxEnum; i++ {
+		sym, actual, ok := symOf(int64(i))
+		if !ok || sym == "" {
+			// Panic or empty value are never acceptable.
+			return syms
+		}
+		switch oobStyle {
+		case oobParen:
+			if strings.Contains(sym, "(") {
+				return syms
+			}
+		case oobNumber:
+			if sym == fmt.Sprint(actual) {
+				return syms
+			}
+		}
+		if sym == prev {
+			// If it's the same as the previous value, it might be "unknown"
+			// or something, so treat both it and the previous value as
+			// out-of-bounds.
+			return syms[0 : len(syms)-1]
+		}
+		syms = append(syms, sym)
+		prev = sym
+	}
+	// Too many values.
+	return nil
+}
+
+// sanitizeEnumSymbol returns a valid Avro enum symbol
+// for the name s by replacing all invalid characters with
+// underscores and prefixing a leading digit with an underscore.
+func sanitizeEnumSymbol(s string) string {
+	if isValidEnumSymbol(s) {
+		return s
+	}
+	var buf strings.Builder
+	if isDigit(s[0]) {
+		buf.WriteByte('_')
+	}
+	for _, r := range s {
+		if r < 0x80 && (r == '_' || isAlpha(byte(r)) || isDigit(byte(r))) {
+			buf.WriteRune(r)
+		} else {
+			buf.WriteByte('_')
+		}
+	}
+	return buf.String()
+}
+
+// From https://avro.apache.org/docs/1.9.1/spec.html#Enums :
+//
+//	Every symbol must match the regular expression [A-Za-z_][A-Za-z0-9_]*
+func isValidEnumSymbol(s string) bool {
+	if s == "" || s[0] != '_' && !isAlpha(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if c := s[i]; c != '_' && !isAlpha(c) && !isDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlpha(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,242 @@
+package typeinfo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heetch/avro/avrotypegen"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	nullType = reflect.TypeOf(avrotypegen.Null{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+// Fields returns the fields of the struct type t that are encoded as
+// Avro record fields, in the order that they're encoded.
+//
+// The fields of embedded (anonymous) struct fields are promoted
+// following the same rules as encoding/json: an embedded struct
+// without a name in its avro or json tag has its fields included as if
+// they were fields of t, and when several fields have the same name,
+// the least nested one is used. Unlike encoding/json, an error is
+// returned when there's no single such field, rather than silently
+// omitting the name.
+//
+// The Index field of each returned field holds its full index
+// sequence, suitable for reflect.Value.FieldByIndex.
+func Fields(t reflect.Type) ([]reflect.StructField, error) {
+	type candidate struct {
+		field  reflect.StructField
+		name   string
+		tagged bool
+	}
+	var candidates []candidate
+	// Embedded structs are explored breadth-first, as in encoding/json,
+	// so that fields are found in order of depth.
+	next := []reflect.StructField{{Type: t}}
+	visited := map[reflect.Type]bool{}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		// A struct type embedded more than once at the same
+		// depth is explored each time so that the resulting
+		// name conflicts are detected, but types found at
+		// lesser depths aren't explored again.
+		level := map[reflect.Type]bool{}
+		for _, outer := range current {
+			st := outer.Type
+			if visited[st] {
+				continue
+			}
+			level[st] = true
+			for i := 0; i < st.NumField(); i++ {
+				f := st.Field(i)
+				index := make([]int, len(outer.Index)+1)
+				copy(index, outer.Index)
+				index[len(outer.Index)] = i
+				f.Index = index
+				if f.Anonymous {
+					ft := f.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if !f.IsExported() && ft.Kind() != reflect.Struct {
+						// Ignore embedded fields of unexported non-struct types.
+						continue
+					}
+					// Embedded fields of unexported struct types
+					// are still considered because they may have
+					// exported fields.
+				} else if !f.IsExported() {
+					continue
+				}
+				tag, err := FieldTag(f)
+				if err != nil {
+					return nil, fmt.Errorf("invalid avro tag on field %s of %s: %v", fieldPath(t, f.Index), t, err)
+				}
+				if tag.Name == "" {
+					continue
+				}
+				name, tagged := tag.Name, tag.Named
+				if f.Anonymous && !tagged && isEmbeddableStruct(f.Type) {
+					if implementsAvroRecord(f.Type) {
+						return nil, fmt.Errorf("cannot embed %s in %s because it was generated by avrogo (use a named field instead)", f.Type, t)
+					}
+					if f.Type.Kind() == reflect.Ptr && !f.IsExported() {
+						return nil, fmt.Errorf("cannot embed pointer to unexported struct type %s in %s", f.Type.Elem(), t)
+					}
+					if f.Type.Kind() == reflect.Ptr {
+						f.Type = f.Type.Elem()
+					}
+					next = append(next, f)
+					continue
+				}
+				if !f.IsExported() {
+					// An unexported embedded field that isn't
+					// promoted can't be accessed.
+					continue
+				}
+				candidates = append(candidates, candidate{
+					field:  f,
+					name:   name,
+					tagged: tagged,
+				})
+			}
+		}
+		for st := range level {
+			visited[st] = true
+		}
+	}
+	// Group the candidates by name and choose the dominant
+	// field for each name.
+	byName := make(map[string][]candidate)
+	var names []string
+	for _, c := range candidates {
+		if byName[c.name] == nil {
+			names = append(names, c.name)
+		}
+		byName[c.name] = append(byName[c.name], c)
+	}
+	fields := make([]reflect.StructField, 0, len(names))
+	for _, name := range names {
+		cs := byName[name]
+		// The candidates are in breadth-first order,
+		// so the first ones are the least nested.
+		depth := len(cs[0].field.Index)
+		var dominant []candidate
+		for _, c := range cs {
+			if len(c.field.Index) > depth {
+				break
+			}
+			dominant = append(dominant, c)
+		}
+		if len(dominant) > 1 {
+			var tagged []candidate
+			for _, c := range dominant {
+				if c.tagged {
+					tagged = append(tagged, c)
+				}
+			}
+			if len(tagged) != 1 {
+				return nil, fmt.Errorf("ambiguous field name %q in %s (%s and %s)", name, t, fieldPath(t, dominant[0].field.Index), fieldPath(t, dominant[1].field.Index))
+			}
+			dominant = tagged
+		}
+		fields = append(fields, dominant[0].field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return indexLess(fields[i].Index, fields[j].Index)
+	})
+	return fields, nil
+}
+
+// isEmbeddableStruct reports whether an embedded field of type t
+// should have its fields promoted. Struct types that have a special
+// Avro representation are treated as ordinary fields.
+func isEmbeddableStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	switch t {
+	case timeType, nullType, uuidType:
+		return false
+	}
+	return true
+}
+
+func implementsAvroRecord(t reflect.Type) bool {
+	recordType := reflect.TypeOf((*avrotypegen.AvroRecord)(nil)).Elem()
+	return t.Implements(recordType) || t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(recordType)
+}
+
+// AvroRecordOf returns the avrotypegen.AvroRecord implementation of
+// t, or nil if t doesn't implement it. An AvroRecord method promoted
+// from an embedded field doesn't count, because the schema it returns
+// describes the embedded type, not t.
+func AvroRecordOf(t reflect.Type) avrotypegen.AvroRecord {
+	r, ok := reflect.Zero(t).Interface().(avrotypegen.AvroRecord)
+	if !ok {
+		return nil
+	}
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.Anonymous && implementsAvroRecord(f.Type) {
+				return nil
+			}
+		}
+	}
+	return r
+}
+
+// FieldByIndex returns the field of the struct v with the given
+// index sequence. Unlike reflect.Value.FieldByIndex, it allocates
+// a new value for any nil embedded struct pointer found on the way,
+// so v must be addressable if there are any.
+func FieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// fieldPath returns a description of the field of t
+// with the given index sequence, for example "Metadata.ID".
+func fieldPath(t reflect.Type, index []int) string {
+	path := ""
+	for i, x := range index {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		f := t.Field(x)
+		if i > 0 {
+			path += "."
+		}
+		path += f.Name
+		t = f.Type
+	}
+	return path
+}
+
+func indexLess(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
@@ -0,0 +1,198 @@
+package typeinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Tag holds the information about a struct field that's specified
+// by its "avro" and "json" struct tags.
+//
+// The "avro" tag has the form:
+//
+//	avro:"name,option,..."
+//
+// where name overrides the name in any "json" tag and each option
+// is one of:
+//
+//	omitempty
+//	default=value
+//	doc=text
+//	logical=logicalType
+//	aliases=name1|name2|...
+//	order=ascending|descending|ignore
+//
+// The default value is in Avro JSON format; a value that isn't valid
+// JSON is treated as a string, so enum symbols and strings don't need
+// to be quoted. An option value containing commas can be enclosed in
+// single quotes. Commas inside a JSON array, object or string in
+// a default value need no quoting. Quotes that don't start a value
+// are taken literally.
+type Tag struct {
+	// Name holds the Avro name of the field, or
+	// the empty string if the field is ignored.
+	Name string
+
+	// Named holds whether the name was taken from
+	// a struct tag rather than the Go field name.
+	Named bool
+
+	// OmitEmpty holds whether the omitempty option
+	// was specified in either the avro or the json tag.
+	OmitEmpty bool
+
+	// HasDefault holds whether a default was specified.
+	HasDefault bool
+
+	// Default holds the default value, as decoded by encoding/json.
+	Default interface{}
+
+	// Doc holds the documentation for the field.
+	Doc string
+
+	// Logical holds the logical type of the field.
+	Logical string
+
+	// Aliases holds any aliases for the field name.
+	Aliases []string
+
+	// Order holds the sort order of the field.
+	Order string
+}
+
+// FieldTag returns the tag information for the field f.
+func FieldTag(f reflect.StructField) (Tag, error) {
+	tag := Tag{
+		Name: f.Name,
+	}
+	jsonParts := strings.Split(f.Tag.Get("json"), ",")
+	for _, part := range jsonParts[1:] {
+		if part == "omitempty" {
+			tag.OmitEmpty = true
+		}
+	}
+	if name := jsonParts[0]; name != "" {
+		tag.Name, tag.Named = name, true
+	}
+	avroTag, ok := f.Tag.Lookup("avro")
+	if !ok {
+		if tag.Name == "-" {
+			tag.Name = ""
+		}
+		return tag, nil
+	}
+	parts, err := splitTag(avroTag)
+	if err != nil {
+		return Tag{}, err
+	}
+	switch name := parts[0]; name {
+	case "-":
+		return Tag{}, nil
+	case "":
+		if tag.Name == "-" {
+			// The json tag name is ignored
+			// only when there's no avro name.
+			tag.Name, tag.Named = f.Name, false
+		}
+	default:
+		tag.Name, tag.Named = name, true
+	}
+	for _, part := range parts[1:] {
+		key, val := part, ""
+		if i := strings.Index(part, "="); i >= 0 {
+			key, val = part[:i], unquoteTagValue(part[i+1:])
+		}
+		switch key {
+		case "omitempty":
+			tag.OmitEmpty = true
+			continue
+		case "default":
+			tag.HasDefault = true
+			if err := json.Unmarshal([]byte(val), &tag.Default); err != nil {
+				tag.Default = val
+			}
+		case "doc":
+			tag.Doc = val
+		case "logical":
+			tag.Logical = val
+		case "aliases":
+			tag.Aliases = strings.Split(val, "|")
+		case "order":
+			switch val {
+			case "ascending", "descending", "ignore":
+			default:
+				return Tag{}, fmt.Errorf("invalid order %q", val)
+			}
+			tag.Order = val
+		default:
+			return Tag{}, fmt.Errorf("unknown option %q", key)
+		}
+		if val == "" {
+			return Tag{}, fmt.Errorf("empty value for %s option", key)
+		}
+	}
+	return tag, nil
+}
+
+// splitTag splits an avro tag into its comma-separated parts,
+// ignoring commas inside single quotes, JSON strings, arrays
+// and objects. Quotes and brackets are only significant at the
+// start of an option value (or inside a JSON array or object),
+// so they can appear literally elsewhere, as in doc=user's name.
+func splitTag(tag string) ([]string, error) {
+	var parts []string
+	depth := 0
+	start := 0
+	// valueStart holds the index of the start of the
+	// value in the current part, or -1 if there's no
+	// value yet.
+	valueStart := -1
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		if depth == 0 {
+			switch {
+			case c == ',':
+				parts = append(parts, tag[start:i])
+				start = i + 1
+				valueStart = -1
+				continue
+			case c == '=' && valueStart == -1:
+				valueStart = i + 1
+				continue
+			case i != valueStart:
+				continue
+			}
+		}
+		switch c {
+		case '\'', '"':
+			if c == '\'' && depth > 0 {
+				// Single quotes have no meaning in JSON.
+				break
+			}
+			j := i + 1
+			for ; j < len(tag) && tag[j] != c; j++ {
+				if c == '"' && tag[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(tag) {
+				return nil, fmt.Errorf("unterminated quote in %q", tag)
+			}
+			i = j
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+	}
+	return append(parts, tag[start:]), nil
+}
+
+func unquoteTagValue(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
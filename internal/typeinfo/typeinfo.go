@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 	"reflect"
-	"strings"
 
 	"github.com/heetch/avro/avrotypegen"
 )
@@ -19,9 +18,10 @@ type Info struct {
 	// FieldName holds the Avro name of the field.
 	FieldName string
 
-	// FieldIndex holds the index of the field if this entry is about
-	// a struct field.
-	FieldIndex int
+	// FieldIndex holds the index sequence of the field if this
+	// entry is about a struct field. It has more than one element
+	// when the field is promoted from an embedded struct.
+	FieldIndex []int
 
 	// MakeDefault is a function that returns the default
 	// value for a field, or nil if there is no default value.
@@ -39,8 +39,50 @@ type Info struct {
 	Entries []Info
 }
 
+// Unions maps from interface types to the union information
+// used for them when it isn't provided by generated code.
+// A nil Unions value holds no entries.
+type Unions map[reflect.Type]avrotypegen.UnionInfo
+
+// lookup returns the union information for t, which may be an
+// interface type or a slice or map type with interface elements,
+// or the zero UnionInfo if there is none.
+func (u Unions) lookup(t reflect.Type) avrotypegen.UnionInfo {
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Interface {
+		return avrotypegen.UnionInfo{}
+	}
+	return u[t]
+}
+
+// Options holds information that affects the Info
+// for Go types that aren't described by generated code.
+type Options struct {
+	// Unions holds information on interface types.
+	Unions Unions
+
+	// NullableField, if non-nil, reports whether a struct
+	// field of type t with the given tag is represented
+	// as a ["null", T] union in which the zero value of
+	// T (nil for slices and maps) represents null.
+	NullableField func(t reflect.Type, tag Tag) bool
+
+	// SanitizeEnumSymbols holds whether enum names that
+	// aren't valid Avro symbols are sanitized.
+	// See EnumSymbols.
+	SanitizeEnumSymbols bool
+}
+
+// Nullable reports whether a field of type t with the
+// given tag is made nullable by o.
+func (o Options) Nullable(t reflect.Type, tag Tag) bool {
+	return o.NullableField != nil && o.NullableField(t, tag)
+}
+
 // ForType returns the Info for the given Go type.
-func ForType(t reflect.Type) (Info, error) {
+func ForType(t reflect.Type, opts Options) (Info, error) {
 	if debugging {
 		debugf("Info(%v)", t)
 	}
@@ -59,45 +101,59 @@ func ForType(t reflect.Type) (Info, error) {
 		// be caught earlier - when we try to determine the Avro schema
 		// from the Go type.
 		var r avrotypegen.RecordInfo
-		if v, ok := reflect.Zero(t).Interface().(avrotypegen.AvroRecord); ok {
+		if v := AvroRecordOf(t); v != nil {
 			r = v.AvroRecord()
+			// The generated schema determines
+			// which fields are nullable.
+			opts.NullableField = nil
 		}
-		for i := 0; i < t.NumField(); i++ {
-			f := t.Field(i)
-			if f.Anonymous {
-				// TODO consider struct embedding.
-				// https://github.com/heetch/avro/issues/40
-				return Info{}, fmt.Errorf("anonymous fields not supported")
-			}
-			if shouldOmitField(f) {
-				continue
-			}
+		fields, err := Fields(t)
+		if err != nil {
+			return Info{}, err
+		}
+		for _, f := range fields {
 			var required bool
 			var makeDefault func() reflect.Value
 			var unionInfo avrotypegen.UnionInfo
-			if i < len(r.Required) {
-				required = r.Required[i]
-			}
-			if i < len(r.Defaults) {
-				if md := r.Defaults[i]; md != nil {
-					makeDefault = func() reflect.Value {
-						return reflect.ValueOf(md())
+			// The RecordInfo slices are indexed by the position
+			// of the field within t, so they don't apply to
+			// promoted fields.
+			if len(f.Index) == 1 {
+				i := f.Index[0]
+				if i < len(r.Required) {
+					required = r.Required[i]
+				}
+				if i < len(r.Defaults) {
+					if md := r.Defaults[i]; md != nil {
+						makeDefault = func() reflect.Value {
+							return reflect.ValueOf(md())
+						}
 					}
 				}
+				if i < len(r.Unions) {
+					unionInfo = r.Unions[i]
+				}
 			}
-			if i < len(r.Unions) {
-				unionInfo = r.Unions[i]
+			entry, err := forField(f, required, makeDefault, unionInfo, opts)
+			if err != nil {
+				return Info{}, err
 			}
-			entry := forField(f, required, makeDefault, unionInfo)
 			info.Entries = append(info.Entries, entry)
 		}
 		if debugging {
 			debugf("-> record, %d entries", len(info.Entries))
 		}
 		return info, nil
+	case reflect.Interface:
+		info := Info{
+			Type: t,
+		}
+		setUnionInfo(&info, opts.Unions[t])
+		if debugging {
+			debugf("-> interface, %d entries", len(info.Entries))
+		}
+		return info, nil
 	default:
-		// TODO check for top-level union types too.
-		// See https://github.com/heetch/avro/issues/13
 		if debugging {
 			debugf("-> unknown")
 		}
@@ -107,8 +163,45 @@ func ForType(t reflect.Type) (Info, error) {
 	}
 }
 
-func forField(f reflect.StructField, required bool, makeDefault func() reflect.Value, unionInfo avrotypegen.UnionInfo) Info {
+// ForWrapper returns the Info for the value held in the
+// wrapper type t. It reports whether t implements
+// avrotypegen.AvroWrapper.
+func ForWrapper(t reflect.Type) (Info, bool) {
+	if t.Kind() != reflect.Struct || t.NumField() != 1 {
+		return Info{}, false
+	}
+	w, ok := reflect.Zero(t).Interface().(avrotypegen.AvroWrapper)
+	if !ok {
+		return Info{}, false
+	}
+	// The field is required, so there's no default that can fail.
+	info, _ := forField(t.Field(0), true, nil, w.AvroWrapper().Union, Options{})
+	return info, true
+}
+
+func forField(f reflect.StructField, required bool, makeDefault func() reflect.Value, unionInfo avrotypegen.UnionInfo, opts Options) (Info, error) {
 	t := f.Type
+	tag, err := FieldTag(f)
+	if err != nil {
+		return Info{}, err
+	}
+	if len(unionInfo.Union) == 0 {
+		unionInfo = opts.Unions.lookup(t)
+	}
+	if len(unionInfo.Union) == 0 && opts.Nullable(t, tag) {
+		// The zero value represents null, and
+		// the default (null) is implied by the union.
+		unionInfo.Union = []avrotypegen.UnionInfo{{
+			Type: nil,
+		}, {
+			Type: reflect.New(t).Interface(),
+		}}
+	} else if makeDefault == nil && !required {
+		makeDefault, err = tagDefault(t, tag, opts)
+		if err != nil {
+			return Info{}, fmt.Errorf("invalid default for field %s: %v", tag.Name, err)
+		}
+	}
 	if t.Kind() == reflect.Ptr && len(unionInfo.Union) == 0 {
 		// It's a pointer but there's no explicit union entry, which means that
 		// the union defaults to ["null", type]
@@ -144,15 +237,48 @@ func forField(f reflect.StructField, required bool, makeDefault func() reflect.V
 			return v
 		}
 	}
-	name, _ := JSONFieldName(f)
 	info := Info{
 		Type:        t,
-		FieldIndex:  f.Index[0],
-		FieldName:   name,
+		FieldIndex:  f.Index,
+		FieldName:   tag.Name,
 		MakeDefault: makeDefault,
 	}
 	setUnionInfo(&info, unionInfo)
-	return info
+	return info, nil
+}
+
+// tagDefault returns a function that makes the default value
+// specified by tag for a field of type t, or nil if there's
+// no such default.
+func tagDefault(t reflect.Type, tag Tag, opts Options) (func() reflect.Value, error) {
+	if !tag.HasDefault {
+		if t.Kind() != reflect.Struct || !isEmbeddableStruct(t) {
+			return nil, nil
+		}
+		// A struct value's fields might have their own defaults,
+		// including those of types generated by avrogo.
+		v, err := structDefault(t, opts)
+		if err != nil {
+			return nil, err
+		}
+		if v.IsZero() {
+			return nil, nil
+		}
+		return func() reflect.Value {
+			// Make a new value each time so that
+			// decoded values don't share any
+			// slices or maps.
+			v, _ := structDefault(t, opts)
+			return v
+		}, nil
+	}
+	if _, err := DefaultValue(t, tag.Default, opts); err != nil {
+		return nil, err
+	}
+	return func() reflect.Value {
+		v, _ := DefaultValue(t, tag.Default, opts)
+		return v
+	}, nil
 }
 
 func setUnionInfo(info *Info, unionInfo avrotypegen.UnionInfo) {
@@ -173,37 +299,6 @@ func setUnionInfo(info *Info, unionInfo avrotypegen.UnionInfo) {
 	}
 }
 
-func shouldOmitField(f reflect.StructField) bool {
-	name, _ := JSONFieldName(f)
-	return name == ""
-}
-
-// JSONFieldName returns the name that the field will be given
-// when marshaled to JSON, or the empty string if
-// the field is ignored.
-// It also reports whether the field has been qualified with
-// the "omitempty" qualifier.
-func JSONFieldName(f reflect.StructField) (name string, omitEmpty bool) {
-	if f.PkgPath != "" {
-		// It's unexported.
-		return "", false
-	}
-	tag := f.Tag.Get("json")
-	parts := strings.Split(tag, ",")
-	for _, part := range parts[1:] {
-		if part == "omitempty" {
-			omitEmpty = true
-		}
-	}
-	switch {
-	case parts[0] == "":
-		return f.Name, omitEmpty
-	case parts[0] == "-":
-		return "", omitEmpty
-	}
-	return parts[0], omitEmpty
-}
-
 const debugging = false
 
 func debugf(f string, a ...interface{}) {
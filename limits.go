@@ -0,0 +1,147 @@
+package avro
+
+import (
+	"fmt"
+	"math"
+)
+
+// DecodeLimits holds limits that are enforced when decoding Avro data.
+// They guard against malicious or corrupt messages that would otherwise
+// cause excessive memory or stack usage, for example by claiming
+// a multi-gigabyte string length.
+//
+// A zero field means that no limit applies, except that bytes and string
+// values are never allowed to be longer than math.MaxInt32.
+//
+// Unless Names.WithDecodeLimits has been used, decoding applies
+// a MaxDepth of 10000 and no other limits. This includes the
+// Unmarshal function and a SingleDecoder created with nil Names.
+type DecodeLimits struct {
+	// MaxBytesLength holds the maximum length of a single bytes
+	// or string value.
+	MaxBytesLength int64
+
+	// MaxBlockCount holds the maximum item count of a single
+	// array or map block.
+	MaxBlockCount int64
+
+	// MaxAllocation holds the maximum total number of bytes that
+	// will be allocated for bytes and string values and for array
+	// and map elements when decoding a single message.
+	MaxAllocation int64
+
+	// MaxDepth holds the maximum nesting depth of record fields,
+	// union values, array items and map values.
+	MaxDepth int
+}
+
+// LimitError is the error returned when decoding data exceeds
+// one of the limits in DecodeLimits.
+type LimitError struct {
+	// Limit holds the name of the DecodeLimits field
+	// that was exceeded.
+	Limit string
+
+	// Value holds the value that exceeded the limit.
+	Value int64
+
+	// Max holds the value of the limit.
+	Max int64
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("decode limit %s exceeded (%d > %d)", e.Limit, e.Value, e.Max)
+}
+
+// defaultDecodeLimits holds the limits that apply when none
+// have been set with Names.WithDecodeLimits. The depth limit
+// guards against exhausting the stack when decoding a recursive type.
+var defaultDecodeLimits = DecodeLimits{
+	MaxDepth: 10000,
+}
+
+// WithDecodeLimits returns a copy of n that applies the given limits
+// when decoding data through n (for example with n.Unmarshal or a
+// SingleDecoder created with n).
+//
+// The limits replace the default limits entirely, so
+// n.WithDecodeLimits(DecodeLimits{}) applies no limits at all.
+func (n *Names) WithDecodeLimits(limits DecodeLimits) *Names {
+	n1 := n.clone()
+	n1.decodeLimits = &limits
+	return n1
+}
+
+// limits returns the limits to apply when decoding through n.
+func (n *Names) limits() DecodeLimits {
+	if n.decodeLimits == nil {
+		return defaultDecodeLimits
+	}
+	return *n.decodeLimits
+}
+
+// checkBytesLength checks that a bytes or string value of
+// the given length can be decoded and accounts for its allocation.
+func (d *decoder) checkBytesLength(size int64) {
+	if size < 0 || size > math.MaxInt32 {
+		d.error(fmt.Errorf("length out of range: %d", size))
+	}
+	if max := d.limits.MaxBytesLength; max > 0 && size > max {
+		d.error(&LimitError{
+			Limit: "MaxBytesLength",
+			Value: size,
+			Max:   max,
+		})
+	}
+	d.alloc(size)
+}
+
+// checkBlockCount checks that an array or map block
+// with the given item count can be decoded.
+func (d *decoder) checkBlockCount(n int64) {
+	if n < 0 {
+		// This can happen when a negative count
+		// can't be negated.
+		d.error(fmt.Errorf("block count out of range: %d", n))
+	}
+	if max := d.limits.MaxBlockCount; max > 0 && n > max {
+		d.error(&LimitError{
+			Limit: "MaxBlockCount",
+			Value: n,
+			Max:   max,
+		})
+	}
+}
+
+// alloc accounts for the allocation of n bytes.
+func (d *decoder) alloc(n int64) {
+	d.allocated += n
+	if max := d.limits.MaxAllocation; max > 0 && d.allocated > max {
+		d.error(&LimitError{
+			Limit: "MaxAllocation",
+			Value: d.allocated,
+			Max:   max,
+		})
+	}
+}
+
+// descend is called when the decoder descends into a nested
+// value. It should be paired with a call to ascend.
+func (d *decoder) descend() {
+	d.depth++
+	if max := d.limits.MaxDepth; max > 0 && d.depth > max {
+		d.error(&LimitError{
+			Limit: "MaxDepth",
+			Value: int64(d.depth),
+			Max:   int64(max),
+		})
+	}
+}
+
+func (d *decoder) ascend() {
+	d.depth--
+	if d.trace {
+		d.path = d.path[:len(d.path)-1]
+	}
+}
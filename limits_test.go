@@ -0,0 +1,241 @@
+package avro_test
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+type limitsRecord struct {
+	S    string
+	B    []byte
+	A    []int
+	M    map[string]int
+	P    *limitsRecord
+	Next *limitsList
+}
+
+type limitsList struct {
+	X    int
+	Next *limitsList
+}
+
+var limitsTests = []struct {
+	testName    string
+	limits      avro.DecodeLimits
+	val         limitsRecord
+	expectLimit string
+}{{
+	testName: "NoLimits",
+	val: limitsRecord{
+		S: "hello",
+		B: []byte{},
+		A: []int{1, 2, 3},
+	},
+}, {
+	testName: "MaxBytesLengthString",
+	limits:   avro.DecodeLimits{MaxBytesLength: 4},
+	val: limitsRecord{
+		S: "hello",
+	},
+	expectLimit: "MaxBytesLength",
+}, {
+	testName: "MaxBytesLengthBytes",
+	limits:   avro.DecodeLimits{MaxBytesLength: 4},
+	val: limitsRecord{
+		B: []byte("abcdef"),
+	},
+	expectLimit: "MaxBytesLength",
+}, {
+	testName: "MaxBytesLengthOK",
+	limits:   avro.DecodeLimits{MaxBytesLength: 5},
+	val: limitsRecord{
+		S: "hello",
+		B: []byte("abcde"),
+	},
+}, {
+	testName: "MaxBlockCountArray",
+	limits:   avro.DecodeLimits{MaxBlockCount: 2},
+	val: limitsRecord{
+		A: []int{1, 2, 3},
+	},
+	expectLimit: "MaxBlockCount",
+}, {
+	testName: "MaxBlockCountMap",
+	limits:   avro.DecodeLimits{MaxBlockCount: 2},
+	val: limitsRecord{
+		M: map[string]int{"a": 1, "b": 2, "c": 3},
+	},
+	expectLimit: "MaxBlockCount",
+}, {
+	testName: "MaxAllocation",
+	limits:   avro.DecodeLimits{MaxAllocation: 100},
+	val: limitsRecord{
+		A: make([]int, 20),
+	},
+	expectLimit: "MaxAllocation",
+}, {
+	testName: "MaxDepth",
+	limits:   avro.DecodeLimits{MaxDepth: 5},
+	val: limitsRecord{
+		Next: &limitsList{Next: &limitsList{Next: &limitsList{}}},
+	},
+	expectLimit: "MaxDepth",
+}, {
+	testName: "MaxDepthOK",
+	limits:   avro.DecodeLimits{MaxDepth: 20},
+	val: limitsRecord{
+		B:    []byte{},
+		Next: &limitsList{Next: &limitsList{Next: &limitsList{}}},
+	},
+}}
+
+func TestDecodeLimits(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range limitsTests {
+		c.Run(test.testName, func(c *qt.C) {
+			data, wType, err := avro.Marshal(test.val)
+			c.Assert(err, qt.IsNil)
+			names := new(avro.Names).WithDecodeLimits(test.limits)
+			var x limitsRecord
+			_, err = names.Unmarshal(data, &x, wType)
+			if test.expectLimit == "" {
+				c.Assert(err, qt.IsNil)
+				c.Assert(x, qt.DeepEquals, test.val)
+				return
+			}
+			var limitErr *avro.LimitError
+			c.Assert(errors.As(err, &limitErr), qt.IsTrue, qt.Commentf("error: %v", err))
+			c.Assert(limitErr.Limit, qt.Equals, test.expectLimit)
+
+			// The global namespace applies only the default limits.
+			_, err = avro.Unmarshal(data, &x, wType)
+			c.Assert(err, qt.IsNil)
+		})
+	}
+}
+
+func TestDecodeLimitsDoNotAffectOriginalNames(t *testing.T) {
+	c := qt.New(t)
+	names := new(avro.Names)
+	limited := names.WithDecodeLimits(avro.DecodeLimits{MaxBytesLength: 1})
+	data, wType, err := avro.Marshal(limitsRecord{S: "hello"})
+	c.Assert(err, qt.IsNil)
+	var x limitsRecord
+	_, err = names.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.IsNil)
+	_, err = limited.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.ErrorMatches, `limitsRecord.S \(offset 1\): decode limit MaxBytesLength exceeded \(5 > 1\)`)
+}
+
+func TestDefaultDecodeLimits(t *testing.T) {
+	c := qt.New(t)
+	// Each list element adds two levels of nesting:
+	// the Next field and the union inside it.
+	var list *limitsList
+	for i := 0; i < 6000; i++ {
+		list = &limitsList{X: i, Next: list}
+	}
+	data, wType, err := avro.Marshal(limitsRecord{
+		B:    []byte{},
+		Next: list,
+	})
+	c.Assert(err, qt.IsNil)
+
+	var x limitsRecord
+	_, err = avro.Unmarshal(data, &x, wType)
+	var limitErr *avro.LimitError
+	c.Assert(errors.As(err, &limitErr), qt.IsTrue, qt.Commentf("error: %v", err))
+	c.Assert(limitErr.Limit, qt.Equals, "MaxDepth")
+	c.Assert(limitErr.Max, qt.Equals, int64(10000))
+
+	// Zero limits turn off the default limits.
+	names := new(avro.Names).WithDecodeLimits(avro.DecodeLimits{})
+	_, err = names.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(x.Next.X, qt.Equals, 5999)
+}
+
+func TestDecodeLargeLength(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		S string
+	}
+	wType, err := avro.TypeOf(R{})
+	c.Assert(err, qt.IsNil)
+	// A string claiming to be ~1GB long but with no content
+	// should fail without allocating the memory.
+	data := []byte{0x80, 0x80, 0x80, 0x80, 0x08}
+	var x R
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.ErrorMatches, `R.S \(offset 5\): unexpected EOF`)
+
+	// Out of range lengths are always rejected.
+	data = []byte{0x80, 0x80, 0x80, 0x80, 0x10}
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.ErrorMatches, `R.S \(offset 5\): length out of range: 2147483648`)
+
+	// So are negative block counts that cannot be negated.
+	type E struct{}
+	type A struct {
+		A []E
+	}
+	wType, err = avro.TypeOf(A{})
+	c.Assert(err, qt.IsNil)
+	data = []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 0x00}
+	var a A
+	_, err = avro.Unmarshal(data, &a, wType)
+	c.Assert(err, qt.ErrorMatches, `A.A \(offset 11\): block count out of range: -9223372036854775808`)
+}
+
+func TestDecodeIntOutOfRange(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		X int32
+	}
+	wType, err := avro.TypeOf(R{})
+	c.Assert(err, qt.IsNil)
+	// The maximum int32 value plus one.
+	data := []byte{0x80, 0x80, 0x80, 0x80, 0x10}
+	var x R
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.ErrorMatches, `R.X \(offset 5\): int value 2147483648 out of range`)
+
+	type R8 struct {
+		X int8
+	}
+	data, wType, err = avro.Marshal(R{1000})
+	c.Assert(err, qt.IsNil)
+	var x8 R8
+	_, err = avro.Unmarshal(data, &x8, wType)
+	c.Assert(err, qt.ErrorMatches, `R8.X \(offset 2\): value 1000 out of range for int8`)
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	wType, err := avro.TypeOf(limitsRecord{})
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, test := range limitsTests {
+		data, _, err := avro.Marshal(test.val)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	names := new(avro.Names).WithDecodeLimits(avro.DecodeLimits{
+		MaxBytesLength: 1 << 20,
+		MaxBlockCount:  1 << 16,
+		MaxAllocation:  1 << 24,
+		MaxDepth:       100,
+	})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var x limitsRecord
+		// We don't mind whether there's an error or not,
+		// but decoding should never panic.
+		names.Unmarshal(data, &x, wType)
+	})
+}
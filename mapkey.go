@@ -0,0 +1,87 @@
+package avro
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Map keys are always strings in Avro. As with encoding/json,
+// Go maps can also have integer keys or keys that implement
+// encoding.TextMarshaler and encoding.TextUnmarshaler, which
+// are converted to and from their textual representation.
+
+// canEncodeMapKey reports whether a map with key type t can be encoded.
+func canEncodeMapKey(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t.Implements(textMarshalerType) || isIntegerKind(t.Kind())
+}
+
+// canDecodeMapKey reports whether a map with key type t can be decoded.
+func canDecodeMapKey(t reflect.Type) bool {
+	return t.Kind() == reflect.String || reflect.PointerTo(t).Implements(textUnmarshalerType) || isIntegerKind(t.Kind())
+}
+
+func isIntegerKind(k reflect.Kind) bool {
+	return reflect.Int <= k && k <= reflect.Uintptr
+}
+
+// mapKeyString returns the Avro map key for the Go map key k.
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		text, err := tm.MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("cannot marshal map key: %v", err)
+		}
+		return string(text), nil
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unexpected map key type %s", k.Type())
+}
+
+// mapKeyValue returns the Go map key of type t for the Avro map key s.
+func mapKeyValue(t reflect.Type, s string) (reflect.Value, error) {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		k := reflect.New(t)
+		if err := k.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot unmarshal map key %q: %v", s, err)
+		}
+		return k.Elem(), nil
+	}
+	k := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		k.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid map key %q for %s", s, t)
+		}
+		k.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid map key %q for %s", s, t)
+		}
+		k.SetUint(n)
+	default:
+		return reflect.Value{}, fmt.Errorf("unexpected map key type %s", t)
+	}
+	return k, nil
+}
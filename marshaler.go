@@ -0,0 +1,171 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// AvroRepresenter is implemented by types that are represented in
+// Avro as a value of some other Go type. A type implementing
+// AvroRepresenter should also implement AvroMarshaler to allow
+// it to be encoded and AvroUnmarshaler to allow it to be decoded.
+//
+// For example, a Money type might be represented as a string
+// holding a decimal number:
+//
+//	func (Money) AvroRepresentation() avro.Representation {
+//		return avro.Representation{
+//			Type: new(string),
+//		}
+//	}
+//
+//	func (m Money) MarshalAvro() (interface{}, error) {
+//		return m.String(), nil
+//	}
+//
+//	func (m *Money) UnmarshalAvro(x interface{}) error {
+//		return m.parse(x.(string))
+//	}
+type AvroRepresenter interface {
+	AvroRepresentation() Representation
+}
+
+// Representation describes the way that a type implementing
+// AvroRepresenter is represented in Avro.
+type Representation struct {
+	// Type holds a pointer to a value of the Go type that the value
+	// is converted to and from. For example, new(string) specifies
+	// that the value is represented as a string. The type must
+	// not be a pointer or interface type.
+	Type interface{}
+
+	// Schema optionally holds the Avro schema to use for the
+	// value. It must be compatible with the encoding of the
+	// representation type; for example it might be
+	// {"type": "string", "logicalType": "ulid"} for a type
+	// represented as a string. If it's empty, TypeOf of the
+	// representation type will be used.
+	//
+	// Union schemas are not allowed.
+	Schema string
+}
+
+// AvroMarshaler is implemented by types that can marshal
+// themselves as their Avro representation.
+type AvroMarshaler interface {
+	AvroRepresenter
+
+	// MarshalAvro returns the value to be encoded
+	// in place of the receiver. The value must be of the type
+	// specified by the Type field of the representation.
+	MarshalAvro() (interface{}, error)
+}
+
+// AvroUnmarshaler is implemented by types that can unmarshal
+// themselves from their Avro representation.
+type AvroUnmarshaler interface {
+	AvroRepresenter
+
+	// UnmarshalAvro is called with a value of the type
+	// specified by the Type field of the representation
+	// and should set the receiver from it.
+	UnmarshalAvro(x interface{}) error
+}
+
+var (
+	avroRepresenterType = reflect.TypeOf((*AvroRepresenter)(nil)).Elem()
+	avroMarshalerType   = reflect.TypeOf((*AvroMarshaler)(nil)).Elem()
+	avroUnmarshalerType = reflect.TypeOf((*AvroUnmarshaler)(nil)).Elem()
+)
+
+// representationOf returns the representation of values of type t
+// and the Go type of that representation. It reports whether t
+// implements AvroRepresenter (either directly or with a pointer
+// receiver).
+func representationOf(t reflect.Type) (Representation, reflect.Type, bool, error) {
+	if t == nil || t.Kind() == reflect.Interface || !reflect.PtrTo(t).Implements(avroRepresenterType) {
+		return Representation{}, nil, false, nil
+	}
+	repr := reflect.New(t).Interface().(AvroRepresenter).AvroRepresentation()
+	if repr.Type == nil {
+		return Representation{}, nil, true, fmt.Errorf("no representation type specified for %s", t)
+	}
+	rt := reflect.TypeOf(repr.Type)
+	if rt.Kind() != reflect.Ptr {
+		return Representation{}, nil, true, fmt.Errorf("representation type for %s is %s, not a pointer", t, rt)
+	}
+	rt = rt.Elem()
+	switch {
+	case rt == t:
+		return Representation{}, nil, true, fmt.Errorf("%s cannot be represented as itself", t)
+	case rt.Kind() == reflect.Ptr || rt.Kind() == reflect.Interface:
+		return Representation{}, nil, true, fmt.Errorf("representation type %s for %s is not allowed", rt, t)
+	}
+	if _, _, ok, _ := representationOf(rt); ok {
+		return Representation{}, nil, true, fmt.Errorf("representation type %s for %s cannot itself implement AvroRepresenter", rt, t)
+	}
+	return repr, rt, true, nil
+}
+
+// schemaForRepresentation returns the schema for the type t
+// which has the given representation.
+func (gts *goTypeSchema) schemaForRepresentation(t reflect.Type, repr Representation, rt reflect.Type) (interface{}, error) {
+	if repr.Schema == "" {
+		return gts.schemaForGoType(rt)
+	}
+	var s interface{}
+	if err := json.Unmarshal([]byte(repr.Schema), &s); err != nil {
+		return nil, fmt.Errorf("invalid schema for %s: %v", t, err)
+	}
+	switch s := s.(type) {
+	case []interface{}:
+		return nil, fmt.Errorf("union schema for %s not allowed", t)
+	case map[string]interface{}:
+		switch s["type"] {
+		case "record", "enum", "fixed":
+			return gts.define(t, s, "")
+		}
+	}
+	return s, nil
+}
+
+type marshalerEncoder struct {
+	reprType reflect.Type
+	enc      encoderFunc
+}
+
+func (me marshalerEncoder) encode(e *encodeState, v reflect.Value) {
+	var m AvroMarshaler
+	if v.Type().Implements(avroMarshalerType) {
+		m = v.Interface().(AvroMarshaler)
+	} else if v.CanAddr() {
+		m = v.Addr().Interface().(AvroMarshaler)
+	} else {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		m = p.Interface().(AvroMarshaler)
+	}
+	x, err := m.MarshalAvro()
+	if err != nil {
+		e.error(err)
+	}
+	xv := reflect.ValueOf(x)
+	if !xv.IsValid() || xv.Type() != me.reprType {
+		e.error(fmt.Errorf("MarshalAvro returned %T, not %s", x, me.reprType))
+	}
+	me.enc(e, xv)
+}
+
+// evalUnmarshal evaluates the program into a new value of the
+// representation type rt and unmarshals that into dst
+// using dst's UnmarshalAvro method.
+func (d *decoder) evalUnmarshal(dst reflect.Value, rt reflect.Type) {
+	rv := reflect.New(rt).Elem()
+	d.eval(rv)
+	u := reflect.New(dst.Type())
+	if err := u.Interface().(AvroUnmarshaler).UnmarshalAvro(rv.Interface()); err != nil {
+		d.error(err)
+	}
+	dst.Set(u.Elem())
+}
@@ -0,0 +1,256 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+// money represents an amount of money in cents.
+// It's represented in Avro as a decimal string.
+type money int64
+
+func (money) AvroRepresentation() avro.Representation {
+	return avro.Representation{
+		Type: new(string),
+	}
+}
+
+func (m money) MarshalAvro() (interface{}, error) {
+	return fmt.Sprintf("%d.%02d", m/100, m%100), nil
+}
+
+func (m *money) UnmarshalAvro(x interface{}) error {
+	s := x.(string)
+	i := strings.Index(s, ".")
+	if i == -1 {
+		return fmt.Errorf("invalid money value %q", s)
+	}
+	n, err := strconv.ParseInt(s[:i]+s[i+1:], 10, 64)
+	if err != nil {
+		return err
+	}
+	*m = money(n)
+	return nil
+}
+
+// ulid is represented with an explicit schema
+// with a logical type.
+type ulid [2]uint64
+
+func (ulid) AvroRepresentation() avro.Representation {
+	return avro.Representation{
+		Type:   new(string),
+		Schema: `{"type": "string", "logicalType": "ulid"}`,
+	}
+}
+
+func (u ulid) MarshalAvro() (interface{}, error) {
+	return fmt.Sprintf("%016x%016x", u[0], u[1]), nil
+}
+
+func (u *ulid) UnmarshalAvro(x interface{}) error {
+	_, err := fmt.Sscanf(x.(string), "%016x%016x", &u[0], &u[1])
+	return err
+}
+
+// point is represented as a record.
+type point string
+
+type pointRepr struct {
+	X int
+	Y int
+}
+
+func (*point) AvroRepresentation() avro.Representation {
+	return avro.Representation{
+		Type: new(pointRepr),
+	}
+}
+
+func (p *point) MarshalAvro() (interface{}, error) {
+	var r pointRepr
+	_, err := fmt.Sscanf(string(*p), "%d,%d", &r.X, &r.Y)
+	return r, err
+}
+
+func (p *point) UnmarshalAvro(x interface{}) error {
+	r := x.(pointRepr)
+	*p = point(fmt.Sprintf("%d,%d", r.X, r.Y))
+	return nil
+}
+
+type marshalerRecord struct {
+	M     money
+	MP    *money
+	MS    []money
+	MM    map[string]money
+	U     ulid
+	P     point
+	Other string
+}
+
+func TestMarshalerRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	mp := money(1234)
+	x := marshalerRecord{
+		M:  10099,
+		MP: &mp,
+		MS: []money{1, 2},
+		MM: map[string]money{
+			"a": 300,
+		},
+		U:     ulid{1, 2},
+		P:     "3,4",
+		Other: "x",
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.IsNil)
+	c.Assert(wType.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "marshalerRecord",
+		"fields": [{
+			"name": "M",
+			"type": "string",
+			"default": ""
+		}, {
+			"name": "MP",
+			"type": ["null", "string"],
+			"default": null
+		}, {
+			"name": "MS",
+			"type": {"type": "array", "items": "string"},
+			"default": []
+		}, {
+			"name": "MM",
+			"type": {"type": "map", "values": "string"},
+			"default": {}
+		}, {
+			"name": "U",
+			"type": {"type": "string", "logicalType": "ulid"},
+			"default": ""
+		}, {
+			"name": "P",
+			"type": {
+				"type": "record",
+				"name": "pointRepr",
+				"fields": [
+					{"name": "X", "type": "long", "default": 0},
+					{"name": "Y", "type": "long", "default": 0}
+				]
+			},
+			"default": {"X": 0, "Y": 0}
+		}, {
+			"name": "Other",
+			"type": "string",
+			"default": ""
+		}]
+	}`))
+
+	// Check that the data is encoded as the representation.
+	type plainRecord struct {
+		M     string
+		MP    *string
+		MS    []string
+		MM    map[string]string
+		U     string
+		P     pointRepr
+		Other string
+	}
+	var plain plainRecord
+	_, err = new(avro.Names).RenameType(plainRecord{}, "marshalerRecord").Unmarshal(data, &plain, wType)
+	c.Assert(err, qt.IsNil)
+	mps := "12.34"
+	c.Assert(plain, qt.DeepEquals, plainRecord{
+		M:  "100.99",
+		MP: &mps,
+		MS: []string{"0.01", "0.02"},
+		MM: map[string]string{
+			"a": "3.00",
+		},
+		U:     "00000000000000010000000000000002",
+		P:     pointRepr{3, 4},
+		Other: "x",
+	})
+
+	var x1 marshalerRecord
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(x1, qt.DeepEquals, x)
+}
+
+func TestMarshalerTopLevel(t *testing.T) {
+	c := qt.New(t)
+	data, wType, err := avro.Marshal(money(512))
+	c.Assert(err, qt.IsNil)
+	c.Assert(wType.String(), qt.Equals, `"string"`)
+	c.Assert(data, qt.DeepEquals, []byte("\x085.12"))
+	var m money
+	_, err = avro.Unmarshal(data, &m, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(m, qt.Equals, money(512))
+}
+
+func TestUnmarshalAvroError(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		M string
+	}
+	data, wType, err := avro.Marshal(R{M: "bad"})
+	c.Assert(err, qt.IsNil)
+	type R1 struct {
+		M money
+	}
+	var x R1
+	_, err = new(avro.Names).RenameType(R1{}, "R").Unmarshal(data, &x, wType)
+	c.Assert(err, qt.ErrorMatches, `R.M \(offset 4\): invalid money value "bad"`)
+}
+
+type badMarshaler int
+
+func (badMarshaler) AvroRepresentation() avro.Representation {
+	return avro.Representation{
+		Type: new(string),
+	}
+}
+
+func (badMarshaler) MarshalAvro() (interface{}, error) {
+	return 1, nil
+}
+
+func TestMarshalAvroBadType(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		B badMarshaler
+	}
+	_, _, err := avro.Marshal(R{})
+	c.Assert(err, qt.ErrorMatches, `R.B: MarshalAvro returned int, not string`)
+
+	// badMarshaler doesn't implement AvroUnmarshaler.
+	wType, err := avro.TypeOf(R{})
+	c.Assert(err, qt.IsNil)
+	var x R
+	_, err = avro.Unmarshal([]byte{0}, &x, wType)
+	c.Assert(err, qt.ErrorMatches, `analysis failed: .*badMarshaler does not implement AvroUnmarshaler`)
+}
+
+type badRepresentation int
+
+func (badRepresentation) AvroRepresentation() avro.Representation {
+	return avro.Representation{
+		Type:   new(string),
+		Schema: `["null", "string"]`,
+	}
+}
+
+func TestRepresentationUnionNotAllowed(t *testing.T) {
+	c := qt.New(t)
+	_, err := avro.TypeOf(badRepresentation(0))
+	c.Assert(err, qt.ErrorMatches, `union schema for avro_test.badRepresentation not allowed`)
+}
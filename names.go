@@ -7,15 +7,38 @@ import (
 
 	"github.com/actgardner/gogen-avro/v10/parser"
 	"github.com/actgardner/gogen-avro/v10/schema"
+
+	"github.com/heetch/avro/avrotypegen"
+	"github.com/heetch/avro/internal/typeinfo"
 )
 
 // Names represents a namespace that can rename schema names.
+// It also holds other options that affect the way that Go values
+// are encoded and decoded.
 // The zero value of a Names is the empty namespace.
 type Names struct {
 	// renames maps from an original Avro schema fully qualified
 	// name to the new name and aliases for that name.
 	renames map[string][]string
 
+	// decodeLimits holds the limits to apply when decoding,
+	// or nil if defaultDecodeLimits apply.
+	decodeLimits *DecodeLimits
+
+	// unions maps from interface type to the union
+	// registered for it with RegisterUnion.
+	unions typeinfo.Unions
+
+	// nullableFields holds whether optional fields are
+	// represented as ["null", T] unions.
+	// See WithNullableFields.
+	nullableFields bool
+
+	// sanitizeEnumSymbols holds whether enum names that
+	// aren't valid Avro symbols are sanitized.
+	// See WithSanitizedEnumSymbols.
+	sanitizeEnumSymbols bool
+
 	// avroTypes is effectively a map[reflect.Type]*Type
 	// that holds Avro types for Go types that specify the schema
 	// entirely. Go types that don't fully specify a schema must be resolved
@@ -51,6 +74,10 @@ func (names *Names) Marshal(x interface{}) ([]byte, *Type, error) {
 // If aliases aren't full names, their namespace will be taken from
 // the namespace of newName.
 //
+// When decoding, a writer schema that uses one of the aliases
+// is read as if it used newName, so data written with the
+// old name can be read into the renamed type.
+//
 // If n already includes a rename for oldName, the old association
 // will be overwritten.
 //
@@ -70,19 +97,162 @@ func (n *Names) Rename(oldName string, newName string, newAliases ...string) *Na
 	if builtinTypes[oldName] {
 		panic(fmt.Errorf("rename of built-in type %q to %q", oldName, newName))
 	}
+	n1 := n.clone()
+	newNames := make([]string, 1+len(newAliases))
+	newNames[0] = newName
+	copy(newNames[1:], newAliases)
+	n1.renames[oldName] = newNames
+	return n1
+}
+
+// clone returns a copy of n with the same options but
+// without any of the cached type information.
+func (n *Names) clone() *Names {
 	n1 := &Names{
-		renames: make(map[string][]string),
+		renames:             make(map[string][]string),
+		decodeLimits:        n.decodeLimits,
+		unions:              make(typeinfo.Unions),
+		nullableFields:      n.nullableFields,
+		sanitizeEnumSymbols: n.sanitizeEnumSymbols,
 	}
 	for name, names := range n.renames {
 		n1.renames[name] = names
 	}
-	newNames := make([]string, 1+len(newAliases))
-	newNames[0] = newName
-	copy(newNames[1:], newAliases)
-	n1.renames[oldName] = newNames
+	for t, u := range n.unions {
+		n1.unions[t] = u
+	}
+	return n1
+}
+
+// RegisterUnion returns a copy of n that represents values of
+// an interface type as an Avro union, so that the interface type
+// can be used in Go types that weren't generated by avrogo.
+//
+// The iface argument must be a nil pointer to the interface type,
+// for example (*Shape)(nil). Each member holds a value of one of
+// the union's member types in the order they appear in the union,
+// for example Circle{}. A nil member stands for the Avro null type,
+// which is represented by a nil interface value.
+//
+// When encoding, the member is chosen by the dynamic type of the
+// interface value, so a value of a type not registered as a
+// member will fail to encode.
+//
+// If RegisterUnion has already been called for the interface
+// type, the old association will be overwritten.
+//
+// RegisterUnion panics if iface isn't a pointer to an interface type,
+// if a member type doesn't implement the interface or is a pointer
+// type, or if a member type is given more than once.
+func (n *Names) RegisterUnion(iface interface{}, members ...interface{}) *Names {
+	it := reflect.TypeOf(iface)
+	if it == nil || it.Kind() != reflect.Ptr || it.Elem().Kind() != reflect.Interface {
+		panic(fmt.Errorf("cannot register union for %T: not a pointer to an interface type", iface))
+	}
+	it = it.Elem()
+	if len(members) == 0 {
+		panic(fmt.Errorf("cannot register union for %s: no member types", it))
+	}
+	u := avrotypegen.UnionInfo{
+		Type:  iface,
+		Union: make([]avrotypegen.UnionInfo, len(members)),
+	}
+	found := make(map[reflect.Type]bool)
+	for i, m := range members {
+		mt := reflect.TypeOf(m)
+		if found[mt] {
+			panic(fmt.Errorf("cannot register union for %s: duplicate member type %v", it, mt))
+		}
+		found[mt] = true
+		if mt == nil {
+			continue
+		}
+		if mt.Kind() == reflect.Ptr {
+			panic(fmt.Errorf("cannot register union for %s: member type %s is a pointer", it, mt))
+		}
+		if !mt.Implements(it) {
+			panic(fmt.Errorf("cannot register union for %s: member type %s does not implement it", it, mt))
+		}
+		u.Union[i].Type = reflect.New(mt).Interface()
+	}
+	n1 := n.clone()
+	n1.unions[it] = u
+	return n1
+}
+
+// WithNullableFields returns a copy of n that represents optional
+// fields of Go struct types as Avro unions of null and the field's
+// type, with a default of null. This makes it possible to distinguish
+// absent values from empty ones, and makes schemas derived from
+// Go types safer to evolve.
+//
+// A field is optional if it has a slice or map type, or if its
+// avro or json tag specifies omitempty and it isn't a pointer
+// (pointer fields are always represented as nullable unions).
+// Slice and map fields are encoded as null when they're nil;
+// other fields are encoded as null when they're the zero value.
+// A null value decodes as the zero value.
+//
+// Fields with a non-null default specified in their avro tag,
+// fields of interface type and fields of types that
+// implement AvroRepresenter are never made nullable, and
+// the representation of types generated by avrogo is unaffected.
+func (n *Names) WithNullableFields() *Names {
+	n1 := n.clone()
+	n1.nullableFields = true
+	return n1
+}
+
+// WithSanitizedEnumSymbols returns a copy of n that treats Go
+// integer types with String methods that return names that
+// aren't valid Avro enum symbols, such as "in-progress", as
+// enums anyway. Each invalid character in a name is replaced
+// with an underscore, and a name starting with a digit is
+// prefixed with an underscore, so "in-progress" becomes the
+// symbol "in_progress".
+//
+// If two names sanitize to the same symbol, the type
+// is encoded as an integer as usual.
+//
+// Without this option, such types are encoded as integers,
+// because the names can't be used as symbols.
+func (n *Names) WithSanitizedEnumSymbols() *Names {
+	n1 := n.clone()
+	n1.sanitizeEnumSymbols = true
 	return n1
 }
 
+// typeinfoOptions returns the options to use when
+// determining type information for Go types.
+func (n *Names) typeinfoOptions() typeinfo.Options {
+	opts := typeinfo.Options{
+		Unions:              n.unions,
+		SanitizeEnumSymbols: n.sanitizeEnumSymbols,
+	}
+	if n.nullableFields {
+		opts.NullableField = isNullableField
+	}
+	return opts
+}
+
+// isNullableField reports whether a field of type t with the given
+// tag is optional, as described in WithNullableFields.
+func isNullableField(t reflect.Type, tag typeinfo.Tag) bool {
+	if tag.HasDefault && tag.Default != nil {
+		return false
+	}
+	if _, _, ok, _ := representationOf(t); ok {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return false
+	case reflect.Slice, reflect.Map:
+		return true
+	}
+	return tag.OmitEmpty && t != nullType
+}
+
 // RenameType returns a copy of n that uses the given name
 // and aliases for the type of x.
 //
@@ -342,3 +342,263 @@ func TestRenameTypeNonDefinition(t *testing.T) {
 		new(avro.Names).RenameType("", "myString")
 	}, qt.PanicMatches, `cannot rename string to "myString": it does not represent an Avro definition`)
 }
+
+type Shape interface {
+	isShape()
+}
+
+type Circle struct {
+	Radius float64
+}
+
+func (Circle) isShape() {}
+
+type Square struct {
+	Side float64
+}
+
+func (Square) isShape() {}
+
+type Triangle struct{}
+
+func (Triangle) isShape() {}
+
+func TestRegisterUnion(t *testing.T) {
+	c := qt.New(t)
+	type Drawing struct {
+		Main   Shape
+		All    []Shape
+		ByName map[string]Shape
+	}
+	names := new(avro.Names).RegisterUnion((*Shape)(nil), Circle{}, nil, Square{})
+	at, err := names.TypeOf(Drawing{})
+	c.Assert(err, qt.IsNil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "Drawing",
+		"fields": [{
+			"name": "Main",
+			"type": [{
+				"type": "record",
+				"name": "Circle",
+				"fields": [{
+					"name": "Radius",
+					"type": "double",
+					"default": 0
+				}]
+			}, "null", {
+				"type": "record",
+				"name": "Square",
+				"fields": [{
+					"name": "Side",
+					"type": "double",
+					"default": 0
+				}]
+			}],
+			"default": {"Radius": 0}
+		}, {
+			"name": "All",
+			"type": {
+				"type": "array",
+				"items": ["Circle", "null", "Square"]
+			},
+			"default": []
+		}, {
+			"name": "ByName",
+			"type": {
+				"type": "map",
+				"values": ["Circle", "null", "Square"]
+			},
+			"default": {}
+		}]
+	}`))
+
+	d := Drawing{
+		Main: Square{Side: 2},
+		All:  []Shape{Circle{Radius: 1}, nil, Square{Side: 3}},
+		ByName: map[string]Shape{
+			"c": Circle{Radius: 4},
+			"n": nil,
+		},
+	}
+	data, wType, err := names.Marshal(d)
+	c.Assert(err, qt.IsNil)
+	var d1 Drawing
+	_, err = names.Unmarshal(data, &d1, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(d1, qt.DeepEquals, d)
+
+	// A value of a type that isn't a member fails to encode.
+	_, _, err = names.Marshal(Drawing{
+		Main: Triangle{},
+	})
+	c.Assert(err, qt.ErrorMatches, `Drawing.Main: unknown type for union avro_test.Triangle`)
+
+	// The union is only known to the Names it was registered with.
+	_, err = avro.TypeOf(Drawing{})
+	c.Assert(err, qt.ErrorMatches, `interface types \(avro_test.Shape\) not yet supported \(use avrogo or Names.RegisterUnion instead\)`)
+}
+
+func TestRegisterUnionDefault(t *testing.T) {
+	c := qt.New(t)
+	// When the writer schema doesn't have an interface-typed
+	// field, it's set to the zero value of the first member.
+	type W struct {
+		A int
+	}
+	type R struct {
+		A     int
+		Shape Shape
+	}
+	names := new(avro.Names).RegisterUnion((*Shape)(nil), Circle{}, Square{})
+	data, wType, err := names.Marshal(W{A: 1})
+	c.Assert(err, qt.IsNil)
+	var r R
+	_, err = names.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r, qt.DeepEquals, R{
+		A:     1,
+		Shape: Circle{},
+	})
+}
+
+func TestRegisterUnionPanics(t *testing.T) {
+	c := qt.New(t)
+	names := new(avro.Names)
+	c.Assert(func() {
+		names.RegisterUnion(Circle{}, Circle{})
+	}, qt.PanicMatches, `cannot register union for avro_test.Circle: not a pointer to an interface type`)
+	c.Assert(func() {
+		names.RegisterUnion((*Shape)(nil))
+	}, qt.PanicMatches, `cannot register union for avro_test.Shape: no member types`)
+	c.Assert(func() {
+		names.RegisterUnion((*Shape)(nil), Circle{}, Circle{})
+	}, qt.PanicMatches, `cannot register union for avro_test.Shape: duplicate member type avro_test.Circle`)
+	c.Assert(func() {
+		names.RegisterUnion((*Shape)(nil), &Circle{})
+	}, qt.PanicMatches, `cannot register union for avro_test.Shape: member type \*avro_test.Circle is a pointer`)
+	c.Assert(func() {
+		names.RegisterUnion((*Shape)(nil), 1)
+	}, qt.PanicMatches, `cannot register union for avro_test.Shape: member type int does not implement it`)
+}
+
+func TestWithNullableFields(t *testing.T) {
+	c := qt.New(t)
+	type Inner struct {
+		X int
+	}
+	type R struct {
+		Name  string `json:"name,omitempty"`
+		Count int
+		Tags  []string
+		Attrs map[string]int
+		Inner Inner `avro:",omitempty"`
+		P     *int
+		Level int `avro:",omitempty,default=3"`
+	}
+	names := new(avro.Names).WithNullableFields()
+	at, err := names.TypeOf(R{})
+	c.Assert(err, qt.IsNil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "name",
+			"type": ["null", "string"],
+			"default": null
+		}, {
+			"name": "Count",
+			"type": "long",
+			"default": 0
+		}, {
+			"name": "Tags",
+			"type": ["null", {
+				"type": "array",
+				"items": "string"
+			}],
+			"default": null
+		}, {
+			"name": "Attrs",
+			"type": ["null", {
+				"type": "map",
+				"values": "long"
+			}],
+			"default": null
+		}, {
+			"name": "Inner",
+			"type": ["null", {
+				"type": "record",
+				"name": "Inner",
+				"fields": [{
+					"name": "X",
+					"type": "long",
+					"default": 0
+				}]
+			}],
+			"default": null
+		}, {
+			"name": "P",
+			"type": ["null", "long"],
+			"default": null
+		}, {
+			"name": "Level",
+			"type": "long",
+			"default": 3
+		}]
+	}`))
+
+	// Nil slices and maps are distinct from empty ones.
+	for _, x := range []R{{
+		Name:  "x",
+		Count: 1,
+		Tags:  []string{"a"},
+		Attrs: map[string]int{"b": 2},
+		Inner: Inner{X: 3},
+	}, {
+		Tags:  []string{},
+		Attrs: map[string]int{},
+	}, {}} {
+		data, wType, err := names.Marshal(x)
+		c.Assert(err, qt.IsNil)
+		var x1 R
+		_, err = names.Unmarshal(data, &x1, wType)
+		c.Assert(err, qt.IsNil)
+		c.Assert(x1, qt.DeepEquals, x)
+	}
+
+	// Values written without nullable fields can
+	// be read into nullable fields.
+	data, wType, err := avro.Marshal(R{
+		Tags: []string{"a"},
+	})
+	c.Assert(err, qt.IsNil)
+	var r R
+	_, err = names.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r, qt.DeepEquals, R{
+		Tags:  []string{"a"},
+		Attrs: map[string]int{},
+	})
+
+	// Missing fields default to null.
+	type W struct {
+		Count int
+	}
+	data, wType, err = names.Marshal(W{Count: 5})
+	c.Assert(err, qt.IsNil)
+	r = R{}
+	_, err = names.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(r, qt.DeepEquals, R{
+		Count: 5,
+		Level: 3,
+	})
+}
+
+func TestWithNullableFieldsGeneratedType(t *testing.T) {
+	c := qt.New(t)
+	// The schema of a type generated by avrogo isn't changed.
+	at, err := new(avro.Names).WithNullableFields().TypeOf(TestRecord{})
+	c.Assert(err, qt.IsNil)
+	c.Assert(at.String(), qt.Equals, mustTypeOf(TestRecord{}).String())
+}
@@ -35,6 +35,7 @@ func (d *decoder) fill(n int) int {
 	}
 	// Slide any remaining bytes to the
 	// start of the buffer.
+	d.consumed += int64(d.scan)
 	total := copy(d.buf, d.buf[d.scan:])
 	d.scan = 0
 	d.buf = d.buf[:cap(d.buf)]
@@ -71,14 +72,8 @@ func (d *decoder) readFloat() float64 {
 }
 
 func (d *decoder) readBytes() []byte {
-	// TODO bounds-check readLong result.
-	// https://github.com/heetch/avro/issues/33
 	size := d.readLong()
-	// Make a temporary buffer for the bytes, limiting the size to
-	// an arbitrary sane default (~2.2GB).
-	if size < 0 || size > math.MaxInt32 {
-		d.error(fmt.Errorf("length out of range: %d", size))
-	}
+	d.checkBytesLength(size)
 	return d.readFixed(int(size))
 }
 
@@ -88,12 +83,18 @@ func (d *decoder) readFixed(size int) []byte {
 		// have, so use that.
 		return d.read(size)
 	}
+	if d.readErr != nil && size > len(d.buf)-d.scan {
+		// There's no more data to read, so avoid
+		// allocating a buffer that can never be filled.
+		d.error(io.ErrUnexpectedEOF)
+	}
 	buf := make([]byte, size)
 	n := copy(buf, d.buf[d.scan:])
 	_, err := io.ReadFull(d.r, buf[n:])
 	if err != nil {
 		d.error(err)
 	}
+	d.consumed += int64(size - n)
 	d.scan = len(d.buf)
 	return buf
 }
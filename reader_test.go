@@ -21,6 +21,7 @@ func TestReadFixed(t *testing.T) {
 	c.Assert(string(b), qt.Equals, "fgh")
 	b = d.readFixed(5)
 	c.Assert(string(b), qt.Equals, "ijklm")
+	c.Assert(d.consumed+int64(d.scan), qt.Equals, int64(13))
 	p := catch(func() {
 		d.readFixed(30)
 	})
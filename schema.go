@@ -0,0 +1,377 @@
+package avro
+
+import (
+	"fmt"
+
+	"github.com/actgardner/gogen-avro/v10/schema"
+)
+
+// Schema represents a node in an Avro schema, as returned by
+// Type.Schema. Its dynamic type is one of *RecordSchema,
+// *EnumSchema, *FixedSchema, *ArraySchema, *MapSchema,
+// *UnionSchema or *PrimitiveSchema.
+//
+// A named type is represented by the same value wherever it's
+// used, so a recursive schema holds a cycle. Schema values are
+// shared and must not be modified.
+type Schema interface {
+	// Kind returns the name of the kind of schema as it
+	// appears in the "type" attribute, such as "record",
+	// "array" or "long".
+	Kind() string
+
+	isSchema()
+}
+
+// RecordSchema represents an Avro record.
+type RecordSchema struct {
+	// Name holds the fully qualified name of the record.
+	Name string
+
+	// Doc holds the documentation for the record.
+	Doc string
+
+	// Aliases holds the fully qualified aliases of the record.
+	Aliases []string
+
+	// Fields holds the fields of the record in order.
+	Fields []*Field
+
+	// Attributes holds any attributes not defined
+	// by the Avro specification.
+	Attributes map[string]interface{}
+}
+
+// Field represents a field in an Avro record.
+type Field struct {
+	// Name holds the name of the field.
+	Name string
+
+	// Doc holds the documentation for the field.
+	Doc string
+
+	// Aliases holds the aliases of the field.
+	Aliases []string
+
+	// Type holds the schema of the field's values.
+	Type Schema
+
+	// HasDefault holds whether the field has a default value.
+	HasDefault bool
+
+	// Default holds the default value of the field in the
+	// form decoded from JSON by encoding/json.
+	Default interface{}
+
+	// Order holds the sort order of the field ("ascending",
+	// "descending" or "ignore"), or the empty string if
+	// it's not specified.
+	Order string
+
+	// Attributes holds any attributes not defined
+	// by the Avro specification.
+	Attributes map[string]interface{}
+}
+
+// EnumSchema represents an Avro enum.
+type EnumSchema struct {
+	// Name holds the fully qualified name of the enum.
+	Name string
+
+	// Doc holds the documentation for the enum.
+	Doc string
+
+	// Aliases holds the fully qualified aliases of the enum.
+	Aliases []string
+
+	// Symbols holds the symbols of the enum.
+	Symbols []string
+
+	// Default holds the default symbol of the enum,
+	// or the empty string if there's none.
+	Default string
+
+	// Attributes holds any attributes not defined
+	// by the Avro specification.
+	Attributes map[string]interface{}
+}
+
+// FixedSchema represents an Avro fixed type.
+type FixedSchema struct {
+	// Name holds the fully qualified name of the fixed type.
+	Name string
+
+	// Aliases holds the fully qualified aliases of the fixed type.
+	Aliases []string
+
+	// Size holds the number of bytes in a value.
+	Size int
+
+	// LogicalType holds the logical type of the fixed
+	// type, or the empty string if there's none.
+	LogicalType string
+
+	// Attributes holds any attributes not defined by the
+	// Avro specification, including those specific to the
+	// logical type, such as "precision" and "scale".
+	Attributes map[string]interface{}
+}
+
+// ArraySchema represents an Avro array.
+type ArraySchema struct {
+	// Items holds the schema of the array's items.
+	Items Schema
+
+	// Attributes holds any attributes not defined
+	// by the Avro specification.
+	Attributes map[string]interface{}
+}
+
+// MapSchema represents an Avro map.
+type MapSchema struct {
+	// Values holds the schema of the map's values.
+	Values Schema
+
+	// Attributes holds any attributes not defined
+	// by the Avro specification.
+	Attributes map[string]interface{}
+}
+
+// UnionSchema represents an Avro union.
+type UnionSchema struct {
+	// Types holds the members of the union in order.
+	Types []Schema
+}
+
+// PrimitiveSchema represents one of the Avro primitive types.
+type PrimitiveSchema struct {
+	// Type holds the name of the primitive type: one of
+	// "null", "boolean", "int", "long", "float", "double",
+	// "bytes" or "string".
+	Type string
+
+	// LogicalType holds the logical type of the
+	// type, or the empty string if there's none.
+	LogicalType string
+
+	// Attributes holds any attributes not defined by the
+	// Avro specification, including those specific to the
+	// logical type, such as "precision" and "scale".
+	Attributes map[string]interface{}
+}
+
+func (*RecordSchema) Kind() string      { return "record" }
+func (*EnumSchema) Kind() string        { return "enum" }
+func (*FixedSchema) Kind() string       { return "fixed" }
+func (*ArraySchema) Kind() string       { return "array" }
+func (*MapSchema) Kind() string         { return "map" }
+func (*UnionSchema) Kind() string       { return "union" }
+func (s *PrimitiveSchema) Kind() string { return s.Type }
+
+func (*RecordSchema) isSchema()    {}
+func (*EnumSchema) isSchema()      {}
+func (*FixedSchema) isSchema()     {}
+func (*ArraySchema) isSchema()     {}
+func (*MapSchema) isSchema()       {}
+func (*UnionSchema) isSchema()     {}
+func (*PrimitiveSchema) isSchema() {}
+
+// Schema returns the schema of the type. This makes it
+// possible to inspect the schema without parsing its
+// JSON representation.
+func (t *Type) Schema() Schema {
+	t.schemaOnce.Do(func() {
+		b := &schemaBuilder{
+			defined: make(map[schema.QualifiedName]Schema),
+		}
+		t.schemaNode = b.build(t.avroType)
+	})
+	return t.schemaNode
+}
+
+// Walk traverses the schema s in depth-first order. It starts
+// by calling f(s); if that returns true, Walk calls itself for
+// each of the schemas directly contained in s: the types of
+// record fields, the items of arrays, the values of maps and the
+// members of unions.
+//
+// Each named type is visited only once, so Walk
+// terminates when the schema is recursive.
+func Walk(s Schema, f func(Schema) bool) {
+	walk(s, f, make(map[Schema]bool))
+}
+
+func walk(s Schema, f func(Schema) bool, visited map[Schema]bool) {
+	switch s.(type) {
+	case *RecordSchema, *EnumSchema, *FixedSchema:
+		if visited[s] {
+			return
+		}
+		visited[s] = true
+	}
+	if !f(s) {
+		return
+	}
+	switch s := s.(type) {
+	case *RecordSchema:
+		for _, field := range s.Fields {
+			walk(field.Type, f, visited)
+		}
+	case *ArraySchema:
+		walk(s.Items, f, visited)
+	case *MapSchema:
+		walk(s.Values, f, visited)
+	case *UnionSchema:
+		for _, t := range s.Types {
+			walk(t, f, visited)
+		}
+	}
+}
+
+// schemaBuilder builds a Schema from a parsed Avro type.
+type schemaBuilder struct {
+	// defined holds the schemas built so far for named types.
+	defined map[schema.QualifiedName]Schema
+}
+
+func (b *schemaBuilder) build(at schema.AvroType) Schema {
+	switch at := at.(type) {
+	case *schema.Reference:
+		if s, ok := b.defined[at.TypeName]; ok {
+			return s
+		}
+		attrs := copyOfSchemaObj(at)
+		switch def := at.Def.(type) {
+		case *schema.RecordDefinition:
+			s := &RecordSchema{
+				Name:       def.AvroName().String(),
+				Doc:        def.Doc(),
+				Aliases:    qualifiedNameStrings(def.Aliases()),
+				Attributes: extraAttributes(attrs, "name", "namespace", "doc", "aliases", "fields"),
+			}
+			// Define the record before building its fields
+			// so that recursive references resolve to it.
+			b.defined[at.TypeName] = s
+			s.Fields = make([]*Field, len(def.Fields()))
+			for i, f := range def.Fields() {
+				s.Fields[i] = b.buildField(f)
+			}
+			return s
+		case *schema.EnumDefinition:
+			s := &EnumSchema{
+				Name:       def.AvroName().String(),
+				Doc:        def.Doc(),
+				Aliases:    qualifiedNameStrings(def.Aliases()),
+				Symbols:    def.Symbols(),
+				Default:    def.Default(),
+				Attributes: extraAttributes(attrs, "name", "namespace", "doc", "aliases", "symbols", "default"),
+			}
+			b.defined[at.TypeName] = s
+			return s
+		case *schema.FixedDefinition:
+			logicalType, _ := attrs["logicalType"].(string)
+			s := &FixedSchema{
+				Name:        def.AvroName().String(),
+				Aliases:     qualifiedNameStrings(def.Aliases()),
+				Size:        def.SizeBytes(),
+				LogicalType: logicalType,
+				Attributes:  extraAttributes(attrs, "name", "namespace", "aliases", "size", "logicalType"),
+			}
+			b.defined[at.TypeName] = s
+			return s
+		default:
+			panic(fmt.Errorf("unknown definition type %T", def))
+		}
+	case *schema.UnionField:
+		s := &UnionSchema{
+			Types: make([]Schema, len(at.ItemTypes())),
+		}
+		for i, t := range at.ItemTypes() {
+			s.Types[i] = b.build(t)
+		}
+		return s
+	case *schema.ArrayField:
+		return &ArraySchema{
+			Items:      b.build(at.ItemType()),
+			Attributes: extraAttributes(copyOfSchemaObj(at), "items"),
+		}
+	case *schema.MapField:
+		return &MapSchema{
+			Values:     b.build(at.ItemType()),
+			Attributes: extraAttributes(copyOfSchemaObj(at), "values"),
+		}
+	}
+	s := &PrimitiveSchema{
+		Type: primitiveTypeName(at),
+	}
+	// The definition of a primitive type is either
+	// its name or an object holding its attributes.
+	def, _ := at.Definition(emptyScope())
+	if attrs, ok := def.(map[string]interface{}); ok {
+		s.LogicalType, _ = attrs["logicalType"].(string)
+		s.Attributes = extraAttributes(attrs, "logicalType")
+	}
+	return s
+}
+
+func (b *schemaBuilder) buildField(f *schema.Field) *Field {
+	attrs := copyOfSchemaObj(f)
+	order, _ := attrs["order"].(string)
+	return &Field{
+		Name:       f.Name(),
+		Doc:        f.Doc(),
+		Aliases:    f.Aliases(),
+		Type:       b.build(f.Type()),
+		HasDefault: f.HasDefault(),
+		Default:    f.Default(),
+		Order:      order,
+		Attributes: extraAttributes(attrs, "name", "doc", "aliases", "type", "default", "order"),
+	}
+}
+
+func primitiveTypeName(at schema.AvroType) string {
+	switch at.(type) {
+	case *schema.NullField:
+		return "null"
+	case *schema.BoolField:
+		return "boolean"
+	case *schema.IntField:
+		return "int"
+	case *schema.LongField:
+		return "long"
+	case *schema.FloatField:
+		return "float"
+	case *schema.DoubleField:
+		return "double"
+	case *schema.BytesField:
+		return "bytes"
+	case *schema.StringField:
+		return "string"
+	}
+	panic(fmt.Errorf("unknown Avro type %T", at))
+}
+
+// extraAttributes returns the attributes in the schema object
+// obj other than "type" and the given standard attributes,
+// or nil if there are none. It modifies obj.
+func extraAttributes(obj map[string]interface{}, standard ...string) map[string]interface{} {
+	delete(obj, "type")
+	for _, name := range standard {
+		delete(obj, name)
+	}
+	if len(obj) == 0 {
+		return nil
+	}
+	return obj
+}
+
+func qualifiedNameStrings(names []schema.QualifiedName) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	s := make([]string, len(names))
+	for i, name := range names {
+		s[i] = name.String()
+	}
+	return s
+}
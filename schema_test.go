@@ -0,0 +1,215 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestTypeSchema(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.ParseType(`{
+		"type": "record",
+		"name": "R",
+		"namespace": "com.example",
+		"doc": "A record.",
+		"aliases": ["OldR", "other.R"],
+		"go.package": "example.com/r",
+		"fields": [{
+			"name": "a",
+			"doc": "Field a.",
+			"aliases": ["oldA"],
+			"type": "int",
+			"default": 42,
+			"order": "descending",
+			"custom": true
+		}, {
+			"name": "e",
+			"type": {
+				"type": "enum",
+				"name": "E",
+				"doc": "An enum.",
+				"symbols": ["Unknown", "X", "Y"],
+				"default": "Unknown"
+			}
+		}, {
+			"name": "f",
+			"type": {
+				"type": "fixed",
+				"name": "other.F",
+				"size": 8,
+				"logicalType": "decimal",
+				"precision": 10,
+				"scale": 2
+			}
+		}, {
+			"name": "t",
+			"type": {
+				"type": "long",
+				"logicalType": "timestamp-micros"
+			}
+		}, {
+			"name": "arr",
+			"type": {
+				"type": "array",
+				"items": "E",
+				"size-hint": 10
+			}
+		}, {
+			"name": "m",
+			"type": {
+				"type": "map",
+				"values": "string"
+			}
+		}, {
+			"name": "u",
+			"type": ["null", "other.F"],
+			"default": null
+		}]
+	}`)
+	c.Assert(err, qt.IsNil)
+	enumSchema := &avro.EnumSchema{
+		Name:    "com.example.E",
+		Doc:     "An enum.",
+		Symbols: []string{"Unknown", "X", "Y"},
+		Default: "Unknown",
+	}
+	fixedSchema := &avro.FixedSchema{
+		Name:        "other.F",
+		Size:        8,
+		LogicalType: "decimal",
+		Attributes: map[string]interface{}{
+			"precision": 10.0,
+			"scale":     2.0,
+		},
+	}
+	c.Assert(at.Schema(), qt.DeepEquals, &avro.RecordSchema{
+		Name:    "com.example.R",
+		Doc:     "A record.",
+		Aliases: []string{"com.example.OldR", "other.R"},
+		Attributes: map[string]interface{}{
+			"go.package": "example.com/r",
+		},
+		Fields: []*avro.Field{{
+			Name:       "a",
+			Doc:        "Field a.",
+			Aliases:    []string{"oldA"},
+			Type:       &avro.PrimitiveSchema{Type: "int"},
+			HasDefault: true,
+			Default:    42.0,
+			Order:      "descending",
+			Attributes: map[string]interface{}{
+				"custom": true,
+			},
+		}, {
+			Name: "e",
+			Type: enumSchema,
+		}, {
+			Name: "f",
+			Type: fixedSchema,
+		}, {
+			Name: "t",
+			Type: &avro.PrimitiveSchema{
+				Type:        "long",
+				LogicalType: "timestamp-micros",
+			},
+		}, {
+			Name: "arr",
+			Type: &avro.ArraySchema{
+				Items: enumSchema,
+				Attributes: map[string]interface{}{
+					"size-hint": 10.0,
+				},
+			},
+		}, {
+			Name: "m",
+			Type: &avro.MapSchema{
+				Values: &avro.PrimitiveSchema{Type: "string"},
+			},
+		}, {
+			Name: "u",
+			Type: &avro.UnionSchema{
+				Types: []avro.Schema{
+					&avro.PrimitiveSchema{Type: "null"},
+					fixedSchema,
+				},
+			},
+			HasDefault: true,
+		}},
+	})
+	s := at.Schema().(*avro.RecordSchema)
+	// Named types are represented by the same value wherever they're used.
+	c.Assert(s.Fields[4].Type.(*avro.ArraySchema).Items, qt.Equals, s.Fields[1].Type)
+	// The schema is only calculated once.
+	c.Assert(at.Schema(), qt.Equals, avro.Schema(s))
+}
+
+func TestTypeSchemaFromGoType(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A int `avro:"a,doc=The a field,default=3"`
+		B *string
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.IsNil)
+	c.Assert(at.Schema(), qt.DeepEquals, &avro.RecordSchema{
+		Name: "R",
+		Fields: []*avro.Field{{
+			Name:       "a",
+			Doc:        "The a field",
+			Type:       &avro.PrimitiveSchema{Type: "long"},
+			HasDefault: true,
+			Default:    3.0,
+		}, {
+			Name: "B",
+			Type: &avro.UnionSchema{
+				Types: []avro.Schema{
+					&avro.PrimitiveSchema{Type: "null"},
+					&avro.PrimitiveSchema{Type: "string"},
+				},
+			},
+			HasDefault: true,
+		}},
+	})
+}
+
+func TestWalkRecursiveSchema(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.ParseType(`{
+		"type": "record",
+		"name": "List",
+		"fields": [{
+			"name": "item",
+			"type": "string"
+		}, {
+			"name": "next",
+			"type": ["null", "List"]
+		}, {
+			"name": "children",
+			"type": {
+				"type": "map",
+				"values": "List"
+			}
+		}]
+	}`)
+	c.Assert(err, qt.IsNil)
+	s := at.Schema().(*avro.RecordSchema)
+	c.Assert(s.Fields[1].Type.(*avro.UnionSchema).Types[1], qt.Equals, avro.Schema(s))
+
+	var kinds []string
+	avro.Walk(s, func(s avro.Schema) bool {
+		kinds = append(kinds, s.Kind())
+		return true
+	})
+	c.Assert(kinds, qt.DeepEquals, []string{"record", "string", "union", "null", "map"})
+
+	// Returning false skips the contents of a schema.
+	kinds = nil
+	avro.Walk(s, func(s avro.Schema) bool {
+		kinds = append(kinds, s.Kind())
+		return s.Kind() != "union"
+	})
+	c.Assert(kinds, qt.DeepEquals, []string{"record", "string", "union", "map"})
+}
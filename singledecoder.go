@@ -95,7 +95,7 @@ func (c *SingleDecoder) Unmarshal(ctx context.Context, data []byte, x interface{
 	if err != nil {
 		return nil, fmt.Errorf("cannot unmarshal: %w", err)
 	}
-	return unmarshal(nil, body, prog, v)
+	return unmarshal(nil, body, prog, v, c.names.limits())
 }
 
 func (c *SingleDecoder) getProgram(ctx context.Context, vt reflect.Type, wID int64) (*decodeProgram, error) {
@@ -19,6 +19,9 @@ type Type struct {
 	// calculate it lazily and store it in canonical[opts].
 	canonical     [RetainAll + 1]string
 	canonicalOnce [RetainAll + 1]sync.Once
+	// The schema model is also calculated lazily.
+	schemaNode Schema
+	schemaOnce sync.Once
 }
 
 // ParseType parses an Avro schema in the format defined by the Avro
@@ -34,6 +37,17 @@ func ParseType(s string) (*Type, error) {
 	}, nil
 }
 
+// MustParseType is like ParseType but panics if the
+// schema cannot be parsed. It's intended for use in
+// package-level variable initializers.
+func MustParseType(s string) *Type {
+	t, err := ParseType(s)
+	if err != nil {
+		panic(fmt.Errorf("avro: cannot parse schema: %v", err))
+	}
+	return t
+}
+
 func (t *Type) String() string {
 	return t.schema
 }
@@ -176,12 +190,15 @@ func (c *canonicalizer) canonicalValue1(at schema.AvroType) interface{} {
 		c.defined[at.TypeName] = true
 		switch def := at.Def.(type) {
 		case *schema.EnumDefinition:
-			// TODO enum default
-			return canonicalFields{
+			cf := canonicalFields{
 				Name:    def.AvroName().String(),
 				Type:    "enum",
 				Symbols: def.Symbols(),
 			}
+			if def.Default() != "" && (c.opts&RetainDefaults) != 0 {
+				cf.Default = def.Default()
+			}
+			return cf
 		case *schema.FixedDefinition:
 			return canonicalFields{
 				Name: def.AvroName().String(),
@@ -232,3 +249,36 @@ func (t *Type) Name() string {
 	}
 	return ref.TypeName.String()
 }
+
+// Fingerprint returns the 64-bit Rabin fingerprint (CRC-64-AVRO)
+// of the type's Parsing Canonical Form, as documented here:
+// https://avro.apache.org/docs/1.9.1/spec.html#schema_fingerprints
+//
+// Two types with the same fingerprint almost certainly have
+// the same canonical form, so the fingerprint can be used as
+// a compact identifier for a schema.
+func (t *Type) Fingerprint() uint64 {
+	return rabinFingerprint(t.CanonicalString(0))
+}
+
+// rabinEmpty is the CRC-64-AVRO fingerprint of the empty string.
+const rabinEmpty = 0xc15d213aa4d7a795
+
+var rabinTable = func() (table [256]uint64) {
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (rabinEmpty & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return table
+}()
+
+func rabinFingerprint(s string) uint64 {
+	fp := uint64(rabinEmpty)
+	for i := 0; i < len(s); i++ {
+		fp = (fp >> 8) ^ rabinTable[byte(fp)^s[i]]
+	}
+	return fp
+}
@@ -262,6 +262,25 @@ var canonicalStringTests = []struct {
               }]
         }`,
 	out: `{"name":"R","type":"record","fields":[{"name":"U","type":["null","string"],"default":null}]}`,
+}, {
+	testName: "enum-with-default",
+	in: `{
+	"type": "enum",
+	"name": "E",
+	"symbols": ["a", "b"],
+	"default": "a"
+}`,
+	out: `{"name":"E","type":"enum","symbols":["a","b"]}`,
+}, {
+	testName: "enum-with-default-retain-defaults",
+	opts:     avro.RetainDefaults,
+	in: `{
+	"type": "enum",
+	"name": "E",
+	"symbols": ["a", "b"],
+	"default": "a"
+}`,
+	out: `{"name":"E","type":"enum","symbols":["a","b"],"default":"a"}`,
 }, {
 	testName: "empty-record",
 	in: `{
@@ -295,6 +314,43 @@ func TestCanonicalString(t *testing.T) {
 	}
 }
 
+var fingerprintTests = []struct {
+	testName string
+	in       string
+	out      uint64
+}{{
+	testName: "null",
+	in:       `"null"`,
+	out:      7195948357588979594,
+}, {
+	testName: "int",
+	in:       `"int"`,
+	out:      8247732601305521295,
+}, {
+	testName: "non-canonical-form",
+	in:       `{"type": "int", "logicalType": "date"}`,
+	out:      8247732601305521295,
+}}
+
+func TestFingerprint(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range fingerprintTests {
+		c.Run(test.testName, func(c *qt.C) {
+			t, err := avro.ParseType(test.in)
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(t.Fingerprint(), qt.Equals, test.out)
+		})
+	}
+}
+
+func TestMustParseType(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(avro.MustParseType(`"string"`).String(), qt.Equals, `"string"`)
+	c.Assert(func() {
+		avro.MustParseType(`"foo"`)
+	}, qt.PanicMatches, `(?s)avro: cannot parse schema: .*Unable to resolve type reference foo`)
+}
+
 func mustParseType(s string) *avro.Type {
 	t, err := avro.ParseType(s)
 	if err != nil {
@@ -0,0 +1,101 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
+)
+
+// sealedUnion is a union type in the form generated
+// by avrogo -sealed.
+type sealedUnion interface {
+	isSealedUnion()
+}
+
+type sealedString string
+
+func (sealedString) isSealedUnion() {}
+
+type sealedRecord struct {
+	X int
+}
+
+func (sealedRecord) isSealedUnion() {}
+
+type sealedUnionRecord struct {
+	U sealedUnion
+}
+
+func (sealedUnionRecord) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"type":"record","name":"sealedUnionRecord","fields":[{"name":"U","type":["null","string",{"type":"record","name":"sealedRecord","fields":[{"name":"X","type":"long"}]}]}]}`,
+		Unions: []avrotypegen.UnionInfo{
+			0: {
+				Type: new(sealedUnion),
+				Union: []avrotypegen.UnionInfo{{
+					Type: nil,
+				}, {
+					Type: new(sealedString),
+				}, {
+					Type: new(sealedRecord),
+				}},
+			},
+		},
+	}
+}
+
+func TestSealedUnionRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	for _, u := range []sealedUnion{nil, sealedString("x"), sealedRecord{X: 5}} {
+		data, wType, err := avro.Marshal(sealedUnionRecord{U: u})
+		c.Assert(err, qt.IsNil)
+		var x sealedUnionRecord
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.IsNil)
+		c.Assert(x, qt.DeepEquals, sealedUnionRecord{U: u})
+	}
+}
+
+func TestSealedUnionPointerMember(t *testing.T) {
+	c := qt.New(t)
+	// A pointer to a member type implements the interface
+	// too, so it's encoded as the member type.
+	data, wType, err := avro.Marshal(sealedUnionRecord{U: &sealedRecord{X: 5}})
+	c.Assert(err, qt.IsNil)
+	var x sealedUnionRecord
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.IsNil)
+	c.Assert(x, qt.DeepEquals, sealedUnionRecord{U: sealedRecord{X: 5}})
+}
+
+type badSealedUnionRecord struct {
+	U sealedUnion
+}
+
+func (badSealedUnionRecord) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"type":"record","name":"badSealedUnionRecord","fields":[{"name":"U","type":["long","string"]}]}`,
+		Unions: []avrotypegen.UnionInfo{
+			0: {
+				Type: new(sealedUnion),
+				Union: []avrotypegen.UnionInfo{{
+					Type: new(int64),
+				}, {
+					Type: new(sealedString),
+				}},
+			},
+		},
+	}
+}
+
+func TestSealedUnionMemberMustImplementInterface(t *testing.T) {
+	c := qt.New(t)
+	wType, err := avro.TypeOf(badSealedUnionRecord{})
+	c.Assert(err, qt.IsNil)
+	var x badSealedUnionRecord
+	_, err = avro.Unmarshal([]byte{0, 2}, &x, wType)
+	c.Assert(err, qt.ErrorMatches, `analysis failed: .*union member type int64 does not implement avro_test.sealedUnion`)
+}
@@ -0,0 +1,62 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
+)
+
+// wrapperValue is a wrapper type in the form generated by avrogo
+// for a schema whose top level type is a union.
+type wrapperValue struct {
+	Value interface{}
+}
+
+func (wrapperValue) AvroWrapper() avrotypegen.WrapperInfo {
+	return avrotypegen.WrapperInfo{
+		Schema: `["long",{"type":"record","name":"wrapperItem","fields":[{"name":"S","type":"string"}]}]`,
+		Union: avrotypegen.UnionInfo{
+			Type: new(interface{}),
+			Union: []avrotypegen.UnionInfo{{
+				Type: new(int64),
+			}, {
+				Type: new(wrapperItem),
+			}},
+		},
+	}
+}
+
+type wrapperItem struct {
+	S string
+}
+
+func TestWrapperRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	wType, err := avro.TypeOf(wrapperValue{})
+	c.Assert(err, qt.IsNil)
+	c.Assert(wType.String(), qt.Equals, `["long",{"type":"record","name":"wrapperItem","fields":[{"name":"S","type":"string"}]}]`)
+
+	for _, v := range []interface{}{int64(99), wrapperItem{S: "hello"}} {
+		data, wType, err := avro.Marshal(wrapperValue{Value: v})
+		c.Assert(err, qt.IsNil)
+		var x wrapperValue
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.IsNil)
+		c.Assert(x, qt.DeepEquals, wrapperValue{Value: v})
+	}
+
+	_, _, err = avro.Marshal(wrapperValue{Value: "x"})
+	c.Assert(err, qt.ErrorMatches, `unknown type for union string`)
+}
+
+func TestWrapperOnlyAtTopLevel(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		W wrapperValue
+	}
+	_, err := avro.TypeOf(R{})
+	c.Assert(err, qt.ErrorMatches, `wrapper type avro_test.wrapperValue can only be used at the top level`)
+}